@@ -0,0 +1,154 @@
+package testcontainers
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/fileutils"
+)
+
+// readDockerignore reads the patterns of the .dockerignore file at the root of the build context, if any.
+// Like the Docker CLI, the Dockerfile and the .dockerignore file are always sent to the daemon.
+func readDockerignore(fsys fs.FS, dockerfile string) ([]string, error) {
+	f, err := fsys.Open(".dockerignore")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		exception := strings.HasPrefix(pattern, "!")
+		if exception {
+			pattern = strings.TrimSpace(pattern[1:])
+		}
+		pattern = strings.TrimPrefix(filepath.ToSlash(path.Clean(pattern)), "/")
+		if exception {
+			pattern = "!" + pattern
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	return append(patterns, "!"+path.Clean(filepath.ToSlash(dockerfile)), "!.dockerignore"), nil
+}
+
+// tarFS archives the files of fsys as a build context, leaving out the files matching the excluded patterns.
+// The files, e.g. an inline Dockerfile, are added to the archive and replace the files of fsys with the same name.
+// fsys can be nil to only archive the files.
+func tarFS(fsys fs.FS, excludes []string, files map[string]string) (io.Reader, error) {
+	matcher, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	tw := tar.NewWriter(buffer)
+
+	if fsys != nil {
+		if err := tarFSFiles(tw, fsys, matcher, files); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(files[name])),
+			Typeflag: tar.TypeReg,
+			ModTime:  time.Now(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(tw, files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// tarFSFiles writes the files of fsys which aren't excluded nor replaced to the archive
+func tarFSFiles(tw *tar.Writer, fsys fs.FS, matcher *fileutils.PatternMatcher, replaced map[string]string) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if _, ok := replaced[name]; name == "." || ok {
+			return nil
+		}
+
+		excluded, err := matcher.Matches(name)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			if d.IsDir() && !matcher.Exclusions() {
+				return fs.SkipDir
+			}
+			// files in the directory might be re-included by an exception
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
@@ -0,0 +1,128 @@
+package testcontainers
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarEntries returns the sorted names of the entries of a tar archive
+func tarEntries(t *testing.T, r io.Reader) []string {
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReadDockerignore(t *testing.T) {
+	fsys := fstest.MapFS{
+		".dockerignore": {Data: []byte("# build output\n\n/bin\n*.log\n!important.log\n Dockerfile.test \n")},
+	}
+
+	patterns, err := readDockerignore(fsys, "Dockerfile.test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bin", "*.log", "!important.log", "Dockerfile.test", "!Dockerfile.test", "!.dockerignore"}, patterns)
+
+	patterns, err = readDockerignore(fstest.MapFS{}, "Dockerfile")
+	require.NoError(t, err)
+	assert.Nil(t, patterns, "nothing is excluded without .dockerignore")
+}
+
+func TestContextFS(t *testing.T) {
+	req := &ContainerRequest{
+		FromDockerfile: FromDockerfile{
+			ContextFS: fstest.MapFS{
+				".dockerignore":     {Data: []byte("bin\n*.log\n!important.log\n")},
+				"Dockerfile":        {Data: []byte("FROM alpine\n")},
+				"bin/app":           {Data: []byte("binary")},
+				"debug.log":         {Data: []byte("debug")},
+				"important.log":     {Data: []byte("important")},
+				"fixtures/data.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+	assert.True(t, req.ShouldBuildImage())
+
+	buildContext, err := req.GetContext()
+	require.NoError(t, err)
+	assert.Equal(t, []string{".dockerignore", "Dockerfile", "fixtures/", "fixtures/data.sql", "important.log"}, tarEntries(t, buildContext))
+}
+
+func TestContextDirHonorsDockerignore(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		".dockerignore": "*.log\nDockerfile\n",
+		"Dockerfile":    "FROM alpine\n",
+		"debug.log":     "debug",
+		"fixtures.sql":  "SELECT 1;",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), os.ModePerm))
+	}
+
+	req := &ContainerRequest{FromDockerfile: FromDockerfile{Context: dir}}
+	buildContext, err := req.GetContext()
+	require.NoError(t, err)
+	assert.Equal(t, []string{".dockerignore", "Dockerfile", "fixtures.sql"}, tarEntries(t, buildContext))
+}
+
+func TestInlineDockerfile(t *testing.T) {
+	req := &ContainerRequest{
+		FromDockerfile: FromDockerfile{DockerfileContent: "FROM alpine\nRUN apk add socat\n"},
+	}
+	assert.True(t, req.ShouldBuildImage())
+
+	buildContext, err := req.GetContext()
+	require.NoError(t, err)
+	tr := tar.NewReader(buildContext)
+	header, err := tr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "Dockerfile", header.Name)
+	content, err := ioutil.ReadAll(tr)
+	require.NoError(t, err)
+	assert.Equal(t, "FROM alpine\nRUN apk add socat\n", string(content))
+	_, err = tr.Next()
+	assert.Equal(t, io.EOF, err, "the context only contains the Dockerfile")
+
+	t.Run("with context", func(t *testing.T) {
+		req := &ContainerRequest{
+			FromDockerfile: FromDockerfile{
+				ContextFS: fstest.MapFS{
+					"Dockerfile.test": {Data: []byte("FROM scratch\n")},
+					"fixtures.sql":    {Data: []byte("SELECT 1;")},
+				},
+				Dockerfile:        "Dockerfile.test",
+				DockerfileContent: "FROM postgres\nCOPY fixtures.sql /docker-entrypoint-initdb.d/\n",
+			},
+		}
+
+		buildContext, err := req.GetContext()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Dockerfile.test", "fixtures.sql"}, tarEntries(t, buildContext), "the inline Dockerfile replaces the one of the context")
+	})
+
+	t.Run("with context archive", func(t *testing.T) {
+		req := &ContainerRequest{
+			FromDockerfile: FromDockerfile{ContextArchive: &bytes.Buffer{}, DockerfileContent: "FROM alpine\n"},
+		}
+
+		_, err := req.GetContext()
+		assert.Error(t, err)
+	})
+}
@@ -10,21 +10,25 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 	"gopkg.in/yaml.v3"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
 const (
-	envProjectName = "COMPOSE_PROJECT_NAME"
-	envComposeFile = "COMPOSE_FILE"
+	envProjectName   = "COMPOSE_PROJECT_NAME"
+	envComposeFile   = "COMPOSE_FILE"
+	envParallelLimit = "COMPOSE_PARALLEL_LIMIT"
 )
 
 var (
@@ -53,16 +57,71 @@ func (c composeVersion2) Format(parts ...string) string {
 // DockerCompose defines the contract for running Docker Compose
 type DockerCompose interface {
 	Down() ExecError
+	DownWithOptions(ComposeDownOptions) ExecError
 	Invoke() ExecError
+	Ps(context.Context) ([]ComposeContainerSummary, error)
+	Config(context.Context) ([]byte, error)
+	RunComposeCommand(context.Context, ...string) ([]byte, error)
+	ProjectName() string
+	Networks(context.Context) ([]string, error)
+	Events(context.Context) (<-chan ComposeEvent, error)
+	RestartService(context.Context, string, time.Duration) error
+	Kill(context.Context, string, ...string) error
+	CopyToService(context.Context, string, string, string, int64) error
+	CopyFromService(context.Context, string, string) (io.ReadCloser, error)
+	ServiceMappedPort(context.Context, string, nat.Port) (nat.Port, error)
+	ServicePortEndpoint(context.Context, string, nat.Port, string) (string, error)
 	WaitForService(string, wait.Strategy) DockerCompose
+	WaitForAllReplicas(string, wait.Strategy) DockerCompose
+	WaitForAllServices() DockerCompose
 	WithCommand([]string) DockerCompose
 	WithEnv(map[string]string) DockerCompose
 	WithExposedService(string, int, wait.Strategy) DockerCompose
+	WithPullPolicy(ComposePullPolicy) DockerCompose
+	WithPullBeforeUp() DockerCompose
+	WithBuildOptions(ComposeBuildOptions) DockerCompose
+	WithParallelism(int) DockerCompose
+}
+
+// ComposePullPolicy defines when the images of the services are pulled on up
+type ComposePullPolicy string
+
+// possible pull policies
+const (
+	PullPolicyAlways  ComposePullPolicy = "always"  // always pull the images, e.g. on CI
+	PullPolicyMissing ComposePullPolicy = "missing" // only pull missing images, the compose default
+	PullPolicyNever   ComposePullPolicy = "never"   // never pull images, they must exist locally
+)
+
+// ComposeBuildOptions controls how the images of services with a build section are built before up
+type ComposeBuildOptions struct {
+	NoCache   bool              // don't use the cache, so that local source changes are always picked up
+	BuildArgs map[string]string // build-time variables passed to the builds of all services
+	BuildKit  bool              // build using BuildKit, which is only the default for compose v2
+	Builder   string            // name of the buildx builder to use, requires BuildKit
+}
+
+// ComposeVolumeRemoval defines which volumes of the services are removed on down
+type ComposeVolumeRemoval uint
+
+// possible volume removals
+const (
+	RemoveAllVolumes       ComposeVolumeRemoval = iota // remove named and anonymous volumes, the default
+	RemoveAnonymousVolumes                             // keep named volumes, e.g. long-lived data volumes
+	RemoveNoVolumes                                    // keep all volumes
+)
+
+// ComposeDownOptions controls which resources of the compose project are removed on down
+type ComposeDownOptions struct {
+	StopTimeout  time.Duration        // time to wait for the containers to stop before they're killed, the compose default if zero
+	Volumes      ComposeVolumeRemoval // which volumes to remove
+	KeepNetworks bool                 // keep the networks, orphaned containers aren't removed then
 }
 
 type waitService struct {
 	service       string
 	publishedPort int
+	allReplicas   bool // apply the strategy to all containers of the service instead of a single one
 }
 
 // LocalDockerCompose represents a Docker Compose execution using local binary
@@ -79,12 +138,28 @@ type LocalDockerCompose struct {
 	Services             map[string]interface{}
 	waitStrategySupplied bool
 	WaitStrategyMap      map[waitService]wait.Strategy
+	provider             *DockerProvider
+	stacks               [][]byte // the stacks passed with WithStackReaders and the generated ones, applied after the files
+	stackErr             error    // error occurred while reading the stacks passed with WithStackReaders
+	pullPolicy           ComposePullPolicy
+	pullBeforeUp         bool
+	buildOptions         *ComposeBuildOptions
+	waitForAllServices   bool
+	parallelism          int
+	executableArgs       []string // arguments preceding all commands, e.g. compose when using the docker CLI plugin
 }
 
 type (
 	// LocalDockerComposeOptions defines options applicable to LocalDockerCompose
 	LocalDockerComposeOptions struct {
-		Logger Logging
+		Logger          Logging
+		StackReaders    []io.Reader // compose stack definitions, applied after the compose files
+		AttachIfRunning bool        // attach to the running containers of the project instead of invoking up
+		UsePlugin       bool        // use the compose plugin of the docker CLI even if docker-compose is installed
+		PluginFallback  bool        // use the compose plugin of the docker CLI if docker-compose can't parse the compose files
+
+		ImageSubstitutors []ImageSubstitutor // applied to the images of the services after the hub.image.name.prefix
+		ExternalNetworks  []ExternalNetwork  // networks created outside of the project, which all services are attached to
 	}
 
 	// LocalDockerComposeOption defines a common interface to modify LocalDockerComposeOptions
@@ -101,6 +176,96 @@ func (f LocalDockerComposeOptionsFunc) ApplyToLocalCompose(opts *LocalDockerComp
 	f(opts)
 }
 
+// WithStackReaders adds compose stack definitions which are read from the given readers
+// e.g. compose files embedded into the test binary with go:embed.
+// The stacks are applied in the given order after the compose files passed to NewLocalDockerCompose.
+// A single stack is piped to compose through STDIN, multiple ones are written to temporary files for each command.
+func WithStackReaders(readers ...io.Reader) LocalDockerComposeOption {
+	return LocalDockerComposeOptionsFunc(func(opts *LocalDockerComposeOptions) {
+		opts.StackReaders = append(opts.StackReaders, readers...)
+	})
+}
+
+// WithStackContent adds a compose stack definition from its YAML content
+func WithStackContent(content []byte) LocalDockerComposeOption {
+	return WithStackReaders(bytes.NewReader(content))
+}
+
+// WithProject adds a compose stack defined in Go code. The project can be any value which
+// gopkg.in/yaml.v3 marshals to a valid compose stack, e.g. a compose-go types.Project or a plain map,
+// so the stack can be generated or mutated (overriding images, adding labels) before it is started.
+func WithProject(project interface{}) LocalDockerComposeOption {
+	return LocalDockerComposeOptionsFunc(func(opts *LocalDockerComposeOptions) {
+		content, err := marshalProject(project)
+		if err != nil {
+			opts.StackReaders = append(opts.StackReaders, errReader{err: err})
+			return
+		}
+		opts.StackReaders = append(opts.StackReaders, bytes.NewReader(content))
+	})
+}
+
+// AttachIfRunning reuses the containers of a compose project with the same identifier if it's already running,
+// e.g. a stack started once by the developer, instead of invoking up. The wait strategies are still applied.
+func AttachIfRunning() LocalDockerComposeOption {
+	return LocalDockerComposeOptionsFunc(func(opts *LocalDockerComposeOptions) {
+		opts.AttachIfRunning = true
+	})
+}
+
+// UseComposePlugin runs compose using the compose plugin of the docker CLI, i.e. docker compose, instead of
+// the docker-compose binary. The plugin is used automatically if docker-compose isn't installed.
+func UseComposePlugin() LocalDockerComposeOption {
+	return LocalDockerComposeOptionsFunc(func(opts *LocalDockerComposeOptions) {
+		opts.UsePlugin = true
+	})
+}
+
+// FallbackToComposePlugin runs compose using the compose plugin of the docker CLI if the installed docker-compose
+// binary can't parse the compose files, e.g. because they use features of a newer compose file format.
+func FallbackToComposePlugin() LocalDockerComposeOption {
+	return LocalDockerComposeOptionsFunc(func(opts *LocalDockerComposeOptions) {
+		opts.PluginFallback = true
+	})
+}
+
+// ExternalNetwork is a network created outside of a compose project, e.g. with GenericNetwork, which the services of
+// the project are attached to with WithExternalNetwork
+type ExternalNetwork struct {
+	Name    string
+	Aliases map[string][]string // additional aliases of the services on the network, by service name
+}
+
+// WithExternalNetwork attaches all services of the compose project to a network created outside of it, e.g. the
+// network of containers created with GenericContainer, so that they can reach the services by their service names
+// and aliases, and vice versa. The services stay attached to the default network of the project, too. Services with
+// a network_mode are skipped.
+func WithExternalNetwork(name string, aliases map[string][]string) LocalDockerComposeOption {
+	return LocalDockerComposeOptionsFunc(func(opts *LocalDockerComposeOptions) {
+		opts.ExternalNetworks = append(opts.ExternalNetworks, ExternalNetwork{Name: name, Aliases: aliases})
+	})
+}
+
+// marshalProject marshals the project to YAML, yaml.Marshal panics on values it can't marshal, e.g. functions
+func marshalProject(project interface{}) (content []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to marshal compose project: %v", r)
+		}
+	}()
+
+	return yaml.Marshal(project)
+}
+
+// errReader is an io.Reader which always fails with the given error
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(_ []byte) (int, error) {
+	return 0, r.err
+}
+
 // NewLocalDockerCompose returns an instance of the local Docker Compose, using an
 // array of Docker Compose file paths and an identifier for the Compose execution.
 //
@@ -124,6 +289,11 @@ func NewLocalDockerCompose(filePaths []string, identifier string, opts ...LocalD
 		dc.Executable = "docker-compose.exe"
 	}
 
+	if dc.UsePlugin || (which(dc.Executable) != nil && composePluginAvailable()) {
+		dc.Executable = dockerExecutable()
+		dc.executableArgs = []string{"compose"}
+	}
+
 	dc.ComposeFilePaths = filePaths
 
 	dc.absComposeFilePaths = make([]string, len(filePaths))
@@ -132,9 +302,24 @@ func NewLocalDockerCompose(filePaths []string, identifier string, opts ...LocalD
 		dc.absComposeFilePaths[i] = abs
 	}
 
+	dc.stackErr = dc.readStacks()
+
+	if dc.PluginFallback && dc.executableArgs == nil && dc.stackErr == nil && !dc.canParse() && composePluginAvailable() {
+		dc.Logger.Printf("%s can't parse the compose files, falling back to the compose plugin", dc.Executable)
+		dc.Executable = dockerExecutable()
+		dc.executableArgs = []string{"compose"}
+	}
+
 	_ = dc.determineVersion()
 	_ = dc.validate()
 
+	if dc.stackErr == nil {
+		dc.stackErr = dc.writeImageSubstitutions(configureTC())
+	}
+	if dc.stackErr == nil {
+		dc.stackErr = dc.writeExternalNetworks()
+	}
+
 	dc.Identifier = strings.ToLower(identifier)
 	dc.waitStrategySupplied = false
 	dc.WaitStrategyMap = make(map[waitService]wait.Strategy)
@@ -144,7 +329,184 @@ func NewLocalDockerCompose(filePaths []string, identifier string, opts ...LocalD
 
 // Down executes docker-compose down
 func (dc *LocalDockerCompose) Down() ExecError {
-	return executeCompose(dc, []string{"down", "--remove-orphans", "--volumes"})
+	return dc.DownWithOptions(ComposeDownOptions{})
+}
+
+// DownWithOptions executes docker-compose down, removing only the resources selected by the options
+func (dc *LocalDockerCompose) DownWithOptions(opts ComposeDownOptions) ExecError {
+	var timeout []string
+	if opts.StopTimeout > 0 {
+		timeout = []string{"--timeout", strconv.Itoa(int(opts.StopTimeout.Seconds()))}
+	}
+
+	// down can't remove only anonymous volumes nor keep the networks,
+	// so the containers are stopped and removed individually then
+	if opts.KeepNetworks || opts.Volumes == RemoveAnonymousVolumes {
+		if execErr := executeCompose(dc, append([]string{"stop"}, timeout...)); execErr.Error != nil {
+			return execErr
+		}
+
+		rm := []string{"rm", "--force"}
+		if opts.Volumes != RemoveNoVolumes {
+			rm = append(rm, "-v")
+		}
+		execErr := executeCompose(dc, rm)
+		if execErr.Error != nil {
+			return execErr
+		}
+
+		if opts.KeepNetworks {
+			// rm -v removes only anonymous volumes, the named ones are removed like down --volumes would
+			if opts.Volumes == RemoveAllVolumes {
+				if err := dc.removeVolumes(context.Background()); err != nil {
+					return ExecError{Command: execErr.Command, Error: err}
+				}
+			}
+
+			return execErr
+		}
+	}
+
+	down := append([]string{"down", "--remove-orphans"}, timeout...)
+	if opts.Volumes == RemoveAllVolumes {
+		down = append(down, "--volumes")
+	}
+
+	return executeCompose(dc, down)
+}
+
+// readStacks reads the stacks passed with WithStackReaders into memory, as they're passed to every command
+func (dc *LocalDockerCompose) readStacks() error {
+	for _, r := range dc.StackReaders {
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read compose stack", err)
+		}
+		dc.stacks = append(dc.stacks, content)
+	}
+
+	return nil
+}
+
+// composeFiles returns the compose files to pass with -f, followed by the stacks. A single stack is piped to compose
+// through STDIN as -f -, as compose reads at most one file from it. Multiple stacks are written to temporary files
+// instead, which are removed by cleanup once the command exited.
+func (dc *LocalDockerCompose) composeFiles() (files []string, stdin io.Reader, cleanup func(), err error) {
+	files = append(files, dc.absComposeFilePaths...)
+	cleanup = func() {}
+
+	switch len(dc.stacks) {
+	case 0:
+		return files, nil, cleanup, nil
+	case 1:
+		return append(files, "-"), bytes.NewReader(dc.stacks[0]), cleanup, nil
+	}
+
+	var tempFiles []string
+	cleanup = func() {
+		for _, p := range tempFiles {
+			_ = os.Remove(p)
+		}
+	}
+	for _, content := range dc.stacks {
+		f, err := ioutil.TempFile("", "testcontainers-compose-*.yml")
+		if err != nil {
+			cleanup()
+			return nil, nil, nil, err
+		}
+		tempFiles = append(tempFiles, f.Name())
+
+		_, err = f.Write(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, nil, fmt.Errorf("%w: failed to write compose stack", err)
+		}
+	}
+	return append(files, tempFiles...), nil, cleanup, nil
+}
+
+// writeImageSubstitutions adds a stack overriding the images of the services which are substituted, so that
+// they are pulled from the same registries as the images of containers. Interpolated images are kept.
+func (dc *LocalDockerCompose) writeImageSubstitutions(config TestContainersConfig) error {
+	if config.HubImageNamePrefix == "" && len(dc.ImageSubstitutors) == 0 {
+		return nil
+	}
+
+	overrides := map[string]interface{}{}
+	for name, service := range dc.Services {
+		definition, ok := service.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		image, ok := definition["image"].(string)
+		if !ok || strings.Contains(image, "$") {
+			continue
+		}
+
+		substituted, err := substituteImage(config, dc.ImageSubstitutors, image)
+		if err != nil {
+			return err
+		}
+		if substituted != image {
+			dc.Logger.Printf("Substituted image %s of service %s with %s", image, name, substituted)
+			overrides[name] = map[string]string{"image": substituted}
+		}
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	content, err := marshalProject(map[string]interface{}{"services": overrides})
+	if err != nil {
+		return err
+	}
+	dc.stacks = append(dc.stacks, content)
+	return nil
+}
+
+// writeExternalNetworks adds a stack declaring the external networks and attaching the services to them
+func (dc *LocalDockerCompose) writeExternalNetworks() error {
+	if len(dc.ExternalNetworks) == 0 {
+		return nil
+	}
+
+	networks := map[string]interface{}{}
+	for _, n := range dc.ExternalNetworks {
+		networks[n.Name] = map[string]interface{}{"external": true, "name": n.Name}
+	}
+
+	services := map[string]interface{}{}
+	for name, service := range dc.Services {
+		definition, _ := service.(map[string]interface{})
+		if definition["network_mode"] != nil {
+			continue
+		}
+
+		// listing the external network replaces the implicit default network, which must only be kept
+		// for services which don't choose their networks themselves
+		serviceNetworks := map[string]interface{}{}
+		if definition["networks"] == nil {
+			serviceNetworks["default"] = map[string]interface{}{}
+		}
+		for _, n := range dc.ExternalNetworks {
+			attachment := map[string]interface{}{}
+			if aliases := n.Aliases[name]; len(aliases) > 0 {
+				attachment["aliases"] = aliases
+			}
+			serviceNetworks[n.Name] = attachment
+		}
+		services[name] = map[string]interface{}{"networks": serviceNetworks}
+	}
+
+	content, err := marshalProject(map[string]interface{}{"networks": networks, "services": services})
+	if err != nil {
+		return err
+	}
+	dc.stacks = append(dc.stacks, content)
+	return nil
 }
 
 func (dc *LocalDockerCompose) getDockerComposeEnvironment() map[string]string {
@@ -158,6 +520,20 @@ func (dc *LocalDockerCompose) getDockerComposeEnvironment() map[string]string {
 	environment[envProjectName] = dc.Identifier
 	environment[envComposeFile] = composeFileEnvVariableValue
 
+	if dc.buildOptions != nil {
+		if dc.buildOptions.BuildKit {
+			environment["DOCKER_BUILDKIT"] = "1"
+			environment["COMPOSE_DOCKER_CLI_BUILD"] = "1"
+		}
+		if dc.buildOptions.Builder != "" {
+			environment["BUILDX_BUILDER"] = dc.buildOptions.Builder
+		}
+	}
+
+	if dc.parallelism > 0 {
+		environment[envParallelLimit] = strconv.Itoa(dc.parallelism)
+	}
+
 	return environment
 }
 
@@ -173,45 +549,351 @@ func (dc *LocalDockerCompose) applyStrategyToRunningContainer() error {
 
 	cli.NegotiateAPIVersion(context.Background())
 
+	waited := map[string]bool{}
 	for k := range dc.WaitStrategyMap {
-		containerName := dc.containerNameFromServiceName(k.service, "_")
-		composeV2ContainerName := dc.containerNameFromServiceName(k.service, "-")
-		f := filters.NewArgs(
-			filters.Arg("name", containerName),
-			filters.Arg("name", composeV2ContainerName),
-			filters.Arg("name", k.service))
-		containerListOptions := types.ContainerListOptions{Filters: f, All: true}
-		containers, err := cli.ContainerList(context.Background(), containerListOptions)
+		containers, err := findWaitServiceContainers(context.Background(), cli, dc, k)
 		if err != nil {
-			return fmt.Errorf("error %w occured while filtering the service %s: %d by name and published port", err, k.service, k.publishedPort)
+			return err
+		}
+		strategy := dc.WaitStrategyMap[k]
+		dockerProvider, err := dc.dockerProvider()
+		if err != nil {
+			return err
 		}
+		for _, container := range containers {
+			waited[container.ID] = true
+			dockercontainer := &DockerContainer{ID: container.ID, WaitingFor: strategy, provider: dockerProvider, logger: dc.Logger}
+			err = strategy.WaitUntilReady(context.Background(), dockercontainer)
+			if err != nil {
+				return fmt.Errorf("Unable to apply wait strategy %v to service %s due to %w", strategy, k.service, err)
+			}
+		}
+	}
 
-		if len(containers) == 0 {
-			return fmt.Errorf("service with name %s not found in list of running containers", k.service)
+	if dc.waitForAllServices {
+		return dc.applyDefaultStrategies(context.Background(), "", waited)
+	}
+
+	return nil
+}
+
+// findWaitServiceContainers finds the containers the wait strategy of the given service is applied to,
+// either all running replicas of the service or the single container of it
+func findWaitServiceContainers(ctx context.Context, cli client.APIClient, dc *LocalDockerCompose, k waitService) ([]types.Container, error) {
+	if !k.allReplicas {
+		container, err := findWaitServiceContainer(ctx, cli, dc, k)
+		if err != nil {
+			return nil, err
 		}
+		return []types.Container{container}, nil
+	}
+
+	f := filters.NewArgs(
+		filters.Arg("label", fmt.Sprintf("%s=%s", composeProjectLabel, dc.Identifier)),
+		filters.Arg("label", fmt.Sprintf("%s=%s", composeServiceLabel, k.service)),
+		filters.Arg("status", "running"))
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{Filters: f})
+	if err != nil {
+		return nil, fmt.Errorf("error %w occured while listing the containers of the service %s", err, k.service)
+	}
+
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("service with name %s not found in list of running containers", k.service)
+	}
+
+	return containers, nil
+}
+
+// findWaitServiceContainer finds the single container the wait strategy of the given service is applied to
+func findWaitServiceContainer(ctx context.Context, cli client.APIClient, dc *LocalDockerCompose, k waitService) (types.Container, error) {
+	containerName := dc.containerNameFromServiceName(k.service, "_")
+	composeV2ContainerName := dc.containerNameFromServiceName(k.service, "-")
+	f := filters.NewArgs(
+		filters.Arg("name", containerName),
+		filters.Arg("name", composeV2ContainerName),
+		filters.Arg("name", k.service))
+	containerListOptions := types.ContainerListOptions{Filters: f, All: true}
+	containers, err := cli.ContainerList(ctx, containerListOptions)
+	if err != nil {
+		return types.Container{}, fmt.Errorf("error %w occured while filtering the service %s: %d by name and published port", err, k.service, k.publishedPort)
+	}
+
+	if len(containers) == 0 {
+		return types.Container{}, fmt.Errorf("service with name %s not found in list of running containers", k.service)
+	}
 
-		// The length should always be a list of 1, since we are matching one service name at a time
-		if l := len(containers); l > 1 {
-			return fmt.Errorf("expecting only one running container for %s but got %d", k.service, l)
+	// The length should always be a list of 1, since we are matching one service name at a time
+	if l := len(containers); l > 1 {
+		return types.Container{}, fmt.Errorf("expecting only one running container for %s but got %d", k.service, l)
+	}
+
+	return containers[0], nil
+}
+
+// applyDefaultStrategies waits for all running containers of the service, or all services if empty,
+// which haven't been waited for explicitly
+func (dc *LocalDockerCompose) applyDefaultStrategies(ctx context.Context, service string, waited map[string]bool) error {
+	containers, err := dc.listContainers(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	dockerProvider, err := dc.dockerProvider()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range containers {
+		name := c.Labels[composeServiceLabel]
+		// containers which exited already, e.g. init jobs, aren't expected to become ready
+		if waited[c.ID] || c.State != "running" {
+			continue
 		}
-		container := containers[0]
-		strategy := dc.WaitStrategyMap[k]
-		dockerProvider, err := NewDockerProvider(WithLogger(dc.Logger))
+
+		strategy, err := defaultWaitStrategy(ctx, dockerProvider, c)
+		if err != nil {
+			return fmt.Errorf("%w: unable to determine the wait strategy of service %s", err, name)
+		}
+		if strategy == nil {
+			continue
+		}
+
+		dockercontainer := &DockerContainer{ID: c.ID, WaitingFor: strategy, provider: dockerProvider, logger: dc.Logger}
+		err = strategy.WaitUntilReady(ctx, dockercontainer)
+		if err != nil {
+			return fmt.Errorf("Unable to apply default wait strategy %v to service %s due to %w", strategy, name, err)
+		}
+	}
+
+	return nil
+}
+
+// defaultWaitStrategy selects the strategy of a service container without an explicit wait strategy:
+// its healthcheck if defined, otherwise all of its published TCP ports.
+// Containers having neither are considered ready once they are running.
+func defaultWaitStrategy(ctx context.Context, provider *DockerProvider, c types.Container) (wait.Strategy, error) {
+	inspect, err := provider.client.ContainerInspect(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if inspect.State != nil && inspect.State.Health != nil {
+		return wait.ForHealthCheck(), nil
+	}
+
+	strategies := []wait.Strategy{}
+	seen := map[uint16]bool{}
+	for _, p := range c.Ports {
+		if p.PublicPort == 0 || p.Type != "tcp" || seen[p.PrivatePort] {
+			continue
+		}
+		seen[p.PrivatePort] = true
+		strategies = append(strategies, wait.ForListeningPort(nat.Port(fmt.Sprintf("%d/tcp", p.PrivatePort))))
+	}
+
+	if len(strategies) == 0 {
+		return nil, nil
+	}
+
+	return wait.ForAll(strategies...), nil
+}
+
+// RestartService restarts all containers of the service and waits for them to become ready again,
+// applying the wait strategies of the service. Containers not stopping within the timeout are killed.
+func (dc *LocalDockerCompose) RestartService(ctx context.Context, service string, timeout time.Duration) error {
+	containers, err := dc.listContainers(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	if len(containers) == 0 {
+		return fmt.Errorf("service with name %s not found in list of containers", service)
+	}
+
+	dockerProvider, err := dc.dockerProvider()
+	if err != nil {
+		return err
+	}
+
+	restarted := map[string]bool{}
+	for _, c := range containers {
+		if err := dockerProvider.client.ContainerRestart(ctx, c.ID, &timeout); err != nil {
+			return fmt.Errorf("%w: failed to restart container %s of service %s", err, c.ID, service)
+		}
+		restarted[c.ID] = true
+	}
+
+	waited := map[string]bool{}
+	for k, strategy := range dc.WaitStrategyMap {
+		containers, err := findWaitServiceContainers(ctx, dockerProvider.client, dc, k)
 		if err != nil {
-			return fmt.Errorf("unable to create new Docker Provider: %w", err)
+			continue
+		}
+
+		for _, container := range containers {
+			if !restarted[container.ID] {
+				// strategies of other services don't need to be re-applied
+				continue
+			}
+			waited[container.ID] = true
+
+			dockercontainer := &DockerContainer{ID: container.ID, WaitingFor: strategy, provider: dockerProvider, logger: dc.Logger}
+			if err := strategy.WaitUntilReady(ctx, dockercontainer); err != nil {
+				return fmt.Errorf("Unable to apply wait strategy %v to service %s due to %w", strategy, k.service, err)
+			}
 		}
-		dockercontainer := &DockerContainer{ID: container.ID, WaitingFor: strategy, provider: dockerProvider, logger: dc.Logger}
-		err = strategy.WaitUntilReady(context.Background(), dockercontainer)
+	}
+
+	if dc.waitForAllServices {
+		return dc.applyDefaultStrategies(ctx, service, waited)
+	}
+
+	return nil
+}
+
+// Config returns the effective configuration of the compose project as YAML, i.e. all compose files merged and
+// the environment variables interpolated, the same as docker compose config.
+func (dc *LocalDockerCompose) Config(ctx context.Context) ([]byte, error) {
+	execErr := runComposeContext(ctx, dc, []string{"config"})
+	if execErr.Error != nil {
+		return nil, execErr.Error
+	}
+
+	return execErr.StdoutOutput, nil
+}
+
+// Kill sends the signal, SIGKILL if empty, to all containers of the given services, or all services if none are given.
+// Unlike stopping, the containers don't get the chance to shut down gracefully, e.g. to test abrupt failures.
+func (dc *LocalDockerCompose) Kill(ctx context.Context, signal string, services ...string) error {
+	if signal == "" {
+		signal = "SIGKILL"
+	}
+
+	if len(services) == 0 {
+		services = []string{""}
+	}
+
+	dockerProvider, err := dc.dockerProvider()
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services {
+		containers, err := dc.listContainers(ctx, service)
 		if err != nil {
-			return fmt.Errorf("Unable to apply wait strategy %v to service %s due to %w", strategy, k.service, err)
+			return err
+		}
+
+		if service != "" && len(containers) == 0 {
+			return fmt.Errorf("service with name %s not found in list of containers", service)
+		}
+
+		for _, c := range containers {
+			if c.State != "running" {
+				continue
+			}
+			if err := dockerProvider.client.ContainerKill(ctx, c.ID, signal); err != nil {
+				return fmt.Errorf("%w: failed to send %s to container %s", err, signal, c.ID)
+			}
 		}
 	}
+
 	return nil
 }
 
 // Invoke invokes the docker compose
 func (dc *LocalDockerCompose) Invoke() ExecError {
-	return executeCompose(dc, dc.Cmd)
+	if !dc.isUpCommand() {
+		return executeCompose(dc, dc.Cmd)
+	}
+
+	if dc.AttachIfRunning {
+		running, err := dc.isProjectRunning(context.Background())
+		if err != nil {
+			return ExecError{Command: dc.Cmd, Error: err}
+		}
+
+		if running {
+			dc.Logger.Printf("compose project %s is already running, attaching to its containers", dc.Identifier)
+			return applyWaitStrategies(dc, ExecError{Command: dc.Cmd})
+		}
+	}
+
+	_, isV1 := dc.ComposeVersion.(composeVersion1)
+	if dc.pullBeforeUp || (dc.pullPolicy == PullPolicyAlways && isV1) {
+		// pull up-front, so that pull failures are reported clearly instead of somewhere in the middle of up
+		if execErr := runCompose(dc, []string{"pull"}); execErr.Error != nil {
+			execErr.Error = fmt.Errorf("%w: failed to pull images", execErr.Error)
+			return execErr
+		}
+	}
+
+	if dc.buildOptions != nil {
+		// up doesn't support all build options, so the images are built up-front
+		if execErr := runCompose(dc, dc.buildCommand()); execErr.Error != nil {
+			execErr.Error = fmt.Errorf("%w: failed to build images", execErr.Error)
+			return execErr
+		}
+	}
+
+	return executeCompose(dc, dc.upCommand())
+}
+
+// buildCommand creates the build command according to the build options
+func (dc *LocalDockerCompose) buildCommand() []string {
+	cmd := []string{"build"}
+	if dc.buildOptions.NoCache {
+		cmd = append(cmd, "--no-cache")
+	}
+
+	// sort the build args to keep the command deterministic
+	keys := make([]string, 0, len(dc.buildOptions.BuildArgs))
+	for k := range dc.buildOptions.BuildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		cmd = append(cmd, "--build-arg", fmt.Sprintf("%s=%s", k, dc.buildOptions.BuildArgs[k]))
+	}
+
+	return cmd
+}
+
+// isProjectRunning checks whether any container of the compose project is running
+func (dc *LocalDockerCompose) isProjectRunning(ctx context.Context) (bool, error) {
+	containers, err := dc.listContainers(ctx, "")
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range containers {
+		if c.State == "running" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isUpCommand checks whether the command to invoke starts services
+func (dc *LocalDockerCompose) isUpCommand() bool {
+	return len(dc.Cmd) > 0 && dc.Cmd[0] == "up"
+}
+
+// upCommand adds the flags derived from the options of LocalDockerCompose to the up command
+func (dc *LocalDockerCompose) upCommand() []string {
+	flags := []string{}
+
+	// docker-compose v1 doesn't support a pull policy, the "always" policy is emulated by pulling up-front
+	if _, isV1 := dc.ComposeVersion.(composeVersion1); dc.pullPolicy != "" && !isV1 {
+		flags = append(flags, "--pull", string(dc.pullPolicy))
+	}
+
+	cmd := make([]string, 0, len(dc.Cmd)+len(flags))
+	cmd = append(cmd, dc.Cmd[0])
+	cmd = append(cmd, flags...)
+	return append(cmd, dc.Cmd[1:]...)
 }
 
 // WaitForService sets the strategy for the service that is to be waited on
@@ -221,6 +903,14 @@ func (dc *LocalDockerCompose) WaitForService(service string, strategy wait.Strat
 	return dc
 }
 
+// WaitForAllReplicas sets the strategy that is applied to every running replica of a scaled service,
+// while WaitForService expects the service to have a single container
+func (dc *LocalDockerCompose) WaitForAllReplicas(service string, strategy wait.Strategy) DockerCompose {
+	dc.waitStrategySupplied = true
+	dc.WaitStrategyMap[waitService{service: service, allReplicas: true}] = strategy
+	return dc
+}
+
 // WithCommand assigns the command
 func (dc *LocalDockerCompose) WithCommand(cmd []string) DockerCompose {
 	dc.Cmd = cmd
@@ -233,6 +923,14 @@ func (dc *LocalDockerCompose) WithEnv(env map[string]string) DockerCompose {
 	return dc
 }
 
+// WaitForAllServices waits for all services without an explicit strategy to become ready, using the healthcheck
+// of their containers if defined or otherwise their published TCP ports
+func (dc *LocalDockerCompose) WaitForAllServices() DockerCompose {
+	dc.waitStrategySupplied = true
+	dc.waitForAllServices = true
+	return dc
+}
+
 // WithExposedService sets the strategy for the service that is to be waited on. If multiple strategies
 // are given for a single service running on different ports, both strategies will be applied on the same container
 func (dc *LocalDockerCompose) WithExposedService(service string, port int, strategy wait.Strategy) DockerCompose {
@@ -241,6 +939,36 @@ func (dc *LocalDockerCompose) WithExposedService(service string, port int, strat
 	return dc
 }
 
+// WithPullPolicy sets the policy used to pull the images of the services on up
+func (dc *LocalDockerCompose) WithPullPolicy(policy ComposePullPolicy) DockerCompose {
+	dc.pullPolicy = policy
+	return dc
+}
+
+// WithPullBeforeUp pulls the images of all services before invoking up
+func (dc *LocalDockerCompose) WithPullBeforeUp() DockerCompose {
+	dc.pullBeforeUp = true
+	return dc
+}
+
+// WithBuildOptions builds the images of all services with a build section using the given options before invoking up
+func (dc *LocalDockerCompose) WithBuildOptions(opts ComposeBuildOptions) DockerCompose {
+	dc.buildOptions = &opts
+	return dc
+}
+
+// WithParallelism limits the number of containers compose creates, starts or stops in parallel, e.g. to not saturate
+// CI machines with large stacks. Both docker-compose v1 and v2 respect the limit, by default compose's own limit applies.
+func (dc *LocalDockerCompose) WithParallelism(n int) DockerCompose {
+	dc.parallelism = n
+	return dc
+}
+
+// canParse checks whether the compose binary is able to parse the compose files
+func (dc *LocalDockerCompose) canParse() bool {
+	return runCompose(dc, []string{"config", "-q"}).Error == nil
+}
+
 // determineVersion checks which version of docker-compose is installed
 // depending on the version services names are composed in a different way
 func (dc *LocalDockerCompose) determineVersion() error {
@@ -250,7 +978,9 @@ func (dc *LocalDockerCompose) determineVersion() error {
 		return err
 	}
 
-	components := bytes.Split(execErr.StdoutOutput, []byte("."))
+	// the compose plugin might prefix the version with v
+	version := bytes.TrimPrefix(bytes.TrimSpace(execErr.StdoutOutput), []byte("v"))
+	components := bytes.Split(version, []byte("."))
 	if componentsLen := len(components); componentsLen != 3 {
 		return fmt.Errorf("expected 3 version components in %s", execErr.StdoutOutput)
 	}
@@ -279,14 +1009,20 @@ func (dc *LocalDockerCompose) validate() error {
 		Services map[string]interface{}
 	}
 
+	contents := make([][]byte, 0, len(dc.absComposeFilePaths)+len(dc.stacks))
 	for _, abs := range dc.absComposeFilePaths {
-		c := compose{}
-
 		yamlFile, err := ioutil.ReadFile(abs)
 		if err != nil {
 			return err
 		}
-		err = yaml.Unmarshal(yamlFile, &c)
+		contents = append(contents, yamlFile)
+	}
+	contents = append(contents, dc.stacks...)
+
+	for _, content := range contents {
+		c := compose{}
+
+		err := yaml.Unmarshal(content, &c)
 		if err != nil {
 			return err
 		}
@@ -314,14 +1050,16 @@ type ExecError struct {
 	Stderr       error
 }
 
-// execute executes a program with arguments and environment variables inside a specific directory
+// execute executes a program with arguments and environment variables inside a specific directory,
+// reading STDIN from stdin unless it's nil. The program is killed once the context is done.
 func execute(
-	dirContext string, environment map[string]string, binary string, args []string) ExecError {
+	ctx context.Context, dirContext string, environment map[string]string, stdin io.Reader, binary string, args []string) ExecError {
 
 	var errStdout, errStderr error
 
-	cmd := exec.Command(binary, args...)
+	cmd := exec.CommandContext(ctx, binary, args...)
 	cmd.Dir = dirContext
+	cmd.Stdin = stdin
 	cmd.Env = os.Environ()
 
 	for key, value := range environment {
@@ -376,7 +1114,13 @@ func execute(
 	}
 }
 
-func executeCompose(dc *LocalDockerCompose, args []string) ExecError {
+// runCompose runs the compose binary with the given arguments, without applying any wait strategy
+func runCompose(dc *LocalDockerCompose, args []string) ExecError {
+	return runComposeContext(context.Background(), dc, args)
+}
+
+// runComposeContext runs the compose binary like runCompose, but kills it once the context is done
+func runComposeContext(ctx context.Context, dc *LocalDockerCompose, args []string) ExecError {
 	if which(dc.Executable) != nil {
 		return ExecError{
 			Command: []string{dc.Executable},
@@ -384,34 +1128,71 @@ func executeCompose(dc *LocalDockerCompose, args []string) ExecError {
 		}
 	}
 
+	if dc.stackErr != nil {
+		return ExecError{
+			Command: []string{dc.Executable},
+			Error:   dc.stackErr,
+		}
+	}
+
 	environment := dc.getDockerComposeEnvironment()
 	for k, v := range dc.Env {
 		environment[k] = v
 	}
 
-	var cmds []string
+	files, stdin, cleanup, err := dc.composeFiles()
+	if err != nil {
+		return ExecError{
+			Command: []string{dc.Executable},
+			Error:   err,
+		}
+	}
+	defer cleanup()
+
+	cmds := append([]string{}, dc.executableArgs...)
 	pwd := "."
 	if len(dc.absComposeFilePaths) > 0 {
 		pwd, _ = filepath.Split(dc.absComposeFilePaths[0])
+	} else if len(dc.stacks) > 0 {
+		// stacks read from memory have no directory, so relative paths
+		// within them are resolved against the current working directory
+		pwd, _ = os.Getwd()
+		cmds = append(cmds, "--project-directory", pwd)
+	}
 
-		for _, abs := range dc.absComposeFilePaths {
-			cmds = append(cmds, "-f", abs)
-		}
-	} else {
-		cmds = append(cmds, "-f", "docker-compose.yml")
+	if len(files) == 0 {
+		files = []string{"docker-compose.yml"}
+	}
+	for _, f := range files {
+		cmds = append(cmds, "-f", f)
 	}
 	cmds = append(cmds, args...)
 
-	execErr := execute(pwd, environment, dc.Executable, cmds)
-	err := execErr.Error
-	if err != nil {
-		args := strings.Join(dc.Cmd, " ")
+	execErr := execute(ctx, pwd, environment, stdin, dc.Executable, cmds)
+	if err := execErr.Error; err != nil {
+		args := strings.Join(args, " ")
 		return ExecError{
-			Command: []string{dc.Executable},
-			Error:   fmt.Errorf("Local Docker compose exited abnormally whilst running %s: [%v]. %s", dc.Executable, args, err.Error()),
+			Command:      []string{dc.Executable},
+			StdoutOutput: execErr.StdoutOutput,
+			StderrOutput: execErr.StderrOutput,
+			Error:        fmt.Errorf("Local Docker compose exited abnormally whilst running %s: [%v]. %w", dc.Executable, args, err),
 		}
 	}
 
+	return execErr
+}
+
+func executeCompose(dc *LocalDockerCompose, args []string) ExecError {
+	execErr := runCompose(dc, args)
+	if execErr.Error != nil {
+		return execErr
+	}
+
+	return applyWaitStrategies(dc, execErr)
+}
+
+// applyWaitStrategies applies the wait strategies once after the services were started
+func applyWaitStrategies(dc *LocalDockerCompose, execErr ExecError) ExecError {
 	if dc.waitStrategySupplied {
 		// If the wait strategy has been executed once for all services during startup , disable it so that it is not invoked while tearing down
 		dc.waitStrategySupplied = false
@@ -453,6 +1234,23 @@ func (w *capturingPassThroughWriter) Bytes() []byte {
 	return b
 }
 
+// dockerExecutable returns the name of the docker CLI binary depending on the platform
+func dockerExecutable() string {
+	if runtime.GOOS == "windows" {
+		return "docker.exe"
+	}
+	return "docker"
+}
+
+// composePluginAvailable checks whether the compose plugin of the docker CLI is installed
+func composePluginAvailable() bool {
+	if which(dockerExecutable()) != nil {
+		return false
+	}
+
+	return exec.Command(dockerExecutable(), "compose", "version").Run() == nil
+}
+
 // Which checks if a binary is present in PATH
 func which(binary string) error {
 	_, err := exec.LookPath(binary)
@@ -0,0 +1,52 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ComposeCommandError is returned by RunComposeCommand if the compose command failed
+type ComposeCommandError struct {
+	Args     []string // the arguments of the compose command, e.g. run --rm migrations
+	ExitCode int      // the exit code of the command, -1 if it didn't exit on its own, e.g. because the context was done
+	Stderr   []byte   // the captured stderr of the command
+	Err      error
+}
+
+func (e *ComposeCommandError) Error() string {
+	return fmt.Sprintf("compose command %q failed with exit code %d: %v", strings.Join(e.Args, " "), e.ExitCode, e.Err)
+}
+
+func (e *ComposeCommandError) Unwrap() error {
+	return e.Err
+}
+
+// RunComposeCommand runs an arbitrary compose command, e.g. run or logs, against the compose files of the project,
+// without applying any wait strategy. The output is streamed to stdout and stderr while the command runs, the captured
+// stdout is returned. The command is killed once the context is done. If it fails a *ComposeCommandError is returned.
+func (dc *LocalDockerCompose) RunComposeCommand(ctx context.Context, args ...string) ([]byte, error) {
+	execErr := runComposeContext(ctx, dc, args)
+	if execErr.Error == nil {
+		return execErr.StdoutOutput, nil
+	}
+
+	cmdErr := &ComposeCommandError{
+		Args:     args,
+		ExitCode: -1,
+		Stderr:   execErr.StderrOutput,
+		Err:      execErr.Error,
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(execErr.Error, &exitErr) {
+		cmdErr.ExitCode = exitErr.ExitCode()
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		cmdErr.Err = fmt.Errorf("%w: %v", ctxErr, execErr.Error)
+	}
+
+	return execErr.StdoutOutput, cmdErr
+}
@@ -0,0 +1,292 @@
+package testcontainers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+const (
+	composeProjectLabel         = "com.docker.compose.project"
+	composeServiceLabel         = "com.docker.compose.service"
+	composeContainerNumberLabel = "com.docker.compose.container-number"
+)
+
+// ComposePortPublisher represents a port of a service container published on the Docker host
+type ComposePortPublisher struct {
+	URL           string // the IP address the port is published on
+	TargetPort    int    // the port within the container
+	PublishedPort int    // the port on the Docker host, 0 if the port isn't published
+	Protocol      string // tcp, udp or sctp
+}
+
+// ComposeContainerSummary holds the status of a single container of a compose service,
+// equivalent to a single line of `docker-compose ps`
+type ComposeContainerSummary struct {
+	ID         string
+	Name       string
+	Service    string
+	Replica    int // the container number of the service, starting at 1
+	Image      string
+	State      string // e.g. created, running, exited
+	Status     string // human readable status, e.g. "Up 2 minutes (healthy)"
+	Publishers []ComposePortPublisher
+}
+
+// Ps lists the containers of all services of the compose project, including stopped ones.
+// The containers are sorted by service name and replica number.
+func (dc *LocalDockerCompose) Ps(ctx context.Context) ([]ComposeContainerSummary, error) {
+	containers, err := dc.listContainers(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ComposeContainerSummary, 0, len(containers))
+	for _, c := range containers {
+		summary := ComposeContainerSummary{
+			ID:         c.ID,
+			Service:    c.Labels[composeServiceLabel],
+			Image:      c.Image,
+			State:      c.State,
+			Status:     c.Status,
+			Publishers: make([]ComposePortPublisher, 0, len(c.Ports)),
+		}
+
+		if len(c.Names) > 0 {
+			summary.Name = trimContainerName(c.Names[0])
+		}
+
+		if replica, err := strconv.Atoi(c.Labels[composeContainerNumberLabel]); err == nil {
+			summary.Replica = replica
+		}
+
+		for _, p := range c.Ports {
+			summary.Publishers = append(summary.Publishers, ComposePortPublisher{
+				URL:           p.IP,
+				TargetPort:    int(p.PrivatePort),
+				PublishedPort: int(p.PublicPort),
+				Protocol:      p.Type,
+			})
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Service != summaries[j].Service {
+			return summaries[i].Service < summaries[j].Service
+		}
+		return summaries[i].Replica < summaries[j].Replica
+	})
+
+	return summaries, nil
+}
+
+// CopyToService copies the file or directory at hostPath to containerPath within all containers of the service,
+// e.g. to inject fixtures without bind mounts in the compose file
+func (dc *LocalDockerCompose) CopyToService(ctx context.Context, service string, hostPath string, containerPath string, fileMode int64) error {
+	containers, err := dc.serviceContainers(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range containers {
+		if err := c.CopyFileToContainer(ctx, hostPath, containerPath, fileMode); err != nil {
+			return fmt.Errorf("%w: failed to copy %s to container %s of service %s", err, hostPath, c.ID, service)
+		}
+	}
+
+	return nil
+}
+
+// CopyFromService copies the file at containerPath from the container of the service.
+// If the service has multiple replicas, the file is copied from the first one.
+func (dc *LocalDockerCompose) CopyFromService(ctx context.Context, service string, containerPath string) (io.ReadCloser, error) {
+	containers, err := dc.serviceContainers(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	return containers[0].CopyFileFromContainer(ctx, containerPath)
+}
+
+// ServiceMappedPort returns the port on the Docker host the given port of the service is published on.
+// If the service has multiple replicas, the port of the first one is returned.
+func (dc *LocalDockerCompose) ServiceMappedPort(ctx context.Context, service string, port nat.Port) (nat.Port, error) {
+	containers, err := dc.serviceContainers(ctx, service)
+	if err != nil {
+		return "", err
+	}
+
+	return containers[0].MappedPort(ctx, port)
+}
+
+// ServicePortEndpoint returns proto://host:port for the given port of the service, or host:port if proto is empty.
+// If the service has multiple replicas, the endpoint of the first one is returned.
+func (dc *LocalDockerCompose) ServicePortEndpoint(ctx context.Context, service string, port nat.Port, proto string) (string, error) {
+	containers, err := dc.serviceContainers(ctx, service)
+	if err != nil {
+		return "", err
+	}
+
+	return containers[0].PortEndpoint(ctx, port, proto)
+}
+
+// ProjectName returns the name of the compose project, i.e. the identifier the stack was created with
+func (dc *LocalDockerCompose) ProjectName() string {
+	return dc.Identifier
+}
+
+// Networks lists the names of the networks created for the compose project, e.g. to attach containers
+// started outside of compose to them. The names are sorted alphabetically.
+func (dc *LocalDockerCompose) Networks(ctx context.Context) ([]string, error) {
+	provider, err := dc.dockerProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	return composeNetworks(ctx, provider, dc.Identifier)
+}
+
+// ComposeNetwork resolves the network of the compose project with the given identifier and returns a
+// ContainerCustomizer attaching containers created with GenericContainer to it, optionally with network aliases
+// so the services of the project can reach them by name. If the project has multiple networks, its default
+// network is used.
+func ComposeNetwork(ctx context.Context, identifier string, aliases ...string) (ContainerCustomizer, error) {
+	provider, err := NewDockerProvider()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new Docker Provider: %w", err)
+	}
+	defer provider.Close()
+
+	networks, err := composeNetworks(ctx, provider, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no network found for compose project %s", identifier)
+	}
+
+	network := networks[0]
+	for _, n := range networks {
+		if n == identifier+"_default" {
+			network = n
+		}
+	}
+
+	return networkCustomizer(network, aliases), nil
+}
+
+// composeNetworks lists the names of the networks of the compose project sorted alphabetically
+func composeNetworks(ctx context.Context, provider *DockerProvider, identifier string) ([]string, error) {
+	f := filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", composeProjectLabel, identifier)))
+	networks, err := provider.client.NetworkList(ctx, types.NetworkListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(networks))
+	for _, n := range networks {
+		names = append(names, n.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// serviceContainers returns the containers of the service sorted by replica number, failing if there are none
+func (dc *LocalDockerCompose) serviceContainers(ctx context.Context, service string) ([]*DockerContainer, error) {
+	containers, err := dc.listContainers(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("service with name %s not found in list of containers", service)
+	}
+
+	sort.Slice(containers, func(i, j int) bool {
+		ri, _ := strconv.Atoi(containers[i].Labels[composeContainerNumberLabel])
+		rj, _ := strconv.Atoi(containers[j].Labels[composeContainerNumberLabel])
+		return ri < rj
+	})
+
+	provider, err := dc.dockerProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*DockerContainer, 0, len(containers))
+	for _, c := range containers {
+		result = append(result, &DockerContainer{ID: c.ID, provider: provider, logger: dc.Logger})
+	}
+
+	return result, nil
+}
+
+// dockerProvider lazily creates the DockerProvider used to interact with the containers of the compose project
+func (dc *LocalDockerCompose) dockerProvider() (*DockerProvider, error) {
+	if dc.provider != nil {
+		return dc.provider, nil
+	}
+
+	provider, err := NewDockerProvider(WithLogger(dc.Logger))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new Docker Provider: %w", err)
+	}
+	dc.provider = provider
+
+	return dc.provider, nil
+}
+
+// removeVolumes removes the named volumes of the compose project
+func (dc *LocalDockerCompose) removeVolumes(ctx context.Context) error {
+	provider, err := dc.dockerProvider()
+	if err != nil {
+		return err
+	}
+
+	f := filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", composeProjectLabel, dc.Identifier)))
+	volumes, err := provider.client.VolumeList(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range volumes.Volumes {
+		if err := provider.client.VolumeRemove(ctx, v.Name, false); err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("%w: removing volume %s of compose project %s", err, v.Name, dc.Identifier)
+		}
+	}
+	return nil
+}
+
+// listContainers lists all containers of the compose project, optionally limited to a single service
+func (dc *LocalDockerCompose) listContainers(ctx context.Context, service string) ([]types.Container, error) {
+	provider, err := dc.dockerProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	f := filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", composeProjectLabel, dc.Identifier)))
+	if service != "" {
+		f.Add("label", fmt.Sprintf("%s=%s", composeServiceLabel, service))
+	}
+
+	return provider.client.ContainerList(ctx, types.ContainerListOptions{Filters: f, All: true})
+}
+
+// trimContainerName removes the leading slash the Docker API prepends to container names
+func trimContainerName(name string) string {
+	if len(name) > 0 && name[0] == '/' {
+		return name[1:]
+	}
+	return name
+}
@@ -0,0 +1,68 @@
+package testcontainers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// ComposeEvent is a lifecycle event of a container of a compose service
+type ComposeEvent struct {
+	Timestamp  time.Time
+	Service    string
+	Container  string            // ID of the container
+	Action     string            // e.g. start, die or "health_status: unhealthy"
+	Attributes map[string]string // attributes of the container, including its labels and e.g. the exit code on die
+}
+
+// Events subscribes to the lifecycle events of the containers of all services, e.g. to assert a crash loop is detected.
+// The channel is closed once the context is done or the event stream fails, failures are logged.
+func (dc *LocalDockerCompose) Events(ctx context.Context) (<-chan ComposeEvent, error) {
+	provider, err := dc.dockerProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	f := filters.NewArgs(
+		filters.Arg("type", events.ContainerEventType),
+		filters.Arg("label", fmt.Sprintf("%s=%s", composeProjectLabel, dc.Identifier)),
+	)
+	messages, errs := provider.client.Events(ctx, types.EventsOptions{Filters: f})
+
+	result := make(chan ComposeEvent)
+	go func() {
+		defer close(result)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-errs:
+				if err != nil && ctx.Err() == nil {
+					dc.Logger.Printf("failed to receive events of compose project %s: %v", dc.Identifier, err)
+				}
+				return
+			case msg := <-messages:
+				event := ComposeEvent{
+					Timestamp:  time.Unix(0, msg.TimeNano),
+					Service:    msg.Actor.Attributes[composeServiceLabel],
+					Container:  msg.Actor.ID,
+					Action:     msg.Action,
+					Attributes: msg.Actor.Attributes,
+				}
+
+				select {
+				case result <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return result, nil
+}
@@ -3,6 +3,7 @@ package testcontainers
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"regexp"
 	"strings"
 	"testing"
@@ -12,6 +13,8 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -382,6 +385,75 @@ func TestLocalDockerComposeWithEnvironment(t *testing.T) {
 	assertContainerEnvironmentVariables(t, compose.Identifier, "nginx", present, absent)
 }
 
+func TestLocalDockerComposeRunComposeCommand(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	output, err := compose.RunComposeCommand(context.Background(), "run", "--rm", "nginx", "echo", "hello")
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "hello")
+
+	_, err = compose.RunComposeCommand(context.Background(), "run", "--rm", "nginx", "sh", "-c", "exit 3")
+	var cmdErr *ComposeCommandError
+	require.ErrorAs(t, err, &cmdErr)
+	assert.Equal(t, 3, cmdErr.ExitCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = compose.RunComposeCommand(ctx, "run", "--rm", "nginx", "sleep", "30")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLocalDockerComposeRunComposeCommandNotFound(t *testing.T) {
+	compose := NewLocalDockerCompose([]string{"./testresources/docker-compose-simple.yml"}, "not-found")
+	compose.Executable = "testcontainers-compose-not-found"
+
+	_, err := compose.RunComposeCommand(context.Background(), "ps")
+
+	var cmdErr *ComposeCommandError
+	require.ErrorAs(t, err, &cmdErr)
+	assert.Equal(t, -1, cmdErr.ExitCode)
+	assert.Equal(t, []string{"ps"}, cmdErr.Args)
+}
+
+func TestLocalDockerComposeConfig(t *testing.T) {
+	composeFiles := []string{
+		"testresources/docker-compose-simple.yml",
+		"testresources/docker-compose-override.yml",
+	}
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose(composeFiles, identifier, WithLogger(TestLogger(t)))
+	compose.WithEnv(map[string]string{
+		"bar": "BAR",
+		"foo": "FOO",
+	})
+
+	content, err := compose.Config(context.Background())
+	require.NoError(t, err)
+
+	var config struct {
+		Services map[string]struct {
+			Environment map[string]string `yaml:"environment"`
+		} `yaml:"services"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &config))
+
+	assert.Len(t, config.Services, 2)
+	assert.Contains(t, config.Services, "mysql")
+	assert.Equal(t, map[string]string{"bar": "BAR", "foo": "FOO"}, config.Services["nginx"].Environment)
+}
+
 func TestLocalDockerComposeWithMultipleComposeFiles(t *testing.T) {
 	composeFiles := []string{
 		"testresources/docker-compose-simple.yml",
@@ -526,3 +598,723 @@ func checkIfError(t *testing.T, err ExecError) {
 	assert.NotNil(t, err.StdoutOutput)
 	assert.NotNil(t, err.StderrOutput)
 }
+
+func TestLocalDockerComposePs(t *testing.T) {
+	path := "./testresources/docker-compose-complex.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	summaries, psErr := compose.Ps(context.Background())
+	if psErr != nil {
+		t.Fatal(psErr)
+	}
+
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, "mysql", summaries[0].Service)
+	assert.Equal(t, "nginx", summaries[1].Service)
+	for _, s := range summaries {
+		assert.NotEmpty(t, s.ID)
+		assert.Equal(t, 1, s.Replica)
+		assert.Equal(t, "running", s.State)
+	}
+	assert.Contains(t, summaries[1].Publishers, ComposePortPublisher{URL: "0.0.0.0", TargetPort: 80, PublishedPort: 9080, Protocol: "tcp"})
+}
+
+func TestLocalDockerComposeServicePortEndpoint(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	port, portErr := compose.ServiceMappedPort(context.Background(), "nginx", "80/tcp")
+	if portErr != nil {
+		t.Fatal(portErr)
+	}
+	assert.Equal(t, "9080", port.Port())
+
+	endpoint, endpointErr := compose.ServicePortEndpoint(context.Background(), "nginx", "80/tcp", "http")
+	if endpointErr != nil {
+		t.Fatal(endpointErr)
+	}
+	assert.Regexp(t, `^http://.+:9080$`, endpoint)
+
+	_, endpointErr = compose.ServicePortEndpoint(context.Background(), "unknown", "80/tcp", "http")
+	assert.Error(t, endpointErr)
+}
+
+func TestLocalDockerComposeNetworks(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	assert.Equal(t, identifier, compose.ProjectName())
+
+	networks, networksErr := compose.Networks(context.Background())
+	if networksErr != nil {
+		t.Fatal(networksErr)
+	}
+
+	assert.Equal(t, []string{identifier + "_default"}, networks)
+}
+
+func TestComposeNetwork(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	ctx := context.Background()
+
+	customizer, networkErr := ComposeNetwork(ctx, identifier, "probe")
+	if networkErr != nil {
+		t.Fatal(networkErr)
+	}
+
+	req := GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image: "docker.io/alpine",
+			Cmd:   []string{"sleep", "30"},
+		},
+		Started: true,
+	}
+	customizer.Customize(&req)
+
+	c, containerErr := GenericContainer(ctx, req)
+	require.NoError(t, containerErr)
+	terminateContainerOnEnd(t, ctx, c)
+
+	networks, networksErr := c.Networks(ctx)
+	require.NoError(t, networksErr)
+	assert.Contains(t, networks, identifier+"_default")
+
+	aliases, aliasesErr := c.NetworkAliases(ctx)
+	require.NoError(t, aliasesErr)
+	assert.Contains(t, aliases[identifier+"_default"], "probe")
+}
+
+func TestLocalDockerComposeWithStackReaders(t *testing.T) {
+	simple, err := ioutil.ReadFile("./testresources/docker-compose-simple.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose(
+		nil,
+		identifier,
+		WithLogger(TestLogger(t)),
+		WithStackContent(simple),
+		WithStackReaders(strings.NewReader(`
+services:
+  nginx:
+    environment:
+      foo: FOO
+`)),
+	)
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	execErr := compose.
+		WithCommand([]string{"up", "-d"}).
+		WithEnv(map[string]string{
+			"bar": "BAR",
+		}).
+		Invoke()
+	checkIfError(t, execErr)
+
+	assert.Equal(t, 1, len(compose.Services))
+	assert.Contains(t, compose.Services, "nginx")
+
+	present := map[string]string{
+		"bar": "BAR",
+		"foo": "FOO",
+	}
+	absent := map[string]string{}
+	assertContainerEnvironmentVariables(t, compose.Identifier, "nginx", present, absent)
+}
+
+func TestLocalDockerComposeWithProject(t *testing.T) {
+	project := map[string]interface{}{
+		"version": "3",
+		"services": map[string]interface{}{
+			"nginx": map[string]interface{}{
+				"image": "docker.io/nginx:stable-alpine",
+				"labels": map[string]string{
+					"org.testcontainers.golang.test": "true",
+				},
+			},
+		},
+	}
+
+	compose := NewLocalDockerCompose(nil, "my_project", WithProject(project))
+
+	assert.NoError(t, compose.stackErr)
+	assert.Equal(t, 1, len(compose.Services))
+	assert.Contains(t, compose.Services, "nginx")
+}
+
+func TestLocalDockerComposeWithInvalidProject(t *testing.T) {
+	compose := NewLocalDockerCompose(nil, "my_project", WithProject(func() {}))
+
+	assert.Error(t, compose.stackErr)
+}
+
+func TestLocalDockerComposeUpCommand(t *testing.T) {
+	type cases struct {
+		name    string
+		version ComposeVersion
+		policy  ComposePullPolicy
+		want    []string
+	}
+
+	tests := []cases{
+		{
+			name:    "no policy",
+			version: composeVersion2{},
+			want:    []string{"up", "-d"},
+		},
+		{
+			name:    "compose v2",
+			version: composeVersion2{},
+			policy:  PullPolicyAlways,
+			want:    []string{"up", "--pull", "always", "-d"},
+		},
+		{
+			name:    "compose v1",
+			version: composeVersion1{},
+			policy:  PullPolicyAlways,
+			want:    []string{"up", "-d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compose := &LocalDockerCompose{
+				ComposeVersion: test.version,
+				Cmd:            []string{"up", "-d"},
+			}
+			compose.WithPullPolicy(test.policy)
+
+			assert.Equal(t, test.want, compose.upCommand())
+		})
+	}
+}
+
+func TestLocalDockerComposeWithPullBeforeUp(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		WithPullPolicy(PullPolicyMissing).
+		WithPullBeforeUp().
+		Invoke()
+	checkIfError(t, err)
+
+	assert.Equal(t, 1, len(compose.Services))
+	assert.Contains(t, compose.Services, "nginx")
+}
+
+func TestLocalDockerComposeBuildCommand(t *testing.T) {
+	compose := &LocalDockerCompose{}
+	compose.WithBuildOptions(ComposeBuildOptions{
+		NoCache: true,
+		BuildArgs: map[string]string{
+			"FOO": "foo",
+			"BAR": "bar",
+		},
+	})
+
+	assert.Equal(t, []string{"build", "--no-cache", "--build-arg", "BAR=bar", "--build-arg", "FOO=foo"}, compose.buildCommand())
+}
+
+func TestLocalDockerComposeWithBuildOptions(t *testing.T) {
+	path := "./testresources/docker-compose-build.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		WithBuildOptions(ComposeBuildOptions{
+			NoCache:   true,
+			BuildArgs: map[string]string{"FOO": "BAR"},
+		}).
+		Invoke()
+	checkIfError(t, err)
+
+	present := map[string]string{
+		"FOO": "BAR",
+	}
+	absent := map[string]string{}
+	assertContainerEnvironmentVariables(t, compose.Identifier, "echo", present, absent)
+}
+
+func TestLocalDockerComposeWaitForAllServices(t *testing.T) {
+	path := "./testresources/docker-compose-complex.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		WithExposedService(compose.Format("mysql", "1"), 13306, wait.NewLogStrategy("started").WithStartupTimeout(10*time.Second)).
+		WaitForAllServices().
+		Invoke()
+	checkIfError(t, err)
+
+	assert.Equal(t, 2, len(compose.Services))
+	assert.Contains(t, compose.Services, "nginx")
+	assert.Contains(t, compose.Services, "mysql")
+}
+
+func TestLocalDockerComposeRestartService(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		WithExposedService(compose.Format("nginx", "1"), 9080, wait.NewHTTPStrategy("/").WithPort("80/tcp")).
+		Invoke()
+	checkIfError(t, err)
+
+	ctx := context.Background()
+
+	before, psErr := compose.Ps(ctx)
+	assert.NoError(t, psErr)
+	assert.Len(t, before, 1)
+
+	assert.NoError(t, compose.RestartService(ctx, "nginx", 5*time.Second))
+
+	after, psErr := compose.Ps(ctx)
+	assert.NoError(t, psErr)
+	assert.Len(t, after, 1)
+	assert.Equal(t, before[0].ID, after[0].ID)
+	assert.Equal(t, "running", after[0].State)
+
+	assert.Error(t, compose.RestartService(ctx, "unknown", 5*time.Second))
+}
+
+func TestLocalDockerComposeKill(t *testing.T) {
+	path := "./testresources/docker-compose-complex.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	ctx := context.Background()
+
+	assert.NoError(t, compose.Kill(ctx, "", "nginx"))
+
+	summaries, psErr := compose.Ps(ctx)
+	assert.NoError(t, psErr)
+	assert.Len(t, summaries, 2)
+
+	assert.Equal(t, "mysql", summaries[0].Service)
+	assert.Equal(t, "running", summaries[0].State)
+	assert.Equal(t, "nginx", summaries[1].Service)
+	assert.Equal(t, "exited", summaries[1].State)
+
+	assert.Error(t, compose.Kill(ctx, "SIGTERM", "unknown"))
+}
+
+func TestLocalDockerComposeCopyToAndFromService(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	execErr := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, execErr)
+
+	ctx := context.Background()
+
+	assert.NoError(t, compose.CopyToService(ctx, "nginx", "./testresources/hello.sh", "/tmp/hello.sh", 700))
+
+	r, err := compose.CopyFromService(ctx, "nginx", "/tmp/hello.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	copied, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("./testresources/hello.sh")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, copied)
+
+	assert.Error(t, compose.CopyToService(ctx, "unknown", "./testresources/hello.sh", "/tmp/hello.sh", 700))
+}
+
+func TestLocalDockerComposeDownKeepingNamedVolumes(t *testing.T) {
+	if providerType == ProviderPodman {
+		t.Skip("fails for some reason with Podman")
+	}
+	path := "./testresources/docker-compose-volume.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+		assertVolumeDoesNotExist(t, compose.Format(identifier, "mydata"))
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	err = compose.DownWithOptions(ComposeDownOptions{
+		StopTimeout: 5 * time.Second,
+		Volumes:     RemoveAnonymousVolumes,
+	})
+	checkIfError(t, err)
+
+	summaries, psErr := compose.Ps(context.Background())
+	assert.NoError(t, psErr)
+	assert.Empty(t, summaries)
+
+	containerClient, _, _, clientErr := NewDockerClient()
+	if clientErr != nil {
+		t.Fatalf("Failed to get provider: %v", clientErr)
+	}
+	volumeList, listErr := containerClient.VolumeList(context.Background(), filters.NewArgs(filters.Arg("name", compose.Format(identifier, "mydata"))))
+	assert.NoError(t, listErr)
+	assert.Len(t, volumeList.Volumes, 1, "the named volume should have been kept")
+}
+
+func TestLocalDockerComposeDownKeepingNetworksRemovesNamedVolumes(t *testing.T) {
+	if providerType == ProviderPodman {
+		t.Skip("fails for some reason with Podman")
+	}
+	path := "./testresources/docker-compose-volume.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	defer func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	err = compose.DownWithOptions(ComposeDownOptions{
+		KeepNetworks: true,
+		Volumes:      RemoveAllVolumes,
+	})
+	checkIfError(t, err)
+
+	assertVolumeDoesNotExist(t, compose.Format(identifier, "mydata"))
+}
+
+func TestLocalDockerComposeAttachIfRunning(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	err := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, err)
+
+	ctx := context.Background()
+	before, psErr := compose.Ps(ctx)
+	assert.NoError(t, psErr)
+
+	attached := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)), AttachIfRunning())
+	err = attached.
+		WithCommand([]string{"up", "-d", "--force-recreate"}).
+		WithExposedService(attached.Format("nginx", "1"), 9080, wait.NewHTTPStrategy("/").WithPort("80/tcp")).
+		Invoke()
+	checkIfError(t, err)
+
+	after, psErr := attached.Ps(ctx)
+	assert.NoError(t, psErr)
+	assert.Equal(t, before, after, "the running containers should have been reused")
+}
+
+func TestLocalDockerComposeEvents(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	events, err := compose.Events(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	execErr := compose.
+		WithCommand([]string{"up", "-d"}).
+		Invoke()
+	checkIfError(t, execErr)
+
+	assert.NoError(t, compose.Kill(ctx, "SIGKILL", "nginx"))
+
+	for event := range events {
+		if event.Action != "die" {
+			continue
+		}
+
+		assert.Equal(t, "nginx", event.Service)
+		assert.Equal(t, "137", event.Attributes["exitCode"])
+		return
+	}
+
+	t.Fatal("no die event received")
+}
+
+func TestLocalDockerComposeUseComposePlugin(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, UseComposePlugin())
+
+	assert.Equal(t, dockerExecutable(), compose.Executable)
+	assert.Equal(t, []string{"compose"}, compose.executableArgs)
+}
+
+func TestLocalDockerComposeWithParallelism(t *testing.T) {
+	path := "./testresources/docker-compose-simple.yml"
+
+	compose := NewLocalDockerCompose([]string{path}, "parallelism")
+	assert.NotContains(t, compose.getDockerComposeEnvironment(), envParallelLimit)
+
+	compose.WithParallelism(2)
+	assert.Equal(t, "2", compose.getDockerComposeEnvironment()[envParallelLimit])
+}
+
+// countingStrategy counts the containers it was applied to
+type countingStrategy struct {
+	count int
+}
+
+func (s *countingStrategy) WaitUntilReady(_ context.Context, _ wait.StrategyTarget) error {
+	s.count++
+	return nil
+}
+
+func TestLocalDockerComposeWaitForAllReplicas(t *testing.T) {
+	path := "./testresources/docker-compose-no-exposed-ports.yml"
+
+	identifier := strings.ToLower(uuid.New().String())
+
+	compose := NewLocalDockerCompose([]string{path}, identifier, WithLogger(TestLogger(t)))
+	destroyFn := func() {
+		err := compose.Down()
+		checkIfError(t, err)
+	}
+	defer destroyFn()
+
+	strategy := &countingStrategy{}
+	err := compose.
+		WithCommand([]string{"up", "-d", "--scale", "nginx=2"}).
+		WaitForAllReplicas("nginx", strategy).
+		Invoke()
+	checkIfError(t, err)
+
+	assert.Equal(t, 2, strategy.count)
+}
+
+func TestLocalDockerComposeFallbackToComposePlugin(t *testing.T) {
+	if which("docker-compose") != nil {
+		t.Skip("docker-compose isn't installed")
+	}
+
+	path := "./testresources/docker-compose-simple.yml"
+
+	compose := NewLocalDockerCompose([]string{path}, "fallback", FallbackToComposePlugin())
+
+	assert.True(t, compose.PluginFallback)
+	assert.Equal(t, "docker-compose", compose.Executable, "docker-compose is able to parse the compose file")
+	assert.Empty(t, compose.executableArgs)
+}
+
+func TestComposeExternalNetworks(t *testing.T) {
+	compose := NewLocalDockerCompose(nil, "external-networks",
+		WithStackContent([]byte(`services:
+  db:
+    image: postgres:14
+  cache:
+    image: redis:7
+    networks:
+      - backend
+  metrics:
+    image: prom/node-exporter
+    network_mode: host
+networks:
+  backend: {}
+`)),
+		WithExternalNetwork("app", map[string][]string{"db": {"postgres"}}),
+	)
+	require.NoError(t, compose.stackErr)
+
+	require.Len(t, compose.stacks, 2, "the networks are added as a stack of their own")
+	assert.Equal(t, `networks:
+    app:
+        external: true
+        name: app
+services:
+    cache:
+        networks:
+            app: {}
+    db:
+        networks:
+            app:
+                aliases:
+                    - postgres
+            default: {}
+`, string(compose.stacks[1]), "services choosing their networks must not be attached to the default network")
+}
+
+func TestComposeFiles(t *testing.T) {
+	compose := &LocalDockerCompose{absComposeFilePaths: []string{"/tmp/docker-compose.yml"}}
+	files, stdin, cleanup, err := compose.composeFiles()
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, []string{"/tmp/docker-compose.yml"}, files)
+	assert.Nil(t, stdin)
+
+	compose.stacks = [][]byte{[]byte("services: {}\n")}
+	files, stdin, cleanup, err = compose.composeFiles()
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, []string{"/tmp/docker-compose.yml", "-"}, files, "a single stack is piped through STDIN")
+	content, err := ioutil.ReadAll(stdin)
+	require.NoError(t, err)
+	assert.Equal(t, "services: {}\n", string(content))
+
+	compose.stacks = append(compose.stacks, []byte("networks: {}\n"))
+	files, stdin, cleanup, err = compose.composeFiles()
+	require.NoError(t, err)
+	assert.Nil(t, stdin)
+	require.Len(t, files, 3, "multiple stacks are written to temporary files")
+	content, err = ioutil.ReadFile(files[2])
+	require.NoError(t, err)
+	assert.Equal(t, "networks: {}\n", string(content))
+
+	cleanup()
+	assert.NoFileExists(t, files[1], "the temporary files are removed once the command exited")
+	assert.NoFileExists(t, files[2])
+}
@@ -2,15 +2,28 @@ package testcontainers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -33,7 +46,8 @@ type ContainerProvider interface {
 	Config() TestContainersConfig
 }
 
-// Container allows getting info about and controlling a single container instance
+// Container allows getting info about and controlling a single container instance.
+// Errors reported by the container runtime are always returned to the caller, implementations must never panic on them
 type Container interface {
 	GetContainerID() string                                         // get the container id from the provider
 	Endpoint(context.Context, string) (string, error)               // get proto://ip:port string for the first exposed port
@@ -48,6 +62,7 @@ type Container interface {
 	Terminate(context.Context) error             // terminate the container
 	Logs(context.Context) (io.ReadCloser, error) // Get logs of the container
 	FollowOutput(LogConsumer)
+	UnfollowOutput(LogConsumer)
 	StartLogProducer(context.Context) error
 	StopLogProducer() error
 	Name(context.Context) (string, error)                        // get container name
@@ -55,21 +70,34 @@ type Container interface {
 	Networks(context.Context) ([]string, error)                  // get container networks
 	NetworkAliases(context.Context) (map[string][]string, error) // get container network aliases for a network
 	Exec(ctx context.Context, cmd []string) (int, io.Reader, error)
+	Schedule(ctx context.Context, interval time.Duration, cmd []string) (*ScheduledCommand, error)
+	FS(ctx context.Context, root string) fs.FS
+	URL(ctx context.Context, scheme string, port nat.Port, path string) (*url.URL, error)
+	HTTPEndpoint(ctx context.Context, port nat.Port, path string) (*url.URL, error)
+	ExtractFiles(ctx context.Context, tb testing.TB, spec map[string]string) (map[string]string, error)
 	ContainerIP(context.Context) (string, error)    // get container ip
 	ContainerIPs(context.Context) ([]string, error) // get all container IPs
-	CopyToContainer(ctx context.Context, fileContent []byte, containerFilePath string, fileMode int64) error
-	CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, fileMode int64) error
-	CopyFileToContainer(ctx context.Context, hostFilePath string, containerFilePath string, fileMode int64) error
+	CopyToContainer(ctx context.Context, fileContent []byte, containerFilePath string, fileMode int64, transforms ...TarHeaderTransform) error
+	CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, fileMode int64, transforms ...TarHeaderTransform) error
+	CopyFileToContainer(ctx context.Context, hostFilePath string, containerFilePath string, fileMode int64, transforms ...TarHeaderTransform) error
 	CopyFileFromContainer(ctx context.Context, filePath string) (io.ReadCloser, error)
 }
 
 // ImageBuildInfo defines what is needed to build an image
 type ImageBuildInfo interface {
-	GetContext() (io.Reader, error)   // the path to the build context
-	GetDockerfile() string            // the relative path to the Dockerfile, including the fileitself
-	ShouldPrintBuildLog() bool        // allow build log to be printed to stdout
-	ShouldBuildImage() bool           // return true if the image needs to be built
-	GetBuildArgs() map[string]*string // return the environment args used to build the from Dockerfile
+	GetContext() (io.Reader, error)    // the path to the build context
+	GetDockerfile() string             // the relative path to the Dockerfile, including the fileitself
+	ShouldPrintBuildLog() bool         // allow build log to be printed to stdout
+	ShouldBuildImage() bool            // return true if the image needs to be built
+	GetBuildArgs() map[string]*string  // return the environment args used to build the from Dockerfile
+	GetTarget() string                 // return the stage of a multi-stage Dockerfile to build, the last one if empty
+	GetCacheFrom() []string            // return the images to consider as cache sources
+	GetBuildLabels() map[string]string // return the labels to set on the built image
+	GetNetworkMode() string            // return the networking mode of the RUN instructions
+	GetRepo() string                   // return the repository of the built image, derived from the context and Dockerfile if empty
+	GetTag() string                    // return the tag of the built image, derived from the build args if empty
+	GetPlatform() string               // return the platform to build the image for, e.g. linux/amd64, the daemon's if empty
+	GetBuildLogWriter() io.Writer      // return the writer the build log is streamed to, nil for none
 }
 
 // FromDockerfile represents the parameters needed to build an image from a Dockerfile
@@ -77,11 +105,36 @@ type ImageBuildInfo interface {
 type FromDockerfile struct {
 	Context        string             // the path to the context of of the docker build
 	ContextArchive io.Reader          // the tar archive file to send to docker that contains the build context
+	ContextFS      fs.FS              // the file system of the build context, e.g. an embed.FS, takes precedence over Context
 	Dockerfile     string             // the path from the context to the Dockerfile for the image, defaults to "Dockerfile"
 	BuildArgs      map[string]*string // enable user to pass build args to docker daemon
 	PrintBuildLog  bool               // enable user to print build log
+	ImageRemoval   ImageRemovalPolicy // whether Terminate removes the built image, defaults to ImageRemovalOnSuccess
+	PruneChildren  bool               // remove the untagged parent images along with the built image
+	Target         string             // the stage of a multi-stage Dockerfile to build, e.g. "test", defaults to the last one
+	CacheFrom      []string           // images to consider as cache sources, e.g. "registry.example.com/app:cache"
+	Labels         map[string]string  // labels to set on the built image
+	NetworkMode    string             // the networking mode of the RUN instructions, e.g. "host"
+	Repo           string             // the repository of the built image, defaults to a random UUID
+	Tag            string             // the tag of the built image, defaults to a random UUID
+	BuildLogWriter io.Writer          // receives the build log as it's streamed, instead of stderr with PrintBuildLog
+
+	// the content of the Dockerfile, e.g. "FROM alpine\nRUN apk add socat", which is added to the build context
+	// in place of the Dockerfile, so trivial images don't need a context at all
+	DockerfileContent string
 }
 
+// ImageRemovalPolicy defines whether Terminate removes the image built from a Dockerfile.
+// The image is removed in the background, so it doesn't slow down the teardown.
+type ImageRemovalPolicy string
+
+// possible image removal policies
+const (
+	ImageRemovalOnSuccess ImageRemovalPolicy = "on-success" // remove the image if the container was removed
+	ImageRemovalAlways    ImageRemovalPolicy = "always"     // remove the image even if the container couldn't be removed
+	ImageRemovalNever     ImageRemovalPolicy = "never"      // keep the image, e.g. to reuse it in subsequent test runs
+)
+
 type ContainerFile struct {
 	HostFilePath      string
 	ContainerFilePath string
@@ -99,14 +152,21 @@ type ContainerRequest struct {
 	Labels          map[string]string
 	Mounts          ContainerMounts
 	Tmpfs           map[string]string
-	RegistryCred    string
+	RegistryCred    string        // credentials encoded like the X-Registry-Auth header, prefer RegistryAuth
+	RegistryAuth    *RegistryAuth // credentials to pull the image, take precedence over RegistryCred
 	WaitingFor      wait.Strategy
 	Name            string // for specifying container name
 	Hostname        string
+	Domainname      string // for specifying the domain name, making up the FQDN of the container with the hostname
+	MacAddress      string // for specifying the MAC address of the container on its first network, e.g. 02:42:ac:11:00:42
 	ExtraHosts      []string
-	Privileged      bool                // for starting privileged container
-	Networks        []string            // for specifying network names
-	NetworkAliases  map[string][]string // for specifying network aliases
+	DNS             []string                               // for specifying DNS servers, e.g. the IP of a DNS server container
+	DNSSearch       []string                               // for specifying DNS search domains
+	DNSOptions      []string                               // for specifying resolv.conf options, e.g. ndots:1
+	Privileged      bool                                   // for starting privileged container
+	Networks        []string                               // for specifying network names
+	NetworkAliases  map[string][]string                    // for specifying network aliases
+	NetworkIPAM     map[string]*network.EndpointIPAMConfig // static addresses of the container by network name
 	NetworkMode     container.NetworkMode
 	Resources       container.Resources
 	Files           []ContainerFile // files which will be copied when container starts
@@ -120,6 +180,16 @@ type ContainerRequest struct {
 	ShmSize         int64    // Amount of memory shared with the host (in bytes)
 	CapAdd          []string // Add Linux capabilities
 	CapDrop         []string // Drop Linux capabilities
+	FailOnUnhealthy bool     // fail waiting for the container as soon as its healthcheck reports unhealthy
+
+	// isolation technology of Windows containers: process or hyperv, the daemon default if empty
+	Isolation container.Isolation
+
+	// after waiting, check that the mapped TCP ports can be dialed from the test host,
+	// e.g. because forwarding the ports of remote daemons lags behind the container
+	VerifyPortsReachable bool
+
+	DefaultStopTimeout time.Duration // time given to the container to shut down gracefully in Stop and Terminate before it is killed
 }
 
 type (
@@ -148,11 +218,14 @@ func (f GenericProviderOptionFunc) ApplyGenericTo(opts *GenericProviderOptions)
 
 // possible provider types
 const (
-	ProviderDocker ProviderType = iota // Docker is default = 0
+	ProviderDefault ProviderType = iota // the first detected registered provider, Docker otherwise, see RegisterProvider
+	ProviderDocker
 	ProviderPodman
 )
 
-// GetProvider provides the provider implementation for a certain type
+// GetProvider provides the provider implementation for a certain type.
+// For ProviderDefault, the providers registered with a detector are tried first, falling back to Docker,
+// while ProviderDocker always returns the Docker provider.
 func (t ProviderType) GetProvider(opts ...GenericProviderOption) (GenericProvider, error) {
 	opt := &GenericProviderOptions{
 		Logger: Logger,
@@ -163,7 +236,13 @@ func (t ProviderType) GetProvider(opts ...GenericProviderOption) (GenericProvide
 	}
 
 	switch t {
-	case ProviderDocker:
+	case ProviderDefault, ProviderDocker:
+		if t == ProviderDefault {
+			if registered, ok := detectProvider(context.Background()); ok {
+				return registered.newProvider(opts...)
+			}
+		}
+
 		providerOptions := append(Generic2DockerOptions(opts...), WithDefaultBridgeNetwork(Bridge))
 		provider, err := NewDockerProvider(providerOptions...)
 		if err != nil {
@@ -177,6 +256,10 @@ func (t ProviderType) GetProvider(opts ...GenericProviderOption) (GenericProvide
 			return nil, fmt.Errorf("%w, failed to create Docker provider", err)
 		}
 		return provider, nil
+	default:
+		if registered, ok := registeredProviderOf(t); ok {
+			return registered.newProvider(opts...)
+		}
 	}
 	return nil, errors.New("unknown provider")
 }
@@ -188,6 +271,8 @@ func (c *ContainerRequest) Validate() error {
 		c.validateContextAndImage,
 		c.validateContextOrImageIsSpecified,
 		c.validateMounts,
+		c.validateNetworkIPAM,
+		c.validateMacAddress,
 	}
 
 	var err error
@@ -204,10 +289,43 @@ func (c *ContainerRequest) Validate() error {
 // GetContext retrieve the build context for the request
 func (c *ContainerRequest) GetContext() (io.Reader, error) {
 	if c.ContextArchive != nil {
+		if c.DockerfileContent != "" {
+			return nil, errors.New("an inline Dockerfile can't be added to a context archive")
+		}
 		return c.ContextArchive, nil
 	}
 
-	buildContext, err := archive.TarWithOptions(c.Context, &archive.TarOptions{})
+	if c.DockerfileContent != "" {
+		contextFS := c.ContextFS
+		if contextFS == nil && c.Context != "" {
+			contextFS = os.DirFS(c.Context)
+		}
+
+		var excludes []string
+		if contextFS != nil {
+			var err error
+			if excludes, err = readDockerignore(contextFS, c.GetDockerfile()); err != nil {
+				return nil, err
+			}
+		}
+		dockerfile := path.Clean(filepath.ToSlash(c.GetDockerfile()))
+		return tarFS(contextFS, excludes, map[string]string{dockerfile: c.DockerfileContent})
+	}
+
+	if c.ContextFS != nil {
+		excludes, err := readDockerignore(c.ContextFS, c.GetDockerfile())
+		if err != nil {
+			return nil, err
+		}
+		return tarFS(c.ContextFS, excludes, nil)
+	}
+
+	excludes, err := readDockerignore(os.DirFS(c.Context), c.GetDockerfile())
+	if err != nil {
+		return nil, err
+	}
+
+	buildContext, err := archive.TarWithOptions(c.Context, &archive.TarOptions{ExcludePatterns: excludes})
 	if err != nil {
 		return nil, err
 	}
@@ -230,8 +348,89 @@ func (c *ContainerRequest) GetDockerfile() string {
 	return f
 }
 
+// GetTarget returns the stage of a multi-stage Dockerfile to build, the last one if empty
+func (c *ContainerRequest) GetTarget() string {
+	return c.FromDockerfile.Target
+}
+
+// GetCacheFrom returns the images to consider as cache sources when building from Dockerfile
+func (c *ContainerRequest) GetCacheFrom() []string {
+	return c.FromDockerfile.CacheFrom
+}
+
+// GetBuildLabels returns the labels to set on the image built from Dockerfile
+func (c *ContainerRequest) GetBuildLabels() map[string]string {
+	return c.FromDockerfile.Labels
+}
+
+// GetNetworkMode returns the networking mode of the RUN instructions when building from Dockerfile
+func (c *ContainerRequest) GetNetworkMode() string {
+	return c.FromDockerfile.NetworkMode
+}
+
+// GetRepo returns the repository of the image built from Dockerfile. If empty, it's derived from the context
+// and the Dockerfile, so that builds of the same Dockerfile reuse the name and their layers. The content of a
+// ContextFS or ContextArchive isn't known without reading it, so each of their builds gets a repository of its own.
+func (c *ContainerRequest) GetRepo() string {
+	if c.FromDockerfile.Repo != "" {
+		return c.FromDockerfile.Repo
+	}
+
+	if c.FromDockerfile.ContextFS != nil || c.FromDockerfile.ContextArchive != nil {
+		return "testcontainers-" + shortHash(uuid.NewString())
+	}
+
+	buildContext := c.FromDockerfile.Context
+	if abs, err := filepath.Abs(buildContext); err == nil && buildContext != "" {
+		buildContext = abs
+	}
+	return "testcontainers-" + shortHash(buildContext, c.GetDockerfile(), c.FromDockerfile.DockerfileContent)
+}
+
+// GetTag returns the tag of the image built from Dockerfile. If empty, it's derived from the build args,
+// so that builds of the same Dockerfile with different build args don't replace each other.
+func (c *ContainerRequest) GetTag() string {
+	if c.FromDockerfile.Tag != "" {
+		return c.FromDockerfile.Tag
+	}
+
+	args := c.GetBuildArgs()
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if v := args[k]; v != nil {
+			parts = append(parts, k+"="+*v)
+		} else {
+			parts = append(parts, k)
+		}
+	}
+	return shortHash(parts...)
+}
+
+// shortHash returns a hex encoded hash of the parts, short enough to be used in image names
+func shortHash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// GetPlatform returns the platform of the image to build from Dockerfile, i.e. the ImagePlatform of the request
+func (c *ContainerRequest) GetPlatform() string {
+	return c.ImagePlatform
+}
+
+// GetBuildLogWriter returns the writer the build log is streamed to, nil if it isn't printed
+func (c *ContainerRequest) GetBuildLogWriter() io.Writer {
+	return c.FromDockerfile.BuildLogWriter
+}
+
 func (c *ContainerRequest) ShouldBuildImage() bool {
-	return c.FromDockerfile.Context != "" || c.FromDockerfile.ContextArchive != nil
+	return c.FromDockerfile.Context != "" || c.FromDockerfile.ContextArchive != nil ||
+		c.FromDockerfile.ContextFS != nil || c.FromDockerfile.DockerfileContent != ""
 }
 
 func (c *ContainerRequest) ShouldPrintBuildLog() bool {
@@ -239,7 +438,7 @@ func (c *ContainerRequest) ShouldPrintBuildLog() bool {
 }
 
 func (c *ContainerRequest) validateContextAndImage() error {
-	if c.FromDockerfile.Context != "" && c.Image != "" {
+	if (c.FromDockerfile.Context != "" || c.FromDockerfile.DockerfileContent != "") && c.Image != "" {
 		return errors.New("you cannot specify both an Image and Context in a ContainerRequest")
 	}
 
@@ -247,13 +446,45 @@ func (c *ContainerRequest) validateContextAndImage() error {
 }
 
 func (c *ContainerRequest) validateContextOrImageIsSpecified() error {
-	if c.FromDockerfile.Context == "" && c.FromDockerfile.ContextArchive == nil && c.Image == "" {
+	if !c.ShouldBuildImage() && c.Image == "" {
 		return errors.New("you must specify either a build context or an image")
 	}
 
 	return nil
 }
 
+func (c *ContainerRequest) validateNetworkIPAM() error {
+	for name, ipam := range c.NetworkIPAM {
+		// a nil entry gives no static addresses, like a missing one
+		if ipam == nil {
+			continue
+		}
+
+		attached := false
+		for _, n := range c.Networks {
+			attached = attached || n == name
+		}
+		if !attached {
+			return fmt.Errorf("static addresses are given for network %s, which the container isn't attached to", name)
+		}
+
+		for _, address := range []string{ipam.IPv4Address, ipam.IPv6Address} {
+			if address != "" && net.ParseIP(address) == nil {
+				return fmt.Errorf("invalid address %s for network %s", address, name)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *ContainerRequest) validateMacAddress() error {
+	if c.MacAddress == "" {
+		return nil
+	}
+	_, err := net.ParseMAC(c.MacAddress)
+	return err
+}
+
 func (c *ContainerRequest) validateMounts() error {
 	targets := make(map[string]bool, len(c.Mounts))
 
@@ -0,0 +1,198 @@
+package testcontainers
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+)
+
+// maxSymlinks is the maximum number of symlinks followed when opening a file, like Linux' MAXSYMLINKS
+const maxSymlinks = 40
+
+// FS returns a read-only view of the file system of the container below root, e.g. to use fs.WalkDir or
+// fstest to assert on files produced in the container. Files are copied from the container when opened,
+// so the view reflects the current state of the container.
+func (c *DockerContainer) FS(ctx context.Context, root string) fs.FS {
+	return &containerFS{
+		root: path.Clean("/" + root),
+		copyFromContainer: func(p string) (io.ReadCloser, error) {
+			r, _, err := c.provider.client.CopyFromContainer(ctx, c.ID, p)
+			return r, err
+		},
+	}
+}
+
+// containerFS implements fs.FS on top of the tar archives copied from a container
+type containerFS struct {
+	root              string
+	copyFromContainer func(p string) (io.ReadCloser, error)
+}
+
+// Open implements fs.FS
+func (f *containerFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return f.open(name, 0)
+}
+
+func (f *containerFS) open(name string, symlinks int) (fs.File, error) {
+	r, err := f.copyFromContainer(path.Join(f.root, name))
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	switch header.Typeflag {
+	case tar.TypeSymlink:
+		return f.followSymlink(name, header.Linkname, symlinks)
+	case tar.TypeDir:
+		entries, err := readDirEntries(tr, header.Name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &containerDir{info: namedFileInfo{header.FileInfo(), name}, entries: entries}, nil
+	default:
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &containerFile{info: namedFileInfo{header.FileInfo(), name}, Reader: bytes.NewReader(content)}, nil
+	}
+}
+
+// followSymlink opens the target of the symlink at name, which must be within the root of the file system
+func (f *containerFS) followSymlink(name string, target string, symlinks int) (fs.File, error) {
+	if symlinks >= maxSymlinks {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("too many levels of symbolic links")}
+	}
+
+	switch {
+	case !path.IsAbs(target):
+		target = path.Join(path.Dir(name), target)
+	case f.root == "/":
+		target = path.Clean(strings.TrimPrefix(target, "/"))
+	case target == f.root:
+		target = "."
+	default:
+		target = path.Clean(strings.TrimPrefix(target, f.root+"/"))
+		if path.IsAbs(target) {
+			target = ".."
+		}
+	}
+
+	if !fs.ValidPath(target) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("symbolic link points outside of the file system")}
+	}
+
+	return f.open(target, symlinks+1)
+}
+
+// readDirEntries reads the direct children of the directory from the rest of the tar archive, sorted by name
+func readDirEntries(tr *tar.Reader, dir string) ([]fs.DirEntry, error) {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+
+	var entries []fs.DirEntry
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rel := strings.TrimSuffix(strings.TrimPrefix(header.Name, prefix), "/")
+		if rel == "" || strings.Contains(rel, "/") {
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(header.FileInfo()))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// namedFileInfo reports the base of the name a file was opened with, instead of the name in the tar archive
+type namedFileInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i namedFileInfo) Name() string {
+	return path.Base(i.name)
+}
+
+// containerFile is a regular file copied from the container
+type containerFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *containerFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *containerFile) Close() error {
+	return nil
+}
+
+// containerDir is a directory copied from the container
+type containerDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *containerDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *containerDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (d *containerDir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *containerDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+
+	return rest[:n], nil
+}
@@ -0,0 +1,115 @@
+package testcontainers
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContainerFiles emulates CopyFromContainer for the given files, directories end with a slash
+// and symlinks are given as "-> target"
+func fakeContainerFiles(t *testing.T, files map[string]string) func(p string) (io.ReadCloser, error) {
+	return func(p string) (io.ReadCloser, error) {
+		var names []string
+		for name := range files {
+			if name == p || name == p+"/" || strings.HasPrefix(name, strings.TrimSuffix(p, "/")+"/") {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, errdefs.NotFound(errors.New("no such file"))
+		}
+		sort.Strings(names)
+
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for _, name := range names {
+			content := files[name]
+			header := &tar.Header{
+				Name: path.Base(p) + strings.TrimPrefix(name, p),
+				Mode: 0644,
+			}
+			switch {
+			case strings.HasSuffix(name, "/"):
+				header.Typeflag = tar.TypeDir
+				header.Mode = 0755
+			case strings.HasPrefix(content, "-> "):
+				header.Typeflag = tar.TypeSymlink
+				header.Linkname = strings.TrimPrefix(content, "-> ")
+			default:
+				header.Typeflag = tar.TypeReg
+				header.Size = int64(len(content))
+			}
+			require.NoError(t, tw.WriteHeader(header))
+			_, err := tw.Write([]byte(content))
+			if header.Typeflag == tar.TypeReg {
+				require.NoError(t, err)
+			}
+		}
+		require.NoError(t, tw.Close())
+
+		return ioutil.NopCloser(buf), nil
+	}
+}
+
+func TestContainerFS(t *testing.T) {
+	fsys := &containerFS{
+		root: "/data",
+		copyFromContainer: fakeContainerFiles(t, map[string]string{
+			"/data/":              "",
+			"/data/hello.txt":     "hello",
+			"/data/logs/":         "",
+			"/data/logs/app.log":  "started",
+			"/data/logs/app2.log": "",
+		}),
+	}
+
+	require.NoError(t, fstest.TestFS(fsys, "hello.txt", "logs/app.log", "logs/app2.log"))
+
+	content, err := fs.ReadFile(fsys, "logs/app.log")
+	require.NoError(t, err)
+	assert.Equal(t, "started", string(content))
+
+	_, err = fsys.Open("missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestContainerFSSymlinks(t *testing.T) {
+	fsys := &containerFS{
+		root: "/data",
+		copyFromContainer: fakeContainerFiles(t, map[string]string{
+			"/data/":              "",
+			"/data/hello.txt":     "hello",
+			"/data/relative":      "-> hello.txt",
+			"/data/absolute":      "-> /data/hello.txt",
+			"/data/outside":       "-> /etc/passwd",
+			"/data/sibling":       "-> /database/hello.txt",
+			"/data/loop":          "-> loop",
+			"/database/":          "",
+			"/database/hello.txt": "hello",
+		}),
+	}
+
+	for _, name := range []string{"relative", "absolute"} {
+		content, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, "hello", string(content), name)
+	}
+
+	for _, name := range []string{"outside", "sibling", "loop"} {
+		_, err := fsys.Open(name)
+		assert.Error(t, err, name)
+	}
+}
@@ -4,15 +4,21 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types/network"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -68,6 +74,50 @@ func Test_ContainerValidation(t *testing.T) {
 				Mounts: Mounts(BindMount("/srv", "/data"), BindMount("/data", "/data")),
 			},
 		},
+		{
+			Name:          "can set static addresses on attached networks",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:       "redis:latest",
+				Networks:    []string{"backend"},
+				NetworkIPAM: map[string]*network.EndpointIPAMConfig{"backend": {IPv4Address: "172.28.5.10", IPv6Address: "fd00::10"}},
+			},
+		},
+		{
+			Name:          "cannot set static addresses on networks the container isn't attached to",
+			ExpectedError: errors.New("static addresses are given for network frontend, which the container isn't attached to"),
+			ContainerRequest: ContainerRequest{
+				Image:       "redis:latest",
+				Networks:    []string{"backend"},
+				NetworkIPAM: map[string]*network.EndpointIPAMConfig{"frontend": {IPv4Address: "172.28.5.10"}},
+			},
+		},
+		{
+			Name:          "can leave static addresses empty",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:       "redis:latest",
+				Networks:    []string{"backend"},
+				NetworkIPAM: map[string]*network.EndpointIPAMConfig{"backend": nil, "frontend": nil},
+			},
+		},
+		{
+			Name:          "cannot set invalid static addresses",
+			ExpectedError: errors.New("invalid address 172.28.5 for network backend"),
+			ContainerRequest: ContainerRequest{
+				Image:       "redis:latest",
+				Networks:    []string{"backend"},
+				NetworkIPAM: map[string]*network.EndpointIPAMConfig{"backend": {IPv4Address: "172.28.5"}},
+			},
+		},
+		{
+			Name:          "cannot set invalid MAC address",
+			ExpectedError: errors.New("address 02:42:ac:11:00: invalid MAC address"),
+			ContainerRequest: ContainerRequest{
+				Image:      "redis:latest",
+				MacAddress: "02:42:ac:11:00",
+			},
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -127,6 +177,137 @@ func Test_GetDockerfile(t *testing.T) {
 	}
 }
 
+func Test_GetRepoAndTag(t *testing.T) {
+	req := &ContainerRequest{FromDockerfile: FromDockerfile{Context: "./testresources", Dockerfile: "echo.Dockerfile"}}
+	repo, tag := req.GetRepo(), req.GetTag()
+	assert.Regexp(t, "^testcontainers-[0-9a-f]{16}$", repo)
+	assert.Regexp(t, "^[0-9a-f]{16}$", tag)
+
+	same := &ContainerRequest{FromDockerfile: FromDockerfile{Context: "testresources", Dockerfile: "echo.Dockerfile"}}
+	assert.Equal(t, repo, same.GetRepo(), "the default repository is derived from the context and Dockerfile")
+	assert.Equal(t, tag, same.GetTag(), "the default tag is derived from the build args")
+
+	other := &ContainerRequest{FromDockerfile: FromDockerfile{Context: "./testresources", Dockerfile: "Dockerfile"}}
+	assert.NotEqual(t, repo, other.GetRepo())
+
+	inline := &ContainerRequest{FromDockerfile: FromDockerfile{Context: "./testresources", Dockerfile: "echo.Dockerfile", DockerfileContent: "FROM alpine"}}
+	assert.NotEqual(t, repo, inline.GetRepo(), "the inline Dockerfile is part of the default repository")
+
+	fsys := &ContainerRequest{FromDockerfile: FromDockerfile{ContextFS: os.DirFS("testresources"), Dockerfile: "echo.Dockerfile"}}
+	assert.NotEqual(t, fsys.GetRepo(), fsys.GetRepo(), "the content of a file system context is unknown, so every build gets its own repository")
+
+	version := "1.2.3"
+	req.FromDockerfile.BuildArgs = map[string]*string{"VERSION": &version}
+	assert.NotEqual(t, tag, req.GetTag(), "builds with other build args must not replace the image")
+	assert.Equal(t, repo, req.GetRepo())
+
+	req.FromDockerfile.Repo = "app"
+	req.FromDockerfile.Tag = "latest"
+	assert.Equal(t, "app", req.GetRepo())
+	assert.Equal(t, "latest", req.GetTag())
+}
+
+func Test_BuildImageOptions(t *testing.T) {
+	var query url.Values
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/build"):
+			query = r.URL.Query()
+			_, _ = w.Write([]byte(`{"stream":"Successfully built c059bfaa849c\n"}`))
+		case strings.HasSuffix(r.URL.Path, "/images/registry.example.com/app:test/json"):
+			_, _ = w.Write([]byte(`{"Id":"sha256:c059bfaa849c"}`))
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+
+	req := &ContainerRequest{
+		ImagePlatform: "linux/amd64",
+		FromDockerfile: FromDockerfile{
+			Context:     "./testresources",
+			Dockerfile:  "echo.Dockerfile",
+			Target:      "test",
+			CacheFrom:   []string{"registry.example.com/app:cache"},
+			Labels:      map[string]string{"team": "payments", TestcontainerLabelSessionID: "spoofed"},
+			NetworkMode: "host",
+			Repo:        "registry.example.com/app",
+			Tag:         "test",
+		},
+	}
+	result, err := provider.BuildImageWithResult(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "registry.example.com/app:test", result.RepoTag)
+	assert.Equal(t, "sha256:c059bfaa849c", result.ID)
+	assert.Empty(t, result.Digest, "the image wasn't pushed")
+	assert.Greater(t, result.Duration, time.Duration(0))
+	assert.Equal(t, "registry.example.com/app:test", query.Get("t"))
+
+	assert.Equal(t, "test", query.Get("target"))
+	assert.Equal(t, `["registry.example.com/app:cache"]`, query.Get("cachefrom"))
+	assert.Equal(t, "host", query.Get("networkmode"))
+	assert.Equal(t, "linux/amd64", query.Get("platform"))
+	assert.Contains(t, query.Get("labels"), `"team":"payments"`)
+	assert.Contains(t, query.Get("labels"), `"`+TestcontainerLabelSessionID+`":"`+SessionID()+`"`, "the session label can't be overridden")
+
+	req.FromDockerfile.ImageRemoval = ImageRemovalNever
+	_, err = provider.BuildImageWithResult(context.Background(), req)
+	require.NoError(t, err)
+	assert.NotContains(t, query.Get("labels"), TestcontainerLabelSessionID, "kept images mustn't be pruned with the session")
+	assert.Contains(t, query.Get("labels"), `"team":"payments"`)
+}
+
+func Test_CreateContainerFromBuiltImage(t *testing.T) {
+	var image string
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/build"):
+			_, _ = w.Write([]byte(`{"stream":"Successfully built c059bfaa849c\n"}`))
+		case strings.HasSuffix(r.URL.Path, "/images/registry.example.com/app:test/json"):
+			_, _ = w.Write([]byte(`{"Id":"sha256:c059bfaa849c"}`))
+		case strings.HasSuffix(r.URL.Path, "/containers/create"):
+			var body struct{ Image string }
+			if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+				image = body.Image
+			}
+			_, _ = w.Write([]byte(`{"Id":"0123456789ab"}`))
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	}, DefaultNetwork(Bridge))
+
+	c, err := provider.CreateContainer(context.Background(), ContainerRequest{
+		FromDockerfile: FromDockerfile{
+			Context:    "./testresources",
+			Dockerfile: "echo.Dockerfile",
+			Repo:       "registry.example.com/app",
+			Tag:        "test",
+		},
+		ExposedPorts: []string{"8080/tcp"},
+		SkipReaper:   true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "sha256:c059bfaa849c", image, "concurrent builds may move the tag, so the container is created from the ID")
+	assert.Equal(t, "registry.example.com/app:test", c.(*DockerContainer).Image)
+}
+
+func Test_ReadBuildOutput(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := readBuildOutput(strings.NewReader(`{"stream":"Step 1/2 : FROM alpine\n"}
+{"stream":" ---\u003e c059bfaa849c\n"}
+{"stream":"Step 2/2 : RUN apk add socat\n"}
+{"stream":"ERROR: unable to select packages\n"}
+{"errorDetail":{"code":1,"message":"The command '/bin/sh -c apk add socat' returned a non-zero code: 1"},"error":"The command '/bin/sh -c apk add socat' returned a non-zero code: 1"}
+{"stream":"never read\n"}
+`), out)
+
+	assert.EqualError(t, err, "The command '/bin/sh -c apk add socat' returned a non-zero code: 1: failed to build image at Step 2/2 : RUN apk add socat")
+	assert.Equal(t, "Step 1/2 : FROM alpine\n ---> c059bfaa849c\nStep 2/2 : RUN apk add socat\nERROR: unable to select packages\n", out.String())
+
+	require.NoError(t, readBuildOutput(strings.NewReader(`{"stream":"Successfully built c059bfaa849c\n"}`), nil))
+}
+
 func Test_BuildImageWithContexts(t *testing.T) {
 	type TestCase struct {
 		Name               string
@@ -0,0 +1,53 @@
+package testcontainers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// URL returns the URL of the path on the port of the container as reachable from the test host,
+// e.g. redis://localhost:49153 or http://localhost:49154/health?ready=1. The path may contain a query.
+func (c *DockerContainer) URL(ctx context.Context, scheme string, port nat.Port, path string) (*url.URL, error) {
+	return PortURL(ctx, c, scheme, port, path)
+}
+
+// HTTPEndpoint returns the http URL of the path on the port of the container as reachable from the test host
+func (c *DockerContainer) HTTPEndpoint(ctx context.Context, port nat.Port, path string) (*url.URL, error) {
+	return c.URL(ctx, "http", port, path)
+}
+
+// PortURL implements Container.URL on top of the Host and MappedPort methods of the container,
+// e.g. for implementations of Container by other providers
+func PortURL(ctx context.Context, c Container, scheme string, port nat.Port, path string) (*url.URL, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return nil, fmt.Errorf("path %s mustn't be an absolute URL", path)
+	}
+	if u.Path != "" && !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+		if u.RawPath != "" {
+			u.RawPath = "/" + u.RawPath
+		}
+	}
+
+	host, err := c.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mapped, err := c.MappedPort(ctx, port)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Scheme = scheme
+	u.Host = net.JoinHostPort(host, mapped.Port())
+	return u, nil
+}
@@ -0,0 +1,47 @@
+package testcontainers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerURL(t *testing.T) {
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id": "0123456789ab", "HostConfig": {"NetworkMode": "default"}, "NetworkSettings": {"Ports": {
+			"80/tcp": [{"HostIp": "0.0.0.0", "HostPort": "49153"}],
+			"6379/tcp": [{"HostIp": "0.0.0.0", "HostPort": "49154"}]}}}`))
+	})
+
+	c := &DockerContainer{ID: "0123456789ab", provider: provider}
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: "http://127.0.0.1:49153"},
+		{path: "/", expected: "http://127.0.0.1:49153/"},
+		{path: "health", expected: "http://127.0.0.1:49153/health"},
+		{path: "/search?q=test%20containers", expected: "http://127.0.0.1:49153/search?q=test%20containers"},
+		{path: "?ready=1", expected: "http://127.0.0.1:49153?ready=1"},
+	} {
+		u, err := c.HTTPEndpoint(ctx, "80", tt.path)
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.expected, u.String(), tt.path)
+	}
+
+	u, err := c.URL(ctx, "redis", "6379/tcp", "/0")
+	require.NoError(t, err)
+	assert.Equal(t, "redis://127.0.0.1:49154/0", u.String())
+	assert.Equal(t, "49154", u.Port())
+
+	_, err = c.HTTPEndpoint(ctx, "80", "http://example.com/health")
+	assert.EqualError(t, err, "path http://example.com/health mustn't be an absolute URL")
+
+	_, err = c.HTTPEndpoint(ctx, "8080", "/")
+	assert.EqualError(t, err, "port not found")
+}
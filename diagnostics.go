@@ -0,0 +1,210 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+)
+
+// selfCheckImage is the image of the container started by SelfCheck
+const selfCheckImage = "docker.io/alpine:3.16"
+
+// DaemonInfo describes the Docker daemon of a provider
+type DaemonInfo struct {
+	ServerVersion   string
+	APIVersion      string // version of the API used to talk to the daemon
+	OperatingSystem string // e.g. Docker Desktop or Ubuntu 22.04.1 LTS
+	OSType          string // linux or windows
+	Architecture    string // e.g. x86_64 or aarch64
+	Rootless        bool
+	CgroupVersion   string // 1 or 2
+	CPUs            int
+	TotalMemory     int64 // in bytes
+}
+
+// String renders the information the way it is printed by SelfCheck
+func (i DaemonInfo) String() string {
+	return fmt.Sprintf("Docker %s (API %s) on %s, %s/%s, rootless: %t, cgroup v%s, %d CPUs, %s memory",
+		i.ServerVersion, i.APIVersion, i.OperatingSystem, i.OSType, i.Architecture,
+		i.Rootless, i.CgroupVersion, i.CPUs, units.BytesSize(float64(i.TotalMemory)))
+}
+
+// Info returns details about the Docker daemon, e.g. to skip tests which need a rootful or Linux daemon
+func (p *DockerProvider) Info(ctx context.Context) (DaemonInfo, error) {
+	info, err := p.client.Info(ctx)
+	if err != nil {
+		return DaemonInfo{}, err
+	}
+
+	rootless := false
+	for _, opt := range info.SecurityOptions {
+		if opt == "name=rootless" {
+			rootless = true
+		}
+	}
+
+	return DaemonInfo{
+		ServerVersion:   info.ServerVersion,
+		APIVersion:      p.client.ClientVersion(),
+		OperatingSystem: info.OperatingSystem,
+		OSType:          info.OSType,
+		Architecture:    info.Architecture,
+		Rootless:        rootless,
+		CgroupVersion:   info.CgroupVersion,
+		CPUs:            info.NCPU,
+		TotalMemory:     info.MemTotal,
+	}, nil
+}
+
+// SelfCheckResult is the outcome of one of the checks of SelfCheck
+type SelfCheckResult struct {
+	Name    string
+	Err     error // nil if the check passed
+	Skipped bool  // the check wasn't run, e.g. the one of the reaper if it's disabled
+}
+
+// SelfCheckReport is the outcome of SelfCheck
+type SelfCheckReport struct {
+	Info    DaemonInfo
+	Results []SelfCheckResult
+}
+
+// Err returns the errors of the failed checks, or nil if all checks passed
+func (r SelfCheckReport) Err() error {
+	var failed []string
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", result.Name, result.Err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return errors.New("self check failed: " + strings.Join(failed, ", "))
+}
+
+// String renders the report with one line per check
+func (r SelfCheckReport) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Info.String())
+	for _, result := range r.Results {
+		status := "ok"
+		if result.Err != nil {
+			status = "failed: " + result.Err.Error()
+		} else if result.Skipped {
+			status = "skipped"
+		}
+		fmt.Fprintf(&sb, "\n  %s: %s", result.Name, status)
+	}
+	return sb.String()
+}
+
+// SelfCheck verifies that the environment supports the features testcontainers relies on, like the startup checks
+// of testcontainers-java: it starts the reaper, unless it's disabled by ryuk.disabled, and a tiny container with a bind mount and an exposed port,
+// and checks that the mount is visible in the container and the port is reachable from the test host.
+// The report is printed with the logger of the provider, the returned error is the one of the report.
+func (p *DockerProvider) SelfCheck(ctx context.Context) (SelfCheckReport, error) {
+	report := SelfCheckReport{}
+
+	info, err := p.Info(ctx)
+	report.Results = append(report.Results, SelfCheckResult{Name: "docker info", Err: err})
+	if err != nil {
+		p.Logger.Printf("%s - Self check:\n%s", time.Now().Format(time.RFC3339), report)
+		return report, report.Err()
+	}
+	report.Info = info
+
+	if p.config.RyukDisabled {
+		report.Results = append(report.Results, SelfCheckResult{Name: "reaper", Skipped: true})
+	} else {
+		report.Results = append(report.Results, SelfCheckResult{Name: "reaper", Err: p.checkReaper(ctx)})
+	}
+	report.Results = append(report.Results, p.checkContainer(ctx)...)
+
+	p.Logger.Printf("%s - Self check:\n%s", time.Now().Format(time.RFC3339), report)
+	return report, report.Err()
+}
+
+// checkReaper starts the reaper of the session, if it isn't running yet, and connects to it
+func (p *DockerProvider) checkReaper(ctx context.Context) error {
+	r, err := NewReaper(context.WithValue(ctx, dockerHostContextKey, p.host), SessionID(), p, "")
+	if err != nil {
+		return err
+	}
+
+	terminationSignal, err := r.Connect()
+	if err != nil {
+		return err
+	}
+	terminationSignal <- true
+	return nil
+}
+
+// checkContainer runs the self check container and checks its bind mount and exposed port
+func (p *DockerProvider) checkContainer(ctx context.Context) []SelfCheckResult {
+	mountDir, err := ioutil.TempDir("", "testcontainers-selfcheck")
+	if err != nil {
+		return []SelfCheckResult{{Name: "container", Err: err}}
+	}
+	defer os.RemoveAll(mountDir)
+
+	const content = "testcontainers"
+	if err := ioutil.WriteFile(filepath.Join(mountDir, "check.txt"), []byte(content), 0o644); err != nil {
+		return []SelfCheckResult{{Name: "container", Err: err}}
+	}
+
+	port := nat.Port("8080/tcp")
+	c, err := p.RunContainer(ctx, ContainerRequest{
+		Image:        selfCheckImage,
+		Cmd:          []string{"nc", "-lk", "-p", port.Port()},
+		ExposedPorts: []string{string(port)},
+		Mounts:       Mounts(BindMount(mountDir, "/selfcheck")),
+		SkipReaper:   true,
+	})
+	if c != nil {
+		defer c.Terminate(ctx)
+	}
+	if err != nil {
+		return []SelfCheckResult{{Name: "container", Err: err}}
+	}
+
+	results := []SelfCheckResult{{Name: "container"}}
+
+	mountErr := func() error {
+		exitCode, _, err := c.Exec(ctx, []string{"grep", "-q", content, "/selfcheck/check.txt"})
+		if err != nil {
+			return err
+		}
+		if exitCode != 0 {
+			return fmt.Errorf("%s isn't visible in the container, the daemon can't access the file system of the test host", mountDir)
+		}
+		return nil
+	}()
+	results = append(results, SelfCheckResult{Name: "bind mount", Err: mountErr})
+
+	portErr := func() error {
+		host, err := c.Host(ctx)
+		if err != nil {
+			return err
+		}
+		ports, err := c.Ports(ctx)
+		if err != nil {
+			return err
+		}
+
+		var dialer net.Dialer
+		return waitForPortsReachable(ctx, host, ports, dialer.DialContext)
+	}()
+	results = append(results, SelfCheckResult{Name: "exposed port", Err: portErr})
+
+	return results
+}
@@ -0,0 +1,73 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerProvider_Info(t *testing.T) {
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.41/info" {
+			_, _ = w.Write([]byte("{}"))
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"ServerVersion": "20.10.17",
+			"OperatingSystem": "Ubuntu 22.04.1 LTS",
+			"OSType": "linux",
+			"Architecture": "x86_64",
+			"SecurityOptions": ["name=seccomp,profile=default", "name=rootless"],
+			"CgroupVersion": "2",
+			"NCPU": 4,
+			"MemTotal": 8589934592
+		}`))
+	})
+
+	info, err := provider.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, DaemonInfo{
+		ServerVersion:   "20.10.17",
+		APIVersion:      "1.41",
+		OperatingSystem: "Ubuntu 22.04.1 LTS",
+		OSType:          "linux",
+		Architecture:    "x86_64",
+		Rootless:        true,
+		CgroupVersion:   "2",
+		CPUs:            4,
+		TotalMemory:     8589934592,
+	}, info)
+	assert.Equal(t, "Docker 20.10.17 (API 1.41) on Ubuntu 22.04.1 LTS, linux/x86_64, rootless: true, cgroup v2, 4 CPUs, 8GiB memory", info.String())
+}
+
+func TestSelfCheckReport(t *testing.T) {
+	report := SelfCheckReport{
+		Info: DaemonInfo{ServerVersion: "20.10.17", APIVersion: "1.41", OSType: "linux", CgroupVersion: "2"},
+		Results: []SelfCheckResult{
+			{Name: "docker info"},
+			{Name: "reaper", Skipped: true},
+			{Name: "bind mount", Err: errors.New("not visible")},
+		},
+	}
+
+	assert.EqualError(t, report.Err(), "self check failed: bind mount: not visible")
+	assert.Contains(t, report.String(), "\n  docker info: ok\n  reaper: skipped\n  bind mount: failed: not visible")
+
+	report.Results = report.Results[:2]
+	assert.NoError(t, report.Err())
+}
+
+func TestDockerProvider_SelfCheck(t *testing.T) {
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+	defer provider.Close()
+
+	report, err := provider.SelfCheck(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, report.Results, 5)
+	assert.NotEmpty(t, report.Info.ServerVersion)
+}
@@ -3,17 +3,21 @@ package testcontainers
 import (
 	"archive/tar"
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -31,7 +35,6 @@ import (
 	"github.com/docker/go-connections/nat"
 	"github.com/google/uuid"
 	"github.com/magiconair/properties"
-	"github.com/moby/term"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -43,6 +46,8 @@ var (
 
 	logOnce                 sync.Once
 	ErrDuplicateMountTarget = errors.New("duplicate mount target detected")
+	// ErrDockerCertificateNotFound is returned if TLS verification is enabled, but a certificate is missing
+	ErrDockerCertificateNotFound = errors.New("docker TLS certificate not found")
 )
 
 const (
@@ -52,6 +57,9 @@ const (
 	packagePath   = "github.com/testcontainers/testcontainers-go"
 )
 
+// portPublishTimeout limits how long Ports and MappedPort wait for the daemon to publish the bound ports of a container
+var portPublishTimeout = 5 * time.Second
+
 // DockerContainer represents a container started using Docker
 type DockerContainer struct {
 	// Container ID from Docker
@@ -60,21 +68,41 @@ type DockerContainer struct {
 	Image      string
 
 	isRunning         bool
-	imageWasBuilt     bool
+	builtImageID      string // the ID of the image built for the container, empty if the image was pulled
+	imageRemoval      ImageRemovalPolicy
+	pruneChildren     bool
+	failOnUnhealthy   bool
+	verifyPorts       bool
+	stopTimeout       time.Duration
 	provider          *DockerProvider
 	sessionID         uuid.UUID
 	terminationSignal chan bool
 	skipReaper        bool
 	consumers         []LogConsumer
+	consumersMtx      sync.Mutex // protects consumers, which can be changed while the log producer runs
 	raw               *types.ContainerJSON
 	stopProducer      chan bool
+	producerError     chan error
 	logger            Logging
+	releaseClient     func() // releases the reference to the client of the provider, nil if none is held
+
+	// the network impairments applied by ShapeNetwork, which are cleared by ClearNetworkImpairments
+	netem NetemOptions
 }
 
 func (c *DockerContainer) GetContainerID() string {
 	return c.ID
 }
 
+// shortContainerID abbreviates the ID of a container to 12 characters like the Docker CLI does,
+// IDs which are already shorter are returned as is
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 func (c *DockerContainer) IsRunning() bool {
 	return c.isRunning
 }
@@ -131,43 +159,134 @@ func (c *DockerContainer) Host(ctx context.Context) (string, error) {
 
 // MappedPort gets externally mapped port for a container port
 func (c *DockerContainer) MappedPort(ctx context.Context, port nat.Port) (nat.Port, error) {
-	inspect, err := c.inspectContainer(ctx)
+	inspect, err := c.inspectPublishedPorts(ctx)
 	if err != nil {
 		return "", err
 	}
 	if inspect.ContainerJSONBase.HostConfig.NetworkMode == "host" {
 		return port, nil
 	}
-	ports, err := c.Ports(ctx)
-	if err != nil {
-		return "", err
-	}
 
+	return mappedPort(inspect.NetworkSettings.Ports, port)
+}
+
+// mappedPort looks up the host port of the container port in the port map. Ports without protocol are TCP ports
+// like in the Docker API, so 53 only matches 53/tcp even if 53/udp is exposed, too.
+func mappedPort(ports nat.PortMap, port nat.Port) (nat.Port, error) {
+	proto, number := nat.SplitProtoPort(string(port))
+
+	var others []string
 	for k, p := range ports {
-		if k.Port() != port.Port() {
-			continue
-		}
-		if port.Proto() != "" && k.Proto() != port.Proto() {
+		if k.Port() != number || len(p) == 0 {
 			continue
 		}
-		if len(p) == 0 {
+		if k.Proto() != proto {
+			others = append(others, string(k))
 			continue
 		}
-		return nat.NewPort(k.Proto(), p[0].HostPort)
+		return nat.NewPort(proto, p[0].HostPort)
 	}
 
+	if len(others) > 0 {
+		sort.Strings(others)
+		return "", fmt.Errorf("port %s/%s not found, but %s is exposed", number, proto, strings.Join(others, ", "))
+	}
 	return "", errors.New("port not found")
 }
 
+// UDPEndpoint gets the host:port address of the first exposed UDP port, the ports are ordered by number
+func (c *DockerContainer) UDPEndpoint(ctx context.Context) (string, error) {
+	ports, err := c.Ports(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var udpPorts []nat.Port
+	for p := range ports {
+		if p.Proto() == "udp" {
+			udpPorts = append(udpPorts, p)
+		}
+	}
+	if len(udpPorts) == 0 {
+		return "", errors.New("no UDP port exposed")
+	}
+	sort.Slice(udpPorts, func(i, j int) bool {
+		return udpPorts[i].Int() < udpPorts[j].Int()
+	})
+
+	return c.UDPPortEndpoint(ctx, udpPorts[0])
+}
+
+// UDPPortEndpoint gets the host:port address of the given UDP port, e.g. to send datagrams to DNS, syslog or statsd
+// servers. The port is a UDP port even if it's given without protocol.
+func (c *DockerContainer) UDPPortEndpoint(ctx context.Context, port nat.Port) (string, error) {
+	host, err := c.Host(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	outerPort, err := c.MappedPort(ctx, nat.Port(port.Port()+"/udp"))
+	if err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(host, outerPort.Port()), nil
+}
+
 // Ports gets the exposed ports for the container.
 func (c *DockerContainer) Ports(ctx context.Context) (nat.PortMap, error) {
-	inspect, err := c.inspectContainer(ctx)
+	inspect, err := c.inspectPublishedPorts(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return inspect.NetworkSettings.Ports, nil
 }
 
+// inspectPublishedPorts inspects the container until the daemon published the ports bound in its host config, as
+// inspecting it right after it started can return empty host ports. After portPublishTimeout, the container is
+// returned as inspected, i.e. with the ports published so far.
+func (c *DockerContainer) inspectPublishedPorts(ctx context.Context) (*types.ContainerJSON, error) {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 50 * time.Millisecond
+	b.MaxElapsedTime = portPublishTimeout
+
+	var inspect *types.ContainerJSON
+	err := backoff.Retry(func() error {
+		var err error
+		inspect, err = c.inspectContainer(ctx)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if port, ok := unpublishedPort(inspect); ok {
+			return fmt.Errorf("port %s of container %s is not published yet", port, c.ID)
+		}
+		return nil
+	}, backoff.WithContext(b, ctx))
+	if err != nil && (inspect == nil || ctx.Err() != nil) {
+		return nil, err
+	}
+	return inspect, nil
+}
+
+// unpublishedPort returns a port bound in the host config of the running container which has no published host port
+func unpublishedPort(inspect *types.ContainerJSON) (nat.Port, bool) {
+	if inspect.ContainerJSONBase == nil || inspect.State == nil || !inspect.State.Running || inspect.NetworkSettings == nil {
+		return "", false
+	}
+	// the ports of containers sharing the network of the host or of another container aren't published
+	if mode := inspect.HostConfig.NetworkMode; mode.IsHost() || mode.IsContainer() {
+		return "", false
+	}
+
+	for port := range inspect.HostConfig.PortBindings {
+		bindings := inspect.NetworkSettings.Ports[port]
+		if len(bindings) == 0 || bindings[0].HostPort == "" {
+			return port, true
+		}
+	}
+	return "", false
+}
+
 // SessionID gets the current session id
 func (c *DockerContainer) SessionID() string {
 	return c.sessionID.String()
@@ -175,9 +294,22 @@ func (c *DockerContainer) SessionID() string {
 
 // Start will start an already created container
 func (c *DockerContainer) Start(ctx context.Context) error {
+	if err := c.provider.hooks.beforeStart(ctx, c); err != nil {
+		return err
+	}
+	err := c.start(ctx)
+	c.provider.hooks.afterStart(ctx, c, err)
+	return err
+}
+
+func (c *DockerContainer) start(ctx context.Context) error {
 	shortID := c.ID[:12]
 	c.logger.Printf("Starting container id: %s image: %s", shortID, c.Image)
 
+	if err := c.provider.faults.inject(ctx, faultStart); err != nil {
+		return err
+	}
+
 	if err := c.provider.client.ContainerStart(ctx, c.ID, types.ContainerStartOptions{}); err != nil {
 		return err
 	}
@@ -185,7 +317,12 @@ func (c *DockerContainer) Start(ctx context.Context) error {
 	// if a Wait Strategy has been specified, wait before returning
 	if c.WaitingFor != nil {
 		c.logger.Printf("Waiting for container id %s image: %s", shortID, c.Image)
-		if err := c.WaitingFor.WaitUntilReady(ctx, c); err != nil {
+		if err := c.waitUntilReady(ctx); err != nil {
+			return err
+		}
+	}
+	if c.verifyPorts {
+		if err := c.verifyPortsReachable(ctx); err != nil {
 			return err
 		}
 	}
@@ -194,16 +331,78 @@ func (c *DockerContainer) Start(ctx context.Context) error {
 	return nil
 }
 
+// waitUntilReady applies the wait strategy of the container. If the container fails on unhealthy,
+// its health is monitored concurrently and waiting is aborted as soon as the container is unhealthy.
+func (c *DockerContainer) waitUntilReady(ctx context.Context) error {
+	if !c.failOnUnhealthy {
+		return c.WaitingFor.WaitUntilReady(ctx, c)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	unhealthy := make(chan error, 1)
+	go func() {
+		if err := c.monitorHealth(ctx); err != nil {
+			unhealthy <- err
+			cancel()
+		}
+	}()
+
+	err := c.WaitingFor.WaitUntilReady(ctx, c)
+	select {
+	case unhealthyErr := <-unhealthy:
+		return unhealthyErr
+	default:
+		return err
+	}
+}
+
+// monitorHealth polls the health of the container until the context is done,
+// returning an error including the output of the last healthcheck once the container is unhealthy
+func (c *DockerContainer) monitorHealth(ctx context.Context) error {
+	for {
+		inspect, err := c.inspectContainer(ctx)
+		if err != nil {
+			return nil
+		}
+
+		health := inspect.State.Health
+		if health == nil {
+			// the image doesn't define a healthcheck
+			return nil
+		}
+
+		if health.Status == types.Unhealthy {
+			output := ""
+			if len(health.Log) > 0 {
+				output = strings.TrimSpace(health.Log[len(health.Log)-1].Output)
+			}
+			return fmt.Errorf("container %s is unhealthy after %d failing healthchecks: %s", shortContainerID(c.ID), health.FailingStreak, output)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 // Stop will stop an already started container
 //
 // In case the container fails to stop
 // gracefully within a time frame specified by the timeout argument,
 // it is forcefully terminated (killed).
 //
-// If the timeout is nil, the container's StopTimeout value is used, if set,
-// otherwise the engine default. A negative timeout value can be specified,
-// meaning no timeout, i.e. no forceful termination is performed.
+// If the timeout is nil, the DefaultStopTimeout of the request is used, if set,
+// otherwise the container's StopTimeout value, if set, otherwise the engine default.
+// A negative timeout value can be specified, meaning no timeout, i.e. no forceful termination is performed.
 func (c *DockerContainer) Stop(ctx context.Context, timeout *time.Duration) error {
+	if timeout == nil && c.stopTimeout > 0 {
+		return c.stopOrKill(ctx, c.stopTimeout)
+	}
+
 	shortID := c.ID[:12]
 	c.logger.Printf("Stopping container id: %s image: %s", shortID, c.Image)
 
@@ -216,32 +415,73 @@ func (c *DockerContainer) Stop(ctx context.Context, timeout *time.Duration) erro
 	return nil
 }
 
+// stopOrKill stops the container, giving it the timeout to shut down gracefully, e.g. for databases to flush
+// their data, and kills it if it couldn't be stopped. The log states whether it was stopped or killed.
+func (c *DockerContainer) stopOrKill(ctx context.Context, timeout time.Duration) error {
+	shortID := c.ID[:12]
+	c.logger.Printf("Stopping container id: %s image: %s timeout: %s", shortID, c.Image, timeout)
+
+	if err := c.provider.client.ContainerStop(ctx, c.ID, &timeout); err != nil {
+		c.logger.Printf("Failed to stop container id: %s image: %s, killing it: %v", shortID, c.Image, err)
+		if err := c.provider.client.ContainerKill(ctx, c.ID, "SIGKILL"); err != nil {
+			return err
+		}
+
+		c.logger.Printf("Container is killed id: %s image: %s", shortID, c.Image)
+		c.isRunning = false
+		return nil
+	}
+
+	// the engine kills the container once the timeout elapsed, which results in the exit code of SIGKILL
+	if inspect, err := c.inspectContainer(ctx); err == nil && inspect.State.ExitCode == 137 {
+		c.logger.Printf("Container is killed after stop timeout of %s id: %s image: %s", timeout, shortID, c.Image)
+	} else {
+		c.logger.Printf("Container is stopped gracefully id: %s image: %s", shortID, c.Image)
+	}
+
+	c.isRunning = false
+	return nil
+}
+
 // Terminate is used to kill the container. It is usually triggered by as defer function.
 func (c *DockerContainer) Terminate(ctx context.Context) error {
+	if err := c.provider.hooks.beforeTerminate(ctx, c); err != nil {
+		return err
+	}
+	err := c.terminate(ctx)
+	c.provider.hooks.afterTerminate(ctx, c, err)
+	return err
+}
+
+func (c *DockerContainer) terminate(ctx context.Context) error {
 	select {
 	// close reaper if it was created
 	case c.terminationSignal <- true:
 	default:
 	}
+
+	// give the container the chance to shut down gracefully, it's removed forcefully anyway
+	if c.stopTimeout > 0 && c.isRunning {
+		if err := c.stopOrKill(ctx, c.stopTimeout); err != nil {
+			c.logger.Printf("failed to stop container %s before removing it: %v", c.ID, err)
+		}
+	}
+
 	err := c.provider.client.ContainerRemove(ctx, c.GetContainerID(), types.ContainerRemoveOptions{
 		RemoveVolumes: true,
 		Force:         true,
 	})
-	if err != nil {
-		return err
-	}
 
-	if c.imageWasBuilt {
-		_, err := c.provider.client.ImageRemove(ctx, c.Image, types.ImageRemoveOptions{
-			Force:         true,
-			PruneChildren: true,
-		})
-		if err != nil {
-			return err
-		}
+	if c.shouldRemoveImage(err) {
+		// the image is removed in the background, so the client is released afterwards.
+		// Close of the provider waits for the removal.
+		c.provider.imageRemovals.Add(1)
+		go c.removeImage(err == nil)
+	} else if err == nil {
+		c.release()
 	}
 
-	if err := c.provider.client.Close(); err != nil {
+	if err != nil {
 		return err
 	}
 
@@ -250,6 +490,49 @@ func (c *DockerContainer) Terminate(ctx context.Context) error {
 	return nil
 }
 
+// shouldRemoveImage checks whether the built image is removed according to the image removal policy,
+// given the error of removing the container
+func (c *DockerContainer) shouldRemoveImage(removeErr error) bool {
+	if c.builtImageID == "" {
+		return false
+	}
+
+	switch c.imageRemoval {
+	case ImageRemovalNever:
+		return false
+	case ImageRemovalAlways:
+		return true
+	default:
+		return removeErr == nil
+	}
+}
+
+// removeImage removes the built image and optionally releases the client afterwards, a failure is logged
+// as the container is gone anyway. The image is removed by its ID, as its tag may have been moved to the image
+// of another build in the meantime.
+func (c *DockerContainer) removeImage(releaseClient bool) {
+	defer c.provider.imageRemovals.Done()
+
+	_, err := c.provider.client.ImageRemove(context.Background(), c.builtImageID, types.ImageRemoveOptions{
+		Force:         true,
+		PruneChildren: c.pruneChildren,
+	})
+	if err != nil {
+		c.logger.Printf("failed to remove image %s: %v", c.Image, err)
+	}
+
+	if releaseClient {
+		c.release()
+	}
+}
+
+// release releases the reference of the container to the client of the provider
+func (c *DockerContainer) release() {
+	if c.releaseClient != nil {
+		c.releaseClient()
+	}
+}
+
 // update container raw info
 func (c *DockerContainer) inspectRawContainer(ctx context.Context) (*types.ContainerJSON, error) {
 	inspect, err := c.provider.client.ContainerInspect(ctx, c.ID)
@@ -328,8 +611,11 @@ func (c *DockerContainer) Logs(ctx context.Context) (io.ReadCloser, error) {
 }
 
 // FollowOutput adds a LogConsumer to be sent logs from the container's
-// STDOUT and STDERR
+// STDOUT and STDERR. It's safe to add consumers while the log producer is running.
 func (c *DockerContainer) FollowOutput(consumer LogConsumer) {
+	c.consumersMtx.Lock()
+	defer c.consumersMtx.Unlock()
+
 	if c.consumers == nil {
 		c.consumers = []LogConsumer{
 			consumer,
@@ -339,6 +625,28 @@ func (c *DockerContainer) FollowOutput(consumer LogConsumer) {
 	}
 }
 
+// UnfollowOutput removes a LogConsumer added with FollowOutput, so it isn't sent any more logs.
+// The consumer is compared by equality, so its type must be comparable, e.g. a pointer.
+func (c *DockerContainer) UnfollowOutput(consumer LogConsumer) {
+	c.consumersMtx.Lock()
+	defer c.consumersMtx.Unlock()
+
+	for i, existing := range c.consumers {
+		if existing == consumer {
+			c.consumers = append(c.consumers[:i:i], c.consumers[i+1:]...)
+			return
+		}
+	}
+}
+
+// logConsumers returns a copy of the consumers the logs are sent to
+func (c *DockerContainer) logConsumers() []LogConsumer {
+	c.consumersMtx.Lock()
+	defer c.consumersMtx.Unlock()
+
+	return append([]LogConsumer(nil), c.consumers...)
+}
+
 // Name gets the name of the container.
 func (c *DockerContainer) Name(ctx context.Context) (string, error) {
 	inspect, err := c.inspectContainer(ctx)
@@ -396,6 +704,37 @@ func (c *DockerContainer) ContainerIP(ctx context.Context) (string, error) {
 	return ip, nil
 }
 
+// GatewayIP gets the gateway of the container in the given network, i.e. the IP of the host on the network, e.g. to
+// call services listening on the Docker bridge of the host. Without network name, the gateway of the default bridge
+// network is returned, or the one of the only network of the container.
+func (c *DockerContainer) GatewayIP(ctx context.Context, networkName string) (string, error) {
+	inspect, err := c.inspectContainer(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if networkName != "" {
+		nw, ok := inspect.NetworkSettings.Networks[networkName]
+		if !ok {
+			return "", fmt.Errorf("container %s is not attached to network %s", c.ID, networkName)
+		}
+		return nw.Gateway, nil
+	}
+
+	gateway := inspect.NetworkSettings.Gateway
+	if gateway == "" {
+		// use the gateway from "Networks" if only single network defined
+		networks := inspect.NetworkSettings.Networks
+		if len(networks) == 1 {
+			for _, v := range networks {
+				gateway = v.Gateway
+			}
+		}
+	}
+
+	return gateway, nil
+}
+
 // ContainerIPs gets the IP addresses of all the networks within the container.
 func (c *DockerContainer) ContainerIPs(ctx context.Context) ([]string, error) {
 	ips := make([]string, 0)
@@ -431,6 +770,20 @@ func (c *DockerContainer) NetworkAliases(ctx context.Context) (map[string][]stri
 	return a, nil
 }
 
+// ConnectToNetwork connects the running container to the network, reachable by the aliases in addition to its name,
+// e.g. to reconnect it after simulating a network partition with DisconnectFromNetwork
+func (c *DockerContainer) ConnectToNetwork(ctx context.Context, networkName string, aliases ...string) error {
+	return c.provider.client.NetworkConnect(ctx, networkName, c.ID, &network.EndpointSettings{
+		Aliases: aliases,
+	})
+}
+
+// DisconnectFromNetwork disconnects the container from the network, so that it can't reach or be reached by the
+// containers on the network anymore. Force disconnects it even if the container isn't running.
+func (c *DockerContainer) DisconnectFromNetwork(ctx context.Context, networkName string, force bool) error {
+	return c.provider.client.NetworkDisconnect(ctx, networkName, c.ID, force)
+}
+
 func (c *DockerContainer) Exec(ctx context.Context, cmd []string) (int, io.Reader, error) {
 	cli := c.provider.client
 	response, err := cli.ContainerExecCreate(ctx, c.ID, types.ExecConfig{
@@ -502,8 +855,9 @@ func (c *DockerContainer) CopyFileFromContainer(ctx context.Context, filePath st
 }
 
 // CopyDirToContainer copies the contents of a directory to a parent path in the container. This parent path must exist in the container first
-// as we cannot create it
-func (c *DockerContainer) CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, fileMode int64) error {
+// as we cannot create it.
+// The tar headers of the copied files can be rewritten with transforms, e.g. to change their ownership
+func (c *DockerContainer) CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, fileMode int64, transforms ...TarHeaderTransform) error {
 	dir, err := isDir(hostDirPath)
 	if err != nil {
 		return err
@@ -514,48 +868,57 @@ func (c *DockerContainer) CopyDirToContainer(ctx context.Context, hostDirPath st
 		return fmt.Errorf("path %s is not a directory", hostDirPath)
 	}
 
-	buff, err := tarDir(hostDirPath, fileMode)
+	buff, err := tarDir(hostDirPath, fileMode, transforms...)
 	if err != nil {
 		return err
 	}
 
 	// create the directory under its parent
-	parent := filepath.Dir(containerParentPath)
+	parent := containerPathDir(containerParentPath)
 
 	return c.provider.client.CopyToContainer(ctx, c.ID, parent, buff, types.CopyToContainerOptions{})
 }
 
-func (c *DockerContainer) CopyFileToContainer(ctx context.Context, hostFilePath string, containerFilePath string, fileMode int64) error {
+// CopyFileToContainer copies a file or directory from the host to the container.
+// The tar headers of the copied files can be rewritten with transforms, e.g. to change their ownership
+func (c *DockerContainer) CopyFileToContainer(ctx context.Context, hostFilePath string, containerFilePath string, fileMode int64, transforms ...TarHeaderTransform) error {
 	dir, err := isDir(hostFilePath)
 	if err != nil {
 		return err
 	}
 
 	if dir {
-		return c.CopyDirToContainer(ctx, hostFilePath, containerFilePath, fileMode)
+		return c.CopyDirToContainer(ctx, hostFilePath, containerFilePath, fileMode, transforms...)
 	}
 
 	fileContent, err := ioutil.ReadFile(hostFilePath)
 	if err != nil {
 		return err
 	}
-	return c.CopyToContainer(ctx, fileContent, containerFilePath, fileMode)
+	return c.CopyToContainer(ctx, fileContent, containerFilePath, fileMode, transforms...)
 }
 
 // CopyToContainer copies fileContent data to a file in container
-func (c *DockerContainer) CopyToContainer(ctx context.Context, fileContent []byte, containerFilePath string, fileMode int64) error {
-	buffer, err := tarFile(fileContent, containerFilePath, fileMode)
+// The tar header of the file can be rewritten with transforms, e.g. to change its ownership
+func (c *DockerContainer) CopyToContainer(ctx context.Context, fileContent []byte, containerFilePath string, fileMode int64, transforms ...TarHeaderTransform) error {
+	buffer, err := tarFile(fileContent, containerFilePath, fileMode, transforms...)
 	if err != nil {
 		return err
 	}
 
-	return c.provider.client.CopyToContainer(ctx, c.ID, filepath.Dir(containerFilePath), buffer, types.CopyToContainerOptions{})
+	return c.provider.client.CopyToContainer(ctx, c.ID, containerPathDir(containerFilePath), buffer, types.CopyToContainerOptions{})
 }
 
 // StartLogProducer will start a concurrent process that will continuously read logs
-// from the container and will send them to each added LogConsumer
+// from the container and will send them to each added LogConsumer.
+// Errors occurring while reading the logs stop the producer, they are logged and returned by StopLogProducer
 func (c *DockerContainer) StartLogProducer(ctx context.Context) error {
+	producerError := make(chan error, 1)
+	c.producerError = producerError
+
 	go func() {
+		defer close(producerError)
+
 		since := ""
 		// if the socket is closed we will make additional logs request with updated Since timestamp
 	BEGIN:
@@ -571,18 +934,19 @@ func (c *DockerContainer) StartLogProducer(ctx context.Context) error {
 
 		r, err := c.provider.client.ContainerLogs(ctx, c.GetContainerID(), options)
 		if err != nil {
-			// if we can't get the logs, panic, we can't return an error to anything
-			// from within this goroutine
-			panic(err)
+			// we can't return an error to anything from within this goroutine,
+			// so we log it and hand it over to StopLogProducer
+			c.logger.Printf("cannot get logs for container %s: %v", c.ID, err)
+			producerError <- err
+			return
 		}
 
 		for {
 			select {
 			case <-c.stopProducer:
-				err := r.Close()
-				if err != nil {
-					// we can't close the read closer, this should never happen
-					panic(err)
+				if err := r.Close(); err != nil {
+					c.logger.Printf("cannot close logs of container %s: %v", c.ID, err)
+					producerError <- err
 				}
 				return
 			default:
@@ -606,7 +970,7 @@ func (c *DockerContainer) StartLogProducer(ctx context.Context) error {
 				}
 				logType := h[0]
 				if logType > 2 {
-					_, _ = fmt.Fprintf(os.Stderr, "received invalid log type: %d", logType)
+					c.logger.Printf("received invalid log type: %d", logType)
 					// sometimes docker returns logType = 3 which is an undocumented log type, so treat it as stdout
 					logType = 1
 				}
@@ -617,11 +981,10 @@ func (c *DockerContainer) StartLogProducer(ctx context.Context) error {
 				b := make([]byte, count)
 				_, err = r.Read(b)
 				if err != nil {
-					// TODO: add-logger: use logger to log out this error
-					_, _ = fmt.Fprintf(os.Stderr, "error occurred reading log with known length %s", err.Error())
+					c.logger.Printf("error occurred reading log with known length %s", err.Error())
 					continue
 				}
-				for _, c := range c.consumers {
+				for _, c := range c.logConsumers() {
 					c.Accept(Log{
 						LogType: logTypes[logType],
 						Content: b,
@@ -635,10 +998,24 @@ func (c *DockerContainer) StartLogProducer(ctx context.Context) error {
 }
 
 // StopLogProducer will stop the concurrent process that is reading logs
-// and sending them to each added LogConsumer
+// and sending them to each added LogConsumer.
+// It returns the error which stopped the producer prematurely, if any
 func (c *DockerContainer) StopLogProducer() error {
-	c.stopProducer <- true
-	return nil
+	if c.producerError == nil {
+		return nil
+	}
+
+	select {
+	case c.stopProducer <- true:
+	case err := <-c.producerError:
+		// the producer already stopped on its own
+		c.producerError = nil
+		return err
+	}
+
+	err := <-c.producerError
+	c.producerError = nil
+	return err
 }
 
 // DockerNetwork represents a network started using Docker
@@ -648,6 +1025,7 @@ type DockerNetwork struct {
 	Name              string
 	provider          *DockerProvider
 	terminationSignal chan bool
+	releaseClient     func()
 }
 
 // Remove is used to remove the network. It is usually triggered by as defer function.
@@ -657,32 +1035,101 @@ func (n *DockerNetwork) Remove(ctx context.Context) error {
 	case n.terminationSignal <- true:
 	default:
 	}
-	return n.provider.client.NetworkRemove(ctx, n.ID)
+	if err := n.provider.client.NetworkRemove(ctx, n.ID); err != nil {
+		return err
+	}
+
+	if n.releaseClient != nil {
+		n.releaseClient()
+	}
+	return nil
+}
+
+// Inspect returns the configuration of the network, e.g. its subnets and gateways in IPAM, and the containers attached to it
+func (n *DockerNetwork) Inspect(ctx context.Context) (types.NetworkResource, error) {
+	return n.provider.client.NetworkInspect(ctx, n.ID, types.NetworkInspectOptions{})
+}
+
+// ConnectedContainers returns the endpoints of the containers attached to the network by container ID
+func (n *DockerNetwork) ConnectedContainers(ctx context.Context) (map[string]types.EndpointResource, error) {
+	resource, err := n.Inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Containers, nil
 }
 
 // DockerProvider implements the ContainerProvider interface
 type DockerProvider struct {
 	*DockerProviderOptions
-	client    *client.Client
-	host      string
-	hostCache string
-	config    TestContainersConfig
+	client       *sharedClient
+	closeOnce    sync.Once
+	host         string
+	hostCache    string
+	gatewayMtx   sync.Mutex
+	gatewayCache map[string]string // gateway IPs by network name
+	config       TestContainersConfig
+
+	imageRemovals     sync.WaitGroup // removals of built images in the background, awaited by Close
+	defaultNetworkMtx sync.Mutex     // guards the lookup of DefaultNetwork on first use
 }
 
 var _ ContainerProvider = (*DockerProvider)(nil)
 
+// Close releases the Docker client of the provider, once the built images of terminated containers are removed.
+// The client stays open until all containers and networks created by the provider are terminated or removed as well.
+func (p *DockerProvider) Close() error {
+	p.imageRemovals.Wait()
+	p.closeOnce.Do(p.client.release)
+	return nil
+}
+
 // or through Decode
 type TestContainersConfig struct {
 	Host           string `properties:"docker.host,default="`
 	TLSVerify      int    `properties:"docker.tls.verify,default=0"`
 	CertPath       string `properties:"docker.cert.path,default="`
 	RyukPrivileged bool   `properties:"ryuk.container.privileged,default=false"`
+	RyukMemory     string `properties:"ryuk.container.memory,default="` // memory limit of the reaper container, e.g. 64m
+	RyukCPUs       string `properties:"ryuk.container.cpus,default="`   // CPU limit of the reaper container, e.g. 0.5
+	// disables probing the well-known Docker socket locations if neither docker.host nor DOCKER_HOST is set
+	DisableSocketDetection bool `properties:"docker.socket.detection.disabled,default=false"`
+	// pins the version of the Docker API, e.g. 1.41, instead of negotiating it with the daemon
+	APIVersion string `properties:"docker.api.version,default="`
+	// endpoint of a hosted Docker backend, e.g. Testcontainers Cloud. It takes precedence over docker.host
+	// and DOCKER_HOST, and the ports of containers are exposed on its host as well
+	TCHost string `properties:"tc.host,default="`
+	// prefix of the images of Docker Hub, e.g. registry.example.com/mirror/ to pull them through a registry mirror
+	HubImageNamePrefix string `properties:"hub.image.name.prefix,default="`
+	// locks pulls across processes, so that the test binaries of go test ./... pull each image only once
+	PullLockEnabled bool `properties:"pull.lock.enabled,default=false"`
+	// attaches all containers to a network shared within the test session, like the WithSessionNetwork option
+	SessionNetworkEnabled bool `properties:"session.network.enabled,default=false"`
+	// image of the reaper, e.g. one mirrored to an internal registry, unless a request sets its ReaperImage
+	RyukImage string `properties:"ryuk.container.image,default="`
+	// how long to wait for the reaper to become reachable and how long the reaper waits for the first connection,
+	// 10s and 60s by default
+	RyukConnectionTimeout time.Duration `properties:"ryuk.connection.timeout,default=0"`
+	// how long the reaper waits for a reconnection before it cleans up, 10s by default
+	RyukReconnectionTimeout time.Duration `properties:"ryuk.reconnection.timeout,default=0"`
+	// skips the reaper for all containers and networks, e.g. on CI runners which are discarded after the tests
+	RyukDisabled bool `properties:"ryuk.disabled,default=false"`
 }
 
 type (
 	// DockerProviderOptions defines options applicable to DockerProvider
 	DockerProviderOptions struct {
 		defaultBridgeNetworkName string
+		faults                   *faultInjector
+		dockerClient             client.APIClient
+		ownsDockerClient         bool // the provider created the client and closes it
+		httpClient               *http.Client
+		apiVersion               string
+		hooks                    providerHooks
+		imageSubstitutors        []ImageSubstitutor
+		pullProgress             []PullProgressFunc
+		pullLogMode              PullLogMode
+		attachSessionNetwork     bool // attach all containers to the network of the session
 		*GenericProviderOptions
 	}
 
@@ -722,34 +1169,101 @@ func WithDefaultBridgeNetwork(bridgeNetworkName string) DockerProviderOption {
 	})
 }
 
+// WithDockerClient makes the provider use the Docker client instead of creating its own one from the environment,
+// e.g. to talk to the daemon through a corporate proxy or to log requests. The client is not closed by the provider.
+func WithDockerClient(cli client.APIClient) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.dockerClient = cli
+	})
+}
+
+// WithHTTPClient makes the Docker client created by the provider send its requests using the HTTP client,
+// e.g. to use a custom TLS configuration or proxy. The daemon is still configured through the environment.
+// An *http.Transport is configured to connect to the daemon, any other transport must be able to reach it on its own,
+// e.g. by wrapping an *http.Transport dialing the Docker socket.
+func WithHTTPClient(httpClient *http.Client) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.httpClient = httpClient
+	})
+}
+
+// WithAPIVersion pins the version of the Docker API used by the provider, which skips negotiating it with the daemon.
+// It takes precedence over the docker.api.version property and the DOCKER_API_VERSION environment variable.
+func WithAPIVersion(version string) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.apiVersion = version
+	})
+}
+
 func NewDockerClient() (cli *client.Client, host string, tcConfig TestContainersConfig, err error) {
+	return newDockerClient(nil, "")
+}
+
+// httpClientOpts returns the option to use the HTTP client, if it's applied before or after configuring the host.
+// Only clients using an *http.Transport can be configured to connect to the host, so all others are applied afterwards.
+func httpClientOpts(httpClient *http.Client, beforeHost bool) []client.Opt {
+	if httpClient == nil {
+		return nil
+	}
+
+	_, configurable := httpClient.Transport.(*http.Transport)
+	if configurable != beforeHost {
+		return nil
+	}
+	return []client.Opt{client.WithHTTPClient(httpClient)}
+}
+
+// newDockerClient creates the Docker client configured by the environment, using the HTTP client if not nil.
+// The API version, if not empty, overrides the one of the configuration.
+func newDockerClient(httpClient *http.Client, apiVersion string) (cli *client.Client, host string, tcConfig TestContainersConfig, err error) {
 	tcConfig = configureTC()
+	if apiVersion != "" {
+		tcConfig.APIVersion = apiVersion
+	}
 
 	host = tcConfig.Host
+	if tcConfig.TCHost != "" {
+		host = tcConfig.TCHost
+	}
 
-	opts := []client.Opt{client.FromEnv}
+	opts := append(httpClientOpts(httpClient, true), client.FromEnv)
 	if host != "" {
 		opts = append(opts, client.WithHost(host))
-
-		// for further informacion, read https://docs.docker.com/engine/security/protect-access/
-		if tcConfig.TLSVerify == 1 {
-			cacertPath := filepath.Join(tcConfig.CertPath, "ca.pem")
-			certPath := filepath.Join(tcConfig.CertPath, "cert.pem")
-			keyPath := filepath.Join(tcConfig.CertPath, "key.pem")
-
-			opts = append(opts, client.WithTLSClientConfig(cacertPath, certPath, keyPath))
-		}
 	} else if dockerHostEnv := os.Getenv("DOCKER_HOST"); dockerHostEnv != "" {
 		host = dockerHostEnv
+	} else if detectedHost, ok := detectDefaultDockerHost(tcConfig); ok {
+		host = detectedHost
+		opts = append(opts, client.WithHost(host))
 	} else {
 		host = "unix:///var/run/docker.sock"
 	}
 
+	// for further informacion, read https://docs.docker.com/engine/security/protect-access/
+	if tcConfig.TLSVerify == 1 && (strings.HasPrefix(host, "tcp://") || strings.HasPrefix(host, "https://")) {
+		tlsOpt, err := tlsClientOpt(tcConfig.CertPath)
+		if err != nil {
+			return nil, "", TestContainersConfig{}, err
+		}
+		opts = append(opts, tlsOpt)
+	}
+
+	if strings.HasPrefix(host, "ssh://") {
+		sshOpts, err := sshClientOpts(host)
+		if err != nil {
+			return nil, "", TestContainersConfig{}, err
+		}
+		opts = append(opts, sshOpts...)
+	}
+
 	opts = append(opts, client.WithHTTPHeaders(
 		map[string]string{
 			"x-tc-sid": sessionID().String(),
 		}),
 	)
+	opts = append(opts, httpClientOpts(httpClient, false)...)
+
+	// a pinned version skips negotiating the version
+	opts = append(opts, client.WithVersion(tcConfig.APIVersion))
 
 	cli, err = client.NewClientWithOpts(opts...)
 
@@ -762,6 +1276,30 @@ func NewDockerClient() (cli *client.Client, host string, tcConfig TestContainers
 	return cli, host, tcConfig, nil
 }
 
+// tlsClientOpt returns the option verifying the daemon with the certificates in the directory,
+// which defaults to ~/.docker like for the Docker CLI
+func tlsClientOpt(certDir string) (client.Opt, error) {
+	if certDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		certDir = filepath.Join(home, ".docker")
+	}
+
+	cacertPath := filepath.Join(certDir, "ca.pem")
+	certPath := filepath.Join(certDir, "cert.pem")
+	keyPath := filepath.Join(certDir, "key.pem")
+
+	for _, p := range []string{cacertPath, certPath, keyPath} {
+		if _, err := os.Stat(p); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrDockerCertificateNotFound, p)
+		}
+	}
+
+	return client.WithTLSClientConfig(cacertPath, certPath, keyPath), nil
+}
+
 // NewDockerProvider creates a Docker provider with the EnvClient
 func NewDockerProvider(provOpts ...DockerProviderOption) (*DockerProvider, error) {
 	o := &DockerProviderOptions{
@@ -774,21 +1312,11 @@ func NewDockerProvider(provOpts ...DockerProviderOption) (*DockerProvider, error
 		provOpts[idx].ApplyDockerTo(o)
 	}
 
-	c, host, tcConfig, err := NewDockerClient()
+	c, host, tcConfig, err := providerClient(o)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = c.Ping(context.TODO())
-	if err != nil {
-		// fallback to environment
-		c, err = client.NewClientWithOpts(client.FromEnv)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	c.NegotiateAPIVersion(context.Background())
 	p := &DockerProvider{
 		DockerProviderOptions: o,
 		host:                  host,
@@ -802,6 +1330,86 @@ func NewDockerProvider(provOpts ...DockerProviderOption) (*DockerProvider, error
 	return p, nil
 }
 
+// NewDockerProviderFor creates a Docker provider bound to the daemon at the host, regardless of the environment,
+// e.g. to start containers on an amd64 and an arm64 daemon in the same test. The host is given like DOCKER_HOST,
+// e.g. tcp://arm64.example.com:2376. Unless certPath is empty, TLS is used and the daemon is verified with the
+// ca.pem, cert.pem and key.pem files in it. Each daemon gets a reaper of its own.
+func NewDockerProviderFor(host string, certPath string, provOpts ...DockerProviderOption) (*DockerProvider, error) {
+	o := &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{}}
+	for idx := range provOpts {
+		provOpts[idx].ApplyDockerTo(o)
+	}
+
+	opts := append(httpClientOpts(o.httpClient, true), client.WithHost(host))
+	if certPath != "" {
+		tlsOpt, err := tlsClientOpt(certPath)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, tlsOpt)
+	}
+	if strings.HasPrefix(host, "ssh://") {
+		sshOpts, err := sshClientOpts(host)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sshOpts...)
+	}
+
+	opts = append(opts, client.WithHTTPHeaders(
+		map[string]string{
+			"x-tc-sid": sessionID().String(),
+		}),
+	)
+	opts = append(opts, httpClientOpts(o.httpClient, false)...)
+	opts = append(opts, client.WithVersion(o.apiVersion), client.WithAPIVersionNegotiation())
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := NewDockerProvider(append(provOpts, DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.dockerClient = cli
+		opts.ownsDockerClient = true
+	}))...)
+	if err != nil {
+		_ = cli.Close()
+		return nil, err
+	}
+	return provider, nil
+}
+
+// providerClient returns the Docker client passed in with the options, or creates one from the environment
+func providerClient(o *DockerProviderOptions) (*sharedClient, string, TestContainersConfig, error) {
+	if o.dockerClient != nil {
+		if o.ownsDockerClient {
+			return newSharedClient(o.dockerClient), o.dockerClient.DaemonHost(), configureTC(), nil
+		}
+		return borrowSharedClient(o.dockerClient), o.dockerClient.DaemonHost(), configureTC(), nil
+	}
+
+	c, host, tcConfig, err := newDockerClient(o.httpClient, o.apiVersion)
+	if err != nil {
+		return nil, "", TestContainersConfig{}, err
+	}
+
+	_, err = c.Ping(context.TODO())
+	if err != nil {
+		// fallback to environment
+		opts := append(httpClientOpts(o.httpClient, true), client.FromEnv)
+		opts = append(opts, httpClientOpts(o.httpClient, false)...)
+		c, err = client.NewClientWithOpts(append(opts, client.WithVersion(tcConfig.APIVersion))...)
+		if err != nil {
+			return nil, "", TestContainersConfig{}, err
+		}
+	}
+
+	c.NegotiateAPIVersion(context.Background())
+
+	return newSharedClient(c), host, tcConfig, nil
+}
+
 func (p *DockerProvider) logDockerServerInfo() {
 	infoMessage := `%v - Connected to docker: 
   Server Version: %v
@@ -826,11 +1434,76 @@ func configureTC() TestContainersConfig {
 	config := TestContainersConfig{}
 
 	applyEnvironmentConfiguration := func(config TestContainersConfig) TestContainersConfig {
+		if ryukDisabledEnv := os.Getenv("TESTCONTAINERS_RYUK_DISABLED"); ryukDisabledEnv != "" {
+			config.RyukDisabled = ryukDisabledEnv == "true"
+		}
+
 		ryukPrivilegedEnv := os.Getenv("TESTCONTAINERS_RYUK_CONTAINER_PRIVILEGED")
 		if ryukPrivilegedEnv != "" {
 			config.RyukPrivileged = ryukPrivilegedEnv == "true"
 		}
 
+		if ryukMemoryEnv := os.Getenv("TESTCONTAINERS_RYUK_CONTAINER_MEMORY"); ryukMemoryEnv != "" {
+			config.RyukMemory = ryukMemoryEnv
+		}
+
+		if ryukCPUsEnv := os.Getenv("TESTCONTAINERS_RYUK_CONTAINER_CPUS"); ryukCPUsEnv != "" {
+			config.RyukCPUs = ryukCPUsEnv
+		}
+
+		if ryukImageEnv := os.Getenv("TESTCONTAINERS_RYUK_CONTAINER_IMAGE"); ryukImageEnv != "" {
+			config.RyukImage = ryukImageEnv
+		}
+
+		if timeoutEnv := os.Getenv("TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT"); timeoutEnv != "" {
+			if timeout, err := time.ParseDuration(timeoutEnv); err == nil {
+				config.RyukConnectionTimeout = timeout
+			} else {
+				fmt.Printf("invalid TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT, it is ignored: %v\n", err)
+			}
+		}
+
+		if timeoutEnv := os.Getenv("TESTCONTAINERS_RYUK_RECONNECTION_TIMEOUT"); timeoutEnv != "" {
+			if timeout, err := time.ParseDuration(timeoutEnv); err == nil {
+				config.RyukReconnectionTimeout = timeout
+			} else {
+				fmt.Printf("invalid TESTCONTAINERS_RYUK_RECONNECTION_TIMEOUT, it is ignored: %v\n", err)
+			}
+		}
+
+		if socketDetectionDisabledEnv := os.Getenv("TESTCONTAINERS_DOCKER_SOCKET_DETECTION_DISABLED"); socketDetectionDisabledEnv != "" {
+			config.DisableSocketDetection = socketDetectionDisabledEnv == "true"
+		}
+
+		if tcHostEnv := os.Getenv("TESTCONTAINERS_TC_HOST"); tcHostEnv != "" {
+			config.TCHost = tcHostEnv
+		}
+
+		if pullLockEnv := os.Getenv("TESTCONTAINERS_PULL_LOCK_ENABLED"); pullLockEnv != "" {
+			config.PullLockEnabled = pullLockEnv == "true"
+		}
+
+		if sessionNetworkEnv := os.Getenv("TESTCONTAINERS_SESSION_NETWORK_ENABLED"); sessionNetworkEnv != "" {
+			config.SessionNetworkEnabled = sessionNetworkEnv == "true"
+		}
+
+		if prefixEnv := os.Getenv("TESTCONTAINERS_HUB_IMAGE_NAME_PREFIX"); prefixEnv != "" {
+			config.HubImageNamePrefix = prefixEnv
+		}
+
+		if apiVersionEnv := os.Getenv("TESTCONTAINERS_DOCKER_API_VERSION"); apiVersionEnv != "" {
+			config.APIVersion = apiVersionEnv
+		}
+
+		// the variables of the Docker CLI, any value enables the verification like for the CLI
+		if tlsVerifyEnv := os.Getenv("DOCKER_TLS_VERIFY"); tlsVerifyEnv != "" {
+			config.TLSVerify = 1
+		}
+
+		if certPathEnv := os.Getenv("DOCKER_CERT_PATH"); certPathEnv != "" {
+			config.CertPath = certPathEnv
+		}
+
 		return config
 	}
 
@@ -854,16 +1527,58 @@ func configureTC() TestContainersConfig {
 	return applyEnvironmentConfiguration(config)
 }
 
+// ImageBuildResult describes an image built by a DockerProvider
+type ImageBuildResult struct {
+	ID       string        // the ID of the image, i.e. the digest of its configuration
+	RepoTag  string        // the repository and tag of the image
+	Digest   string        // the digest of the image in a registry, empty unless it was pushed
+	Duration time.Duration // how long building the image took
+}
+
 // BuildImage will build and image from context and Dockerfile, then return the tag
 func (p *DockerProvider) BuildImage(ctx context.Context, img ImageBuildInfo) (string, error) {
-	repo := uuid.New()
-	tag := uuid.New()
+	result, err := p.BuildImageWithResult(ctx, img)
+	if err != nil {
+		return "", err
+	}
+	return result.RepoTag, nil
+}
+
+// keepsImage reports whether the image is built for a request keeping it beyond the session
+func keepsImage(img ImageBuildInfo) bool {
+	req, ok := img.(*ContainerRequest)
+	return ok && req.FromDockerfile.ImageRemoval == ImageRemovalNever
+}
 
-	repoTag := fmt.Sprintf("%s:%s", repo, tag)
+// BuildImageWithResult builds an image from context and Dockerfile like BuildImage, returning a description of
+// the image. The image carries the session labels, so it's removed by the reaper and Prune with the session,
+// unless the ImageRemoval of the request is ImageRemovalNever.
+func (p *DockerProvider) BuildImageWithResult(ctx context.Context, img ImageBuildInfo) (ImageBuildResult, error) {
+	start := time.Now()
+	result := ImageBuildResult{RepoTag: fmt.Sprintf("%s:%s", img.GetRepo(), img.GetTag())}
+	repoTag := result.RepoTag
 
 	buildContext, err := img.GetContext()
 	if err != nil {
-		return "", err
+		return result, err
+	}
+
+	labels := map[string]string{}
+	for k, v := range img.GetBuildLabels() {
+		labels[k] = v
+	}
+	if keepsImage(img) {
+		// the image is meant to be reused in subsequent test runs, so it mustn't be pruned with the session
+		for k := range SessionLabels() {
+			delete(labels, k)
+		}
+	} else {
+		for k, v := range SessionLabels() { // so that the image is pruned with the session
+			labels[k] = v
+		}
+	}
+	for k, v := range StandardLabels() {
+		labels[k] = v
 	}
 
 	buildOptions := types.ImageBuildOptions{
@@ -871,66 +1586,157 @@ func (p *DockerProvider) BuildImage(ctx context.Context, img ImageBuildInfo) (st
 		Dockerfile:  img.GetDockerfile(),
 		Context:     buildContext,
 		Tags:        []string{repoTag},
+		Labels:      labels,
+		Target:      img.GetTarget(),
+		CacheFrom:   img.GetCacheFrom(),
+		NetworkMode: img.GetNetworkMode(),
+		Platform:    img.GetPlatform(),
 		Remove:      true,
 		ForceRemove: true,
 	}
 
 	resp, err := p.client.ImageBuild(ctx, buildContext, buildOptions)
 	if err != nil {
-		return "", err
+		return result, err
 	}
+	defer resp.Body.Close()
 
-	if img.ShouldPrintBuildLog() {
-		termFd, isTerm := term.GetFdInfo(os.Stderr)
-		err = jsonmessage.DisplayJSONMessagesStream(resp.Body, os.Stderr, termFd, isTerm, nil)
-		if err != nil {
-			return "", err
-		}
+	out := img.GetBuildLogWriter()
+	if out == nil && img.ShouldPrintBuildLog() {
+		out = os.Stderr
 	}
 
-	// need to read the response from Docker, I think otherwise the image
-	// might not finish building before continuing to execute here
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(resp.Body)
+	// the image is built once the response is read completely
+	if err := readBuildOutput(resp.Body, out); err != nil {
+		return result, err
+	}
+	result.Duration = time.Since(start)
+
+	image, _, err := p.client.ImageInspectWithRaw(ctx, repoTag)
 	if err != nil {
-		return "", err
+		return result, fmt.Errorf("%w: failed to inspect built image %s", err, repoTag)
+	}
+	result.ID = image.ID
+	if len(image.RepoDigests) > 0 {
+		result.Digest = image.RepoDigests[0]
 	}
 
-	_ = resp.Body.Close()
+	return result, nil
+}
+
+// readBuildOutput reads the messages of a build as they arrive, writing them to out unless it's nil.
+// A failing build is reported as soon as the daemon reports the error, along with the failing step.
+func readBuildOutput(r io.Reader, out io.Writer) error {
+	var step string
+	decoder := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		err := decoder.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(msg.Stream, "Step ") {
+			step = strings.TrimSpace(msg.Stream)
+		}
+		if msg.Error != nil {
+			if step == "" {
+				return fmt.Errorf("%w: failed to build image", msg.Error)
+			}
+			return fmt.Errorf("%w: failed to build image at %s", msg.Error, step)
+		}
 
-	return repoTag, nil
+		if out != nil {
+			if err := msg.Display(out, false); err != nil {
+				return err
+			}
+		}
+	}
 }
 
 // CreateContainer fulfills a request for a container without starting it
 func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerRequest) (Container, error) {
+	if err := p.hooks.beforeCreate(ctx, &req); err != nil {
+		return nil, err
+	}
+	c, err := p.createContainer(ctx, req)
+	p.hooks.afterCreate(ctx, c, err)
+	return c, err
+}
+
+func (p *DockerProvider) createContainer(ctx context.Context, req ContainerRequest) (Container, error) {
 	var err error
 
+	if err = p.faults.inject(ctx, faultCreate); err != nil {
+		return nil, err
+	}
+
 	// Make sure that bridge network exists
 	// In case it is disabled we will create reaper_default network
-	if p.DefaultNetwork == "" {
-		p.DefaultNetwork, err = p.getDefaultNetwork(ctx, p.client)
-		if err != nil {
-			return nil, err
-		}
+	defaultNetwork, err := p.defaultNetwork(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// If default network is not bridge make sure it is attached to the request
 	// as container won't be attached to it automatically
 	// in case of Podman the bridge network is called 'podman' as 'bridge' would conflict
-	if p.DefaultNetwork != p.defaultBridgeNetworkName {
+	if defaultNetwork != p.defaultBridgeNetworkName {
 		isAttached := false
 		for _, net := range req.Networks {
-			if net == p.DefaultNetwork {
+			if net == defaultNetwork {
 				isAttached = true
 				break
 			}
 		}
 
 		if !isAttached {
-			req.Networks = append(req.Networks, p.DefaultNetwork)
+			req.Networks = append(req.Networks, defaultNetwork)
 		}
 	}
 
+	// aliases don't resolve on the default bridge network, so containers with aliases which would end up on it are
+	// attached to the network of the session instead, which gets all their aliases. A user-defined default network
+	// of the provider resolves aliases, so the containers stay on it.
+	onDefaultBridge := defaultNetwork == p.defaultBridgeNetworkName && len(req.Networks) == 0 && req.NetworkMode == ""
+	if onDefaultBridge && len(req.NetworkAliases) > 0 {
+		networkName, err := p.sessionNetwork(ctx, SessionNetworkName, req.SkipReaper)
+		if err != nil {
+			return nil, err
+		}
+		p.Logger.Printf("Network aliases don't resolve on the default bridge network, attaching the container to %s", networkName)
+
+		req.Networks = []string{networkName}
+		req.NetworkAliases = map[string][]string{networkName: aliasesOfAllNetworks(req.NetworkAliases)}
+	}
+
+	if (p.attachSessionNetwork || p.config.SessionNetworkEnabled) && req.NetworkMode == "" {
+		networkName, err := p.sessionNetwork(ctx, SessionNetworkName, req.SkipReaper)
+		if err != nil {
+			return nil, err
+		}
+
+		isAttached := false
+		for _, net := range req.Networks {
+			isAttached = isAttached || net == networkName
+		}
+		if !isAttached {
+			req.Networks = append(req.Networks, networkName)
+		}
+	}
+
+	gatewayNetwork := ""
+	if len(req.Networks) > 0 {
+		gatewayNetwork = req.Networks[0]
+	}
+	extraHosts, err := p.resolveHostGateway(ctx, req.ExtraHosts, gatewayNetwork)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to resolve the host gateway", err)
+	}
+
 	env := []string{}
 	for envKey, envVar := range req.Env {
 		env = append(env, envKey+"="+envVar)
@@ -943,7 +1749,7 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 	sessionID := sessionID()
 
 	var termSignal chan bool
-	if !req.SkipReaper {
+	if !req.SkipReaper && !p.config.RyukDisabled {
 		r, err := NewReaper(context.WithValue(ctx, dockerHostContextKey, p.host), sessionID.String(), p, req.ReaperImage)
 		if err != nil {
 			return nil, fmt.Errorf("%w: creating reaper failed", err)
@@ -960,28 +1766,41 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 	} else {
 		p.printReaperBanner("container")
 	}
+	for k, v := range StandardLabels() {
+		if _, ok := req.Labels[k]; !ok {
+			req.Labels[k] = v
+		}
+	}
 
 	if err = req.Validate(); err != nil {
 		return nil, err
 	}
 
-	var tag string
+	var tag, builtImageID string
 	var platform *specs.Platform
 
+	if req.ImagePlatform != "" {
+		p, err := platforms.Parse(req.ImagePlatform)
+		if err != nil {
+			return nil, fmt.Errorf("invalid platform %s: %w", req.ImagePlatform, err)
+		}
+		platform = &p
+	}
+
 	if req.ShouldBuildImage() {
-		tag, err = p.BuildImage(ctx, &req)
+		result, err := p.BuildImageWithResult(ctx, &req)
 		if err != nil {
 			return nil, err
 		}
+		// the container is created from the ID of the built image, as concurrent builds may move its tag
+		tag, builtImageID = result.RepoTag, result.ID
 	} else {
-		tag = req.Image
-
-		if req.ImagePlatform != "" {
-			p, err := platforms.Parse(req.ImagePlatform)
-			if err != nil {
-				return nil, fmt.Errorf("invalid platform %s: %w", req.ImagePlatform, err)
-			}
-			platform = &p
+		tag, err = substituteImage(p.config, p.imageSubstitutors, req.Image)
+		if err != nil {
+			return nil, err
+		}
+		if tag != req.Image {
+			p.Logger.Printf("Substituted image %s with %s", req.Image, tag)
 		}
 
 		var shouldPullImage bool
@@ -997,7 +1816,7 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 					return nil, err
 				}
 			}
-			if platform != nil && (image.Architecture != platform.Architecture || image.Os != platform.OS) {
+			if platform != nil && !imageMatchesPlatform(image, *platform) {
 				shouldPullImage = true
 			}
 		}
@@ -1007,8 +1826,16 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 				Platform: req.ImagePlatform, // may be empty
 			}
 
-			if req.RegistryCred != "" {
+			switch {
+			case req.RegistryAuth != nil:
+				pullOpt.RegistryAuth, err = req.RegistryAuth.encode()
+			case req.RegistryCred != "":
 				pullOpt.RegistryAuth = req.RegistryCred
+			default:
+				pullOpt.RegistryAuth, err = registryAuth(tag)
+			}
+			if err != nil {
+				return nil, err
 			}
 
 			if err := p.attemptToPullImage(ctx, tag, pullOpt); err != nil {
@@ -1017,9 +1844,14 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		}
 	}
 
+	imageRef := tag
+	if builtImageID != "" {
+		imageRef = builtImageID
+	}
+
 	exposedPorts := req.ExposedPorts
 	if len(exposedPorts) == 0 && !req.NetworkMode.IsContainer() {
-		image, _, err := p.client.ImageInspectWithRaw(ctx, tag)
+		image, _, err := p.client.ImageInspectWithRaw(ctx, imageRef)
 		if err != nil {
 			return nil, err
 		}
@@ -1035,12 +1867,14 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 
 	dockerInput := &container.Config{
 		Entrypoint:   req.Entrypoint,
-		Image:        tag,
+		Image:        imageRef,
 		Env:          env,
 		ExposedPorts: exposedPortSet,
 		Labels:       req.Labels,
 		Cmd:          req.Cmd,
 		Hostname:     req.Hostname,
+		Domainname:   req.Domainname,
+		MacAddress:   req.MacAddress,
 		User:         req.User,
 	}
 
@@ -1048,7 +1882,10 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 	mounts := mapToDockerMounts(req.Mounts)
 
 	hostConfig := &container.HostConfig{
-		ExtraHosts:   req.ExtraHosts,
+		ExtraHosts:   extraHosts,
+		DNS:          req.DNS,
+		DNSSearch:    req.DNSSearch,
+		DNSOptions:   req.DNSOptions,
 		PortBindings: exposedPortMap,
 		Binds:        req.Binds,
 		Mounts:       mounts,
@@ -1060,6 +1897,7 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		ShmSize:      req.ShmSize,
 		CapAdd:       req.CapAdd,
 		CapDrop:      req.CapDrop,
+		Isolation:    req.Isolation,
 	}
 
 	endpointConfigs := map[string]*network.EndpointSettings{}
@@ -1075,8 +1913,9 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		})
 		if err == nil {
 			endpointSetting := network.EndpointSettings{
-				Aliases:   req.NetworkAliases[attachContainerTo],
-				NetworkID: nw.ID,
+				Aliases:    req.NetworkAliases[attachContainerTo],
+				IPAMConfig: req.NetworkIPAM[attachContainerTo],
+				NetworkID:  nw.ID,
 			}
 			endpointConfigs[attachContainerTo] = &endpointSetting
 		}
@@ -1099,7 +1938,8 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 			})
 			if err == nil {
 				endpointSetting := network.EndpointSettings{
-					Aliases: req.NetworkAliases[n],
+					Aliases:    req.NetworkAliases[n],
+					IPAMConfig: req.NetworkIPAM[n],
 				}
 				err = p.client.NetworkConnect(ctx, nw.ID, resp.ID, &endpointSetting)
 				if err != nil {
@@ -1113,18 +1953,25 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		ID:                resp.ID,
 		WaitingFor:        req.WaitingFor,
 		Image:             tag,
-		imageWasBuilt:     req.ShouldBuildImage(),
+		builtImageID:      builtImageID,
+		imageRemoval:      req.FromDockerfile.ImageRemoval,
+		pruneChildren:     req.FromDockerfile.PruneChildren,
 		sessionID:         sessionID,
 		provider:          p,
 		terminationSignal: termSignal,
 		skipReaper:        req.SkipReaper,
+		failOnUnhealthy:   req.FailOnUnhealthy,
+		verifyPorts:       req.VerifyPortsReachable,
+		stopTimeout:       req.DefaultStopTimeout,
 		stopProducer:      make(chan bool),
 		logger:            p.Logger,
+		releaseClient:     p.client.acquire(),
 	}
 
 	for _, f := range req.Files {
 		err := c.CopyFileToContainer(ctx, f.HostFilePath, f.ContainerFilePath, f.FileMode)
 		if err != nil {
+			c.release()
 			return nil, fmt.Errorf("can't copy %s to container: %w", f.HostFilePath, err)
 		}
 	}
@@ -1163,7 +2010,7 @@ func (p *DockerProvider) ReuseOrCreateContainer(ctx context.Context, req Contain
 
 	sessionID := sessionID()
 	var termSignal chan bool
-	if !req.SkipReaper {
+	if !req.SkipReaper && !p.config.RyukDisabled {
 		r, err := NewReaper(ctx, sessionID.String(), p, req.ReaperImage)
 		if err != nil {
 			return nil, fmt.Errorf("%w: creating reaper failed", err)
@@ -1183,17 +2030,130 @@ func (p *DockerProvider) ReuseOrCreateContainer(ctx context.Context, req Contain
 		provider:          p,
 		terminationSignal: termSignal,
 		skipReaper:        req.SkipReaper,
+		failOnUnhealthy:   req.FailOnUnhealthy,
+		verifyPorts:       req.VerifyPortsReachable,
+		stopTimeout:       req.DefaultStopTimeout,
 		stopProducer:      make(chan bool),
 		logger:            p.Logger,
 		isRunning:         c.State == "running",
+		releaseClient:     p.client.acquire(),
 	}
 	return dc, nil
 
 }
 
+// imageMatchesPlatform checks whether the image was built for the platform, including the variant of ARM platforms,
+// so that e.g. a linux/arm/v7 image present locally isn't used for linux/arm64
+func imageMatchesPlatform(image types.ImageInspect, platform specs.Platform) bool {
+	return platforms.NewMatcher(platform).Match(specs.Platform{
+		OS:           image.Os,
+		Architecture: image.Architecture,
+		Variant:      image.Variant,
+	})
+}
+
+// imagePulls deduplicates concurrent pulls of the same image, so that parallel tests requesting
+// an image which isn't present yet only pull it once
+var imagePulls = &pullGroup{pulls: map[string]*imagePull{}}
+
+// imagePull is a pull in progress, done is closed once it finished
+type imagePull struct {
+	done    chan struct{}
+	err     error
+	waiters int // callers waiting for the pull to finish
+}
+
+type pullGroup struct {
+	mu    sync.Mutex
+	pulls map[string]*imagePull
+}
+
+// do runs pull unless a pull with the same key is in progress already, in which case it waits for that pull
+// to finish and returns its result. If that pull was canceled by the context of its caller, e.g. because
+// its test timed out, the waiting callers pull the image themselves instead of failing as well.
+func (g *pullGroup) do(ctx context.Context, key string, pull func() error) error {
+	g.mu.Lock()
+	for {
+		p, ok := g.pulls[key]
+		if !ok {
+			break
+		}
+		p.waiters++
+		g.mu.Unlock()
+
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			g.mu.Lock()
+			p.waiters--
+			g.mu.Unlock()
+			return ctx.Err()
+		}
+		if !errors.Is(p.err, context.Canceled) && !errors.Is(p.err, context.DeadlineExceeded) {
+			return p.err
+		}
+
+		g.mu.Lock()
+	}
+
+	p := &imagePull{done: make(chan struct{})}
+	g.pulls[key] = p
+	g.mu.Unlock()
+
+	p.err = pull()
+
+	g.mu.Lock()
+	delete(g.pulls, key)
+	g.mu.Unlock()
+	close(p.done)
+
+	return p.err
+}
+
+// waiting returns the number of callers waiting for the pull in progress with the key
+func (g *pullGroup) waiting(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if p, ok := g.pulls[key]; ok {
+		return p.waiters
+	}
+	return 0
+}
+
 // attemptToPullImage tries to pull the image while respecting the ctx cancellations.
+// Concurrent pulls of the same image are deduplicated.
 // Besides, if the image cannot be pulled due to ErrorNotFound then no need to retry but terminate immediately.
 func (p *DockerProvider) attemptToPullImage(ctx context.Context, tag string, pullOpt types.ImagePullOptions) error {
+	if err := p.faults.inject(ctx, faultPull); err != nil {
+		return err
+	}
+
+	// the same image might be pulled by providers of different daemons at the same time
+	key := p.host + "/" + tag + "@" + pullOpt.Platform
+	return imagePulls.do(ctx, key, func() error {
+		if !p.config.PullLockEnabled {
+			return p.pullImage(ctx, tag, pullOpt)
+		}
+
+		unlock, waited, err := acquirePullLock(ctx, key)
+		if err != nil {
+			return fmt.Errorf("%w: failed to lock the pull of %s", err, tag)
+		}
+		defer unlock()
+
+		if waited && pullOpt.Platform == "" {
+			// another process held the lock, which most likely pulled the image
+			if _, _, err := p.client.ImageInspectWithRaw(ctx, tag); err == nil {
+				return nil
+			}
+		}
+		return p.pullImage(ctx, tag, pullOpt)
+	})
+}
+
+// pullImage pulls the image, retrying with an exponential backoff
+func (p *DockerProvider) pullImage(ctx context.Context, tag string, pullOpt types.ImagePullOptions) error {
 	var (
 		err  error
 		pull io.ReadCloser
@@ -1215,8 +2175,7 @@ func (p *DockerProvider) attemptToPullImage(ctx context.Context, tag string, pul
 	defer pull.Close()
 
 	// download of docker image finishes at EOF of the pull request
-	_, err = ioutil.ReadAll(pull)
-	return err
+	return p.readPullProgress(tag, pull)
 }
 
 // Health measure the healthiness of the provider. Right now we leverage the
@@ -1246,9 +2205,19 @@ func (p *DockerProvider) Config() TestContainersConfig {
 	return p.config
 }
 
-// daemonHost gets the host or ip of the Docker daemon where ports are exposed on
+// DaemonHost gets the host or ip of the Docker daemon where the ports of containers are exposed on, e.g. localhost.
+// If the tests run in a container themselves, this is the gateway IP of the default network, because the
+// exposed ports aren't reachable on localhost then. The host is only determined once.
 // Warning: this is based on your Docker host setting. Will fail if using an SSH tunnel
 // You can use the "TC_HOST" env variable to set this yourself
+func (p *DockerProvider) DaemonHost(ctx context.Context) (string, error) {
+	return p.daemonHost(ctx)
+}
+
+// daemonHost gets the host or ip of the Docker daemon where ports are exposed on
+// Warning: this is based on your Docker host setting. For ssh:// hosts this is the SSH target,
+// which fails if it is an alias from the SSH config or if the ports are only reachable through a tunnel.
+// You can use the "TC_HOST" env variable to set this yourself
 func (p *DockerProvider) daemonHost(ctx context.Context) (string, error) {
 	if p.hostCache != "" {
 		return p.hostCache, nil
@@ -1269,9 +2238,12 @@ func (p *DockerProvider) daemonHost(ctx context.Context) (string, error) {
 	switch url.Scheme {
 	case "http", "https", "tcp":
 		p.hostCache = url.Hostname()
-	case "unix", "npipe":
+	case "npipe":
+		// named pipes are local to Windows hosts, which expose the ports of containers on localhost
+		p.hostCache = "localhost"
+	case "unix":
 		if inAContainer() {
-			ip, err := p.GetGatewayIP(ctx)
+			ip, err := p.GatewayIP(ctx, "")
 			if err != nil {
 				// fallback to getDefaultGatewayIP
 				ip, err = getDefaultGatewayIP()
@@ -1296,10 +2268,8 @@ func (p *DockerProvider) CreateNetwork(ctx context.Context, req NetworkRequest)
 
 	// Make sure that bridge network exists
 	// In case it is disabled we will create reaper_default network
-	if p.DefaultNetwork == "" {
-		if p.DefaultNetwork, err = p.getDefaultNetwork(ctx, p.client); err != nil {
-			return nil, err
-		}
+	if _, err = p.defaultNetwork(ctx); err != nil {
+		return nil, err
 	}
 
 	if req.Labels == nil {
@@ -1314,10 +2284,16 @@ func (p *DockerProvider) CreateNetwork(ctx context.Context, req NetworkRequest)
 		Attachable:     req.Attachable,
 		Labels:         req.Labels,
 		IPAM:           req.IPAM,
+		Options:        req.Options,
+		Scope:          req.Scope,
+		ConfigOnly:     req.ConfigOnly,
+	}
+	if req.ConfigFrom != "" {
+		nc.ConfigFrom = &network.ConfigReference{Network: req.ConfigFrom}
 	}
 
 	var termSignal chan bool
-	if !req.SkipReaper {
+	if !req.SkipReaper && !p.config.RyukDisabled {
 		sessionID := sessionID()
 		r, err := NewReaper(context.WithValue(ctx, dockerHostContextKey, p.host), sessionID.String(), p, req.ReaperImage)
 		if err != nil {
@@ -1335,6 +2311,11 @@ func (p *DockerProvider) CreateNetwork(ctx context.Context, req NetworkRequest)
 	} else {
 		p.printReaperBanner("network")
 	}
+	for k, v := range StandardLabels() {
+		if _, ok := req.Labels[k]; !ok {
+			req.Labels[k] = v
+		}
+	}
 
 	response, err := p.client.NetworkCreate(ctx, req.Name, nc)
 	if err != nil {
@@ -1347,6 +2328,7 @@ func (p *DockerProvider) CreateNetwork(ctx context.Context, req NetworkRequest)
 		Name:              req.Name,
 		terminationSignal: termSignal,
 		provider:          p,
+		releaseClient:     p.client.acquire(),
 	}
 
 	return n, nil
@@ -1364,34 +2346,74 @@ func (p *DockerProvider) GetNetwork(ctx context.Context, req NetworkRequest) (ty
 	return networkResource, err
 }
 
+// GetNetworkByID returns the network with the given ID or ID prefix, e.g. the ID of a DockerNetwork or the NetworkID
+// of the network settings of a container. Unlike GetNetwork, it doesn't match networks by name.
+func (p *DockerProvider) GetNetworkByID(ctx context.Context, id string) (types.NetworkResource, error) {
+	resource, err := p.client.NetworkInspect(ctx, id, types.NetworkInspectOptions{})
+	if err != nil {
+		return types.NetworkResource{}, err
+	}
+
+	// the daemon looks up networks by name, too
+	if !strings.HasPrefix(resource.ID, id) {
+		return types.NetworkResource{}, errdefs.NotFound(fmt.Errorf("network %s not found", id))
+	}
+	return resource, nil
+}
+
+// GetGatewayIP returns the gateway IP of the default network
+// Deprecated: use GatewayIP, or DaemonHost to get the host the ports of containers are exposed on
 func (p *DockerProvider) GetGatewayIP(ctx context.Context) (string, error) {
-	// Use a default network as defined in the DockerProvider
-	if p.DefaultNetwork == "" {
+	return p.GatewayIP(ctx, "")
+}
+
+// GatewayIP returns the IP of the gateway of the given network, or of the default network if empty.
+// Containers attached to the network reach the Docker host through it. Unlike DaemonHost it isn't affected
+// by TC_HOST or the Docker host setting. The gateway IP is cached per network.
+func (p *DockerProvider) GatewayIP(ctx context.Context, networkName string) (string, error) {
+	if networkName == "" {
+		// Use a default network as defined in the DockerProvider
 		var err error
-		p.DefaultNetwork, err = p.getDefaultNetwork(ctx, p.client)
-		if err != nil {
+		if networkName, err = p.defaultNetwork(ctx); err != nil {
 			return "", err
 		}
 	}
-	nw, err := p.GetNetwork(ctx, NetworkRequest{Name: p.DefaultNetwork})
+
+	p.gatewayMtx.Lock()
+	defer p.gatewayMtx.Unlock()
+
+	if ip, ok := p.gatewayCache[networkName]; ok {
+		return ip, nil
+	}
+
+	nw, err := p.GetNetwork(ctx, NetworkRequest{Name: networkName})
 	if err != nil {
 		return "", err
 	}
 
-	var ip string
-	for _, config := range nw.IPAM.Config {
-		if config.Gateway != "" {
-			ip = config.Gateway
-			break
-		}
-	}
+	ip := gatewayFromIPAM(nw.IPAM.Config)
 	if ip == "" {
 		return "", errors.New("Failed to get gateway IP from network settings")
 	}
 
+	if p.gatewayCache == nil {
+		p.gatewayCache = map[string]string{}
+	}
+	p.gatewayCache[networkName] = ip
+
 	return ip, nil
 }
 
+// gatewayFromIPAM returns the first gateway of the IPAM configs of a network
+func gatewayFromIPAM(configs []network.IPAMConfig) string {
+	for _, config := range configs {
+		if config.Gateway != "" {
+			return config.Gateway
+		}
+	}
+	return ""
+}
+
 func (p *DockerProvider) printReaperBanner(resource string) {
 	ryukDisabledMessage := `
 	**********************************************************************************************
@@ -1402,6 +2424,10 @@ func (p *DockerProvider) printReaperBanner(resource string) {
 }
 
 func inAContainer() bool {
+	// the detection is Linux specific, Windows containers don't have the marker file
+	if runtime.GOOS == "windows" {
+		return false
+	}
 	// see https://github.com/testcontainers/testcontainers-java/blob/3ad8d80e2484864e554744a4800a81f6b7982168/core/src/main/java/org/testcontainers/dockerclient/DockerClientConfigUtils.java#L15
 	if _, err := os.Stat("/.dockerenv"); err == nil {
 		return true
@@ -1425,7 +2451,22 @@ func getDefaultGatewayIP() (string, error) {
 	return ip, nil
 }
 
-func (p *DockerProvider) getDefaultNetwork(ctx context.Context, cli *client.Client) (string, error) {
+// defaultNetwork returns the DefaultNetwork of the provider, looking it up on first use if it isn't configured
+func (p *DockerProvider) defaultNetwork(ctx context.Context) (string, error) {
+	p.defaultNetworkMtx.Lock()
+	defer p.defaultNetworkMtx.Unlock()
+
+	if p.DefaultNetwork == "" {
+		network, err := p.getDefaultNetwork(ctx, p.client)
+		if err != nil {
+			return "", err
+		}
+		p.DefaultNetwork = network
+	}
+	return p.DefaultNetwork, nil
+}
+
+func (p *DockerProvider) getDefaultNetwork(ctx context.Context, cli client.APIClient) (string, error) {
 	// Get list of available networks
 	networkResources, err := cli.NetworkList(ctx, types.NetworkListOptions{})
 	if err != nil {
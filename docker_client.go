@@ -0,0 +1,57 @@
+package testcontainers
+
+import (
+	"sync"
+
+	"github.com/docker/docker/client"
+)
+
+// sharedClient is a Docker client shared by a provider and the containers and networks created by it.
+// Each of them holds a reference, and the client is only closed once the last reference is released,
+// so terminating one container doesn't close the client of all others.
+type sharedClient struct {
+	client.APIClient
+	borrowed bool // the client was passed in by the user, who remains responsible for closing it
+	mtx      sync.Mutex
+	refs     int
+}
+
+// newSharedClient wraps the client, holding the reference of its creator
+func newSharedClient(c client.APIClient) *sharedClient {
+	return &sharedClient{APIClient: c, refs: 1}
+}
+
+// borrowSharedClient wraps a client passed in by the user, which is never closed
+func borrowSharedClient(c client.APIClient) *sharedClient {
+	return &sharedClient{APIClient: c, borrowed: true, refs: 1}
+}
+
+// acquire adds a reference to the client, the returned func releases it and may be called multiple times
+func (c *sharedClient) acquire() func() {
+	c.mtx.Lock()
+	c.refs++
+	c.mtx.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(c.release)
+	}
+}
+
+// release removes a reference from the client and closes it once there are no references left
+func (c *sharedClient) release() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.refs--
+	if c.refs == 0 && !c.borrowed {
+		_ = c.APIClient.Close()
+	}
+}
+
+// references returns the number of references held to the client
+func (c *sharedClient) references() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.refs
+}
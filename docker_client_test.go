@@ -0,0 +1,249 @@
+package testcontainers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/env"
+)
+
+func TestSharedClient(t *testing.T) {
+	cli, err := client.NewClientWithOpts(client.WithHost("unix:///var/run/docker.sock"))
+	require.NoError(t, err)
+
+	shared := newSharedClient(cli)
+	assert.Equal(t, 1, shared.references())
+
+	releaseA := shared.acquire()
+	releaseB := shared.acquire()
+	assert.Equal(t, 3, shared.references())
+
+	releaseA()
+	releaseA()
+	assert.Equal(t, 2, shared.references(), "releasing a reference twice must not release another one")
+
+	shared.release()
+	releaseB()
+	assert.Equal(t, 0, shared.references())
+}
+
+func TestDockerProviderCloseKeepsClientOfContainers(t *testing.T) {
+	cli, err := client.NewClientWithOpts(client.WithHost("unix:///var/run/docker.sock"))
+	require.NoError(t, err)
+
+	provider := &DockerProvider{client: newSharedClient(cli)}
+	containerA := &DockerContainer{provider: provider, releaseClient: provider.client.acquire()}
+	containerB := &DockerContainer{provider: provider, releaseClient: provider.client.acquire()}
+
+	require.NoError(t, provider.Close())
+	require.NoError(t, provider.Close())
+	assert.Equal(t, 2, provider.client.references())
+
+	containerA.release()
+	containerA.release()
+	assert.Equal(t, 1, provider.client.references(), "terminating one container must keep the client of the others open")
+
+	containerB.release()
+	assert.Equal(t, 0, provider.client.references())
+
+	// containers not created by the provider, e.g. those of compose projects, don't hold a reference
+	(&DockerContainer{provider: provider}).release()
+	assert.Equal(t, 0, provider.client.references())
+}
+
+func TestDockerProviderCloseWaitsForImageRemoval(t *testing.T) {
+	removing := make(chan struct{})
+	unblock := make(chan struct{})
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "/images/") {
+			close(removing)
+			<-unblock
+		}
+		_, _ = w.Write([]byte("[]"))
+	})
+
+	c := &DockerContainer{
+		ID:            "0123456789ab",
+		Image:         "testcontainers-app:latest",
+		builtImageID:  "sha256:c059bfaa849c",
+		provider:      provider,
+		logger:        provider.Logger,
+		releaseClient: provider.client.acquire(),
+	}
+	require.NoError(t, c.Terminate(context.Background()), "the image is removed in the background")
+	<-removing
+
+	closed := make(chan struct{})
+	go func() {
+		_ = provider.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close must wait for the removal of the image")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(unblock)
+	<-closed
+	assert.Equal(t, 0, provider.client.references())
+}
+
+// fakeDaemon is a Docker API answering every request with an empty JSON object, recording the requested paths
+func fakeDaemon(t *testing.T) (*httptest.Server, *[]string) {
+	var mtx sync.Mutex
+	var paths []string
+	server := fakeDaemonServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		paths = append(paths, r.URL.Path)
+		mtx.Unlock()
+
+		_, _ = w.Write([]byte("{}"))
+	})
+
+	return server, &paths
+}
+
+// fakeDaemonServer is a Docker API answering the requests with the handler, as JSON of API version 1.41 by default
+func fakeDaemonServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// fakeDaemonClient returns a client of the fake daemon, using the API version
+func fakeDaemonClient(t *testing.T, server *httptest.Server, version string) *client.Client {
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+server.Listener.Addr().String()), client.WithVersion(version))
+	require.NoError(t, err)
+	return cli
+}
+
+// fakeDaemonProvider returns a DockerProvider of a fake daemon answering the requests with the handler,
+// which logs to the test
+func fakeDaemonProvider(t *testing.T, handler http.HandlerFunc, opts ...DockerProviderOption) *DockerProvider {
+	cli := fakeDaemonClient(t, fakeDaemonServer(t, handler), "1.41")
+	provider, err := NewDockerProvider(append([]DockerProviderOption{WithDockerClient(cli), WithLogger(TestLogger(t))}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Close() })
+
+	return provider
+}
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	requests int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithDockerClient(t *testing.T) {
+	server, paths := fakeDaemon(t)
+
+	cli := fakeDaemonClient(t, server, "1.41")
+	provider, err := NewDockerProvider(WithDockerClient(cli))
+	require.NoError(t, err)
+
+	assert.Equal(t, cli.DaemonHost(), provider.host)
+	require.NoError(t, provider.Health(context.Background()))
+	assert.Contains(t, *paths, "/_ping")
+
+	require.NoError(t, provider.Close())
+	assert.Equal(t, 0, provider.client.references())
+	assert.True(t, provider.client.borrowed, "the client passed in must not be closed by the provider")
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	server, _ := fakeDaemon(t)
+	env.Patch(t, "HOME", t.TempDir())
+	env.Patch(t, "DOCKER_HOST", "tcp://"+server.Listener.Addr().String())
+
+	transport := &countingTransport{}
+	provider, err := NewDockerProvider(WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	defer provider.Close()
+
+	before := atomic.LoadInt32(&transport.requests)
+	assert.Greater(t, before, int32(0), "creating the provider should have pinged the daemon")
+
+	require.NoError(t, provider.Health(context.Background()))
+	assert.Equal(t, before+1, atomic.LoadInt32(&transport.requests))
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	server, paths := fakeDaemon(t)
+	env.Patch(t, "HOME", t.TempDir())
+	env.Patch(t, "DOCKER_HOST", "tcp://"+server.Listener.Addr().String())
+
+	t.Run("option", func(t *testing.T) {
+		provider, err := NewDockerProvider(WithAPIVersion("1.30"))
+		require.NoError(t, err)
+		defer provider.Close()
+
+		assert.Equal(t, "1.30", provider.client.ClientVersion())
+		assert.Equal(t, "1.30", provider.Config().APIVersion)
+
+		_, err = provider.client.Info(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, *paths, "/v1.30/info")
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		env.Patch(t, "TESTCONTAINERS_DOCKER_API_VERSION", "1.32")
+
+		provider, err := NewDockerProvider()
+		require.NoError(t, err)
+		defer provider.Close()
+
+		assert.Equal(t, "1.32", provider.client.ClientVersion())
+	})
+
+	t.Run("option takes precedence", func(t *testing.T) {
+		env.Patch(t, "TESTCONTAINERS_DOCKER_API_VERSION", "1.32")
+
+		provider, err := NewDockerProvider(WithAPIVersion("1.30"))
+		require.NoError(t, err)
+		defer provider.Close()
+
+		assert.Equal(t, "1.30", provider.client.ClientVersion())
+	})
+}
+
+func TestNewDockerProviderFor(t *testing.T) {
+	amd64, amd64Paths := fakeDaemon(t)
+	arm64, arm64Paths := fakeDaemon(t)
+	env.Patch(t, "HOME", t.TempDir())
+	env.Patch(t, "DOCKER_HOST", "tcp://"+amd64.Listener.Addr().String())
+
+	provider, err := NewDockerProviderFor("tcp://"+arm64.Listener.Addr().String(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "tcp://"+arm64.Listener.Addr().String(), provider.host)
+	require.NoError(t, provider.Health(context.Background()))
+	assert.Contains(t, *arm64Paths, "/_ping")
+	assert.Empty(t, *amd64Paths, "DOCKER_HOST must be ignored")
+
+	require.NoError(t, provider.Close())
+	assert.False(t, provider.client.borrowed, "the provider created the client and must close it")
+}
+
+func TestNewDockerProviderForMissingCertificates(t *testing.T) {
+	_, err := NewDockerProviderFor("tcp://arm64.example.com:2376", t.TempDir())
+	assert.ErrorIs(t, err, ErrDockerCertificateNotFound)
+}
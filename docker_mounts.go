@@ -97,7 +97,7 @@ func mapToDockerMounts(containerMounts ContainerMounts) []mount.Mount {
 			Type:     mountType,
 			Source:   m.Source.Source(),
 			ReadOnly: m.ReadOnly,
-			Target:   m.Target.Target(),
+			Target:   normalizeContainerPath(m.Target.Target()),
 		}
 
 		switch typedMounter := m.Source.(type) {
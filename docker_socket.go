@@ -0,0 +1,86 @@
+package testcontainers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// socketPingTimeout limits how long a detected socket may take to answer a ping
+const socketPingTimeout = 2 * time.Second
+
+// dockerSocketCandidates returns the well-known locations of Docker sockets in the order they are probed:
+// the default socket, followed by the sockets of Docker Desktop, Colima and Rancher Desktop in the user's home directory
+func dockerSocketCandidates(home string) []string {
+	candidates := []string{"/var/run/docker.sock"}
+	if home == "" {
+		return candidates
+	}
+
+	return append(candidates,
+		filepath.Join(home, ".docker", "run", "docker.sock"),
+		filepath.Join(home, ".docker", "desktop", "docker.sock"),
+		filepath.Join(home, ".colima", "default", "docker.sock"),
+		filepath.Join(home, ".colima", "docker.sock"),
+		filepath.Join(home, ".rd", "docker.sock"),
+	)
+}
+
+// detectDockerHost returns the host of the first candidate socket which exists and belongs to a healthy daemon
+func detectDockerHost(ctx context.Context, candidates []string, healthy func(ctx context.Context, host string) bool) (string, bool) {
+	for _, socket := range candidates {
+		info, err := os.Stat(socket)
+		if err != nil || info.Mode()&os.ModeSocket == 0 {
+			continue
+		}
+
+		host := "unix://" + socket
+		if healthy(ctx, host) {
+			return host, true
+		}
+	}
+
+	return "", false
+}
+
+// pingDockerHost reports whether the daemon listening on host answers a ping
+func pingDockerHost(ctx context.Context, host string) bool {
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return false
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, socketPingTimeout)
+	defer cancel()
+
+	_, err = cli.Ping(ctx)
+	return err == nil
+}
+
+// the result of probing the well-known socket locations, which is done once per process
+var (
+	detectedDockerHost     string
+	detectedDockerHostOK   bool
+	detectedDockerHostOnce sync.Once
+)
+
+// detectDefaultDockerHost probes the well-known socket locations, unless detection is disabled
+// or not applicable because the platform doesn't use unix sockets.
+// The sockets are probed on the first call only, as every new client would ping all candidates otherwise.
+func detectDefaultDockerHost(tcConfig TestContainersConfig) (string, bool) {
+	if tcConfig.DisableSocketDetection || runtime.GOOS == "windows" {
+		return "", false
+	}
+
+	detectedDockerHostOnce.Do(func() {
+		home, _ := os.UserHomeDir()
+		detectedDockerHost, detectedDockerHostOK = detectDockerHost(context.Background(), dockerSocketCandidates(home), pingDockerHost)
+	})
+	return detectedDockerHost, detectedDockerHostOK
+}
@@ -0,0 +1,63 @@
+package testcontainers
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerSocketCandidates(t *testing.T) {
+	assert.Equal(t, []string{"/var/run/docker.sock"}, dockerSocketCandidates(""))
+
+	assert.Equal(t, []string{
+		"/var/run/docker.sock",
+		"/Users/foo/.docker/run/docker.sock",
+		"/Users/foo/.docker/desktop/docker.sock",
+		"/Users/foo/.colima/default/docker.sock",
+		"/Users/foo/.colima/docker.sock",
+		"/Users/foo/.rd/docker.sock",
+	}, dockerSocketCandidates("/Users/foo"))
+}
+
+func TestDetectDockerHost(t *testing.T) {
+	dir := t.TempDir()
+
+	listen := func(name string) string {
+		socket := filepath.Join(dir, name)
+		l, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		t.Cleanup(func() { l.Close() })
+		return socket
+	}
+
+	missing := filepath.Join(dir, "missing.sock")
+	regularFile := filepath.Join(dir, "file.sock")
+	require.NoError(t, ioutil.WriteFile(regularFile, nil, 0o600))
+	unhealthy := listen("unhealthy.sock")
+	healthy := listen("healthy.sock")
+	other := listen("other.sock")
+
+	var probed []string
+	isHealthy := func(_ context.Context, host string) bool {
+		probed = append(probed, host)
+		return host != "unix://"+unhealthy
+	}
+
+	host, ok := detectDockerHost(context.Background(), []string{missing, regularFile, unhealthy, healthy, other}, isHealthy)
+	require.True(t, ok)
+	assert.Equal(t, "unix://"+healthy, host)
+	assert.Equal(t, []string{"unix://" + unhealthy, "unix://" + healthy}, probed, "only existing sockets should be probed, until a healthy one is found")
+
+	_, ok = detectDockerHost(context.Background(), []string{missing, unhealthy}, isHealthy)
+	assert.False(t, ok)
+}
+
+func TestDetectDefaultDockerHostDisabled(t *testing.T) {
+	_, ok := detectDefaultDockerHost(TestContainersConfig{DisableSocketDetection: true})
+	assert.False(t, ok)
+}
@@ -0,0 +1,167 @@
+package testcontainers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// sshClientOpts returns the options connecting the Docker client to the daemon behind an ssh:// host,
+// by running `docker system dial-stdio` on the remote host like the Docker CLI does.
+// The client talks plain HTTP to the SSH target, which is also where the ports of containers are exposed.
+func sshClientOpts(host string) ([]client.Opt, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := sshArgs(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return []client.Opt{
+		client.WithHost("http://" + u.Hostname()),
+		client.WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return newCommandConn("ssh", args...)
+		}),
+	}, nil
+}
+
+// sshArgs returns the arguments of the ssh command running `docker system dial-stdio` on the host of the URL
+func sshArgs(u *url.URL) ([]string, error) {
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("invalid ssh docker host %q: scheme must be ssh", u)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid ssh docker host %q: no host specified", u)
+	}
+	if strings.TrimPrefix(u.Path, "/") != "" || u.RawQuery != "" {
+		return nil, fmt.Errorf("invalid ssh docker host %q: path and query are not supported", u)
+	}
+
+	var args []string
+	if user := u.User.Username(); user != "" {
+		args = append(args, "-l", user)
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+
+	return append(args, "--", u.Hostname(), "docker", "system", "dial-stdio"), nil
+}
+
+// commandConn is a net.Conn reading from the stdout and writing to the stdin of a command
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	stderr    lockedBuffer
+	closeOnce sync.Once
+}
+
+func newCommandConn(name string, args ...string) (net.Conn, error) {
+	c := &commandConn{cmd: exec.Command(name, args...)}
+	c.cmd.Stderr = &c.stderr
+
+	var err error
+	if c.stdin, err = c.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if c.stdout, err = c.cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if err = c.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%w: starting %s", err, name)
+	}
+
+	return c, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err != nil && err != io.EOF {
+		if stderr := c.stderr.String(); stderr != "" {
+			err = fmt.Errorf("%w: %s", err, stderr)
+		}
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// CloseWrite closes stdin, signaling the command that no more data is sent
+func (c *commandConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+// Close closes stdin and stops the command
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		_ = c.stdin.Close()
+		_ = c.cmd.Process.Kill()
+		_ = c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr{}
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr{}
+}
+
+// deadlines aren't supported by pipes to commands, the command is stopped when the connection is closed instead
+
+func (c *commandConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (c *commandConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (c *commandConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// lockedBuffer collects the stderr of the command, which is written concurrently to reading it
+type lockedBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return strings.TrimSpace(b.buf.String())
+}
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string {
+	return "command"
+}
+
+func (commandAddr) String() string {
+	return "command"
+}
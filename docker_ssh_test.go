@@ -0,0 +1,98 @@
+package testcontainers
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os/exec"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/env"
+)
+
+func TestSSHArgs(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected []string
+		err      string
+	}{
+		{
+			host:     "ssh://docker.example.com",
+			expected: []string{"--", "docker.example.com", "docker", "system", "dial-stdio"},
+		},
+		{
+			host:     "ssh://me@docker.example.com:2222/",
+			expected: []string{"-l", "me", "-p", "2222", "--", "docker.example.com", "docker", "system", "dial-stdio"},
+		},
+		{
+			host: "ssh://docker.example.com/var/run/docker.sock",
+			err:  "path and query are not supported",
+		},
+		{
+			host: "ssh://",
+			err:  "no host specified",
+		},
+		{
+			host: "tcp://docker.example.com:2375",
+			err:  "scheme must be ssh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			u, err := url.Parse(tt.host)
+			require.NoError(t, err)
+
+			args, err := sshArgs(u)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
+
+func TestSSHClientOpts(t *testing.T) {
+	opts, err := sshClientOpts("ssh://me@docker.example.com")
+	require.NoError(t, err)
+
+	cli, err := client.NewClientWithOpts(opts...)
+	require.NoError(t, err)
+	defer cli.Close()
+
+	assert.Equal(t, "http://docker.example.com", cli.DaemonHost())
+}
+
+func TestNewDockerClientWithSSHHost(t *testing.T) {
+	env.Patch(t, "HOME", t.TempDir())
+	env.Patch(t, "DOCKER_HOST", "ssh://me@docker.example.com")
+
+	cli, host, _, err := NewDockerClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	assert.Equal(t, "ssh://me@docker.example.com", host)
+	assert.Equal(t, "http://docker.example.com", cli.DaemonHost())
+}
+
+func TestCommandConn(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	conn, err := newCommandConn("cat")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*commandConn).CloseWrite())
+
+	out, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(out))
+}
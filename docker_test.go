@@ -10,18 +10,23 @@ import (
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/containerd/containerd/platforms"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-connections/nat"
 	"github.com/docker/go-units"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gotest.tools/v3/env"
@@ -29,6 +34,7 @@ import (
 
 	"github.com/docker/docker/errdefs"
 
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 
 	"github.com/docker/docker/api/types"
@@ -497,6 +503,61 @@ func TestContainerStopWithReaper(t *testing.T) {
 	}
 }
 
+func TestContainerStopWithDefaultStopTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	nginxA, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image: nginxAlpineImage,
+			ExposedPorts: []string{
+				nginxDefaultPort,
+			},
+			DefaultStopTimeout: 10 * time.Second,
+		},
+		Started: true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxA)
+
+	err = nginxA.Stop(ctx, nil)
+	require.NoError(t, err)
+
+	state, err := nginxA.State(ctx)
+	require.NoError(t, err)
+	assert.False(t, state.Running)
+	assert.NotEqual(t, 137, state.ExitCode, "nginx should have been stopped gracefully")
+	assert.False(t, nginxA.IsRunning())
+}
+
+func TestContainerVerifyPortsReachable(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image: nginxAlpineImage,
+			ExposedPorts: []string{
+				nginxDefaultPort,
+			},
+			WaitingFor:           wait.ForLog("start worker process"),
+			VerifyPortsReachable: true,
+		},
+		Started: true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	endpoint, err := nginxC.PortEndpoint(ctx, nginxDefaultPort, "")
+	require.NoError(t, err)
+
+	conn, err := net.DialTimeout("tcp", endpoint, time.Second)
+	require.NoError(t, err)
+	conn.Close()
+}
+
 func TestContainerTerminationWithReaper(t *testing.T) {
 	ctx := context.Background()
 
@@ -628,13 +689,56 @@ func TestContainerTerminationRemovesDockerImage(t *testing.T) {
 		}
 		imageID := resp.Config.Image
 
+		err = container.Terminate(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the image is removed in the background
+		assert.Eventually(t, func() bool {
+			_, _, err := client.ImageInspectWithRaw(ctx, imageID)
+			return err != nil
+		}, 30*time.Second, 100*time.Millisecond, "custom built image should have been removed")
+	})
+
+	t.Run("if built from Dockerfile with image removal disabled", func(t *testing.T) {
+		ctx := context.Background()
+		client, err := client.NewClientWithOpts(client.FromEnv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.NegotiateAPIVersion(ctx)
+		req := ContainerRequest{
+			FromDockerfile: FromDockerfile{
+				Context:      "./testresources",
+				ImageRemoval: ImageRemovalNever,
+			},
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections"),
+		}
+		container, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType:     providerType,
+			ContainerRequest: req,
+			Started:          true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.ContainerInspect(ctx, container.GetContainerID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		imageID := resp.Config.Image
+		t.Cleanup(func() {
+			_, _ = client.ImageRemove(ctx, imageID, types.ImageRemoveOptions{Force: true})
+		})
+
 		err = container.Terminate(ctx)
 		if err != nil {
 			t.Fatal(err)
 		}
 		_, _, err = client.ImageInspectWithRaw(ctx, imageID)
-		if err == nil {
-			t.Fatal("custom built image should have been removed")
+		if err != nil {
+			t.Fatal("custom built image should have been kept")
 		}
 	})
 }
@@ -1549,6 +1653,188 @@ func TestReadTCPropsFile(t *testing.T) {
 					RyukPrivileged: false,
 				},
 			},
+			{
+				`ryuk.container.memory=128m
+				ryuk.container.cpus=0.5`,
+				map[string]string{
+					"TESTCONTAINERS_RYUK_CONTAINER_CPUS": "1",
+				},
+				TestContainersConfig{
+					Host:       "",
+					TLSVerify:  0,
+					CertPath:   "",
+					RyukMemory: "128m",
+					RyukCPUs:   "1",
+				},
+			},
+			{
+				`ryuk.disabled=true`,
+				map[string]string{},
+				TestContainersConfig{
+					Host:         "",
+					TLSVerify:    0,
+					CertPath:     "",
+					RyukDisabled: true,
+				},
+			},
+			{
+				``,
+				map[string]string{
+					"TESTCONTAINERS_RYUK_DISABLED": "true",
+				},
+				TestContainersConfig{
+					Host:         "",
+					TLSVerify:    0,
+					CertPath:     "",
+					RyukDisabled: true,
+				},
+			},
+			{
+				`docker.api.version=1.41`,
+				map[string]string{
+					"TESTCONTAINERS_DOCKER_API_VERSION": "1.40",
+				},
+				TestContainersConfig{
+					Host:       "",
+					TLSVerify:  0,
+					CertPath:   "",
+					APIVersion: "1.40",
+				},
+			},
+			{
+				`docker.host = tcp://127.0.0.1:33293`,
+				map[string]string{
+					"DOCKER_TLS_VERIFY": "1",
+					"DOCKER_CERT_PATH":  "/tmp/certs",
+				},
+				TestContainersConfig{
+					Host:      "tcp://127.0.0.1:33293",
+					TLSVerify: 1,
+					CertPath:  "/tmp/certs",
+				},
+			},
+			{
+				`docker.tls.verify = 1
+	docker.cert.path=/tmp/certs`,
+				map[string]string{
+					"DOCKER_CERT_PATH": "/tmp/docker-certs",
+				},
+				TestContainersConfig{
+					Host:      "",
+					TLSVerify: 1,
+					CertPath:  "/tmp/docker-certs",
+				},
+			},
+			{
+				`docker.host = tcp://127.0.0.1:33293
+	tc.host = tcp://tc.example.com:2376`,
+				map[string]string{},
+				TestContainersConfig{
+					Host:   "tcp://127.0.0.1:33293",
+					TCHost: "tcp://tc.example.com:2376",
+				},
+			},
+			{
+				`tc.host = tcp://tc.example.com:2376`,
+				map[string]string{
+					"TESTCONTAINERS_TC_HOST": "tcp://127.0.0.1:2375",
+				},
+				TestContainersConfig{
+					TCHost: "tcp://127.0.0.1:2375",
+				},
+			},
+			{
+				`hub.image.name.prefix = registry.example.com/mirror/`,
+				map[string]string{},
+				TestContainersConfig{
+					HubImageNamePrefix: "registry.example.com/mirror/",
+				},
+			},
+			{
+				`hub.image.name.prefix = registry.example.com/mirror/`,
+				map[string]string{
+					"TESTCONTAINERS_HUB_IMAGE_NAME_PREFIX": "mirror.example.com/",
+				},
+				TestContainersConfig{
+					HubImageNamePrefix: "mirror.example.com/",
+				},
+			},
+			{
+				`pull.lock.enabled = true`,
+				map[string]string{},
+				TestContainersConfig{
+					PullLockEnabled: true,
+				},
+			},
+			{
+				`pull.lock.enabled = true`,
+				map[string]string{
+					"TESTCONTAINERS_PULL_LOCK_ENABLED": "false",
+				},
+				TestContainersConfig{},
+			},
+			{
+				`session.network.enabled = true`,
+				map[string]string{},
+				TestContainersConfig{
+					SessionNetworkEnabled: true,
+				},
+			},
+			{
+				``,
+				map[string]string{
+					"TESTCONTAINERS_SESSION_NETWORK_ENABLED": "true",
+				},
+				TestContainersConfig{
+					SessionNetworkEnabled: true,
+				},
+			},
+			{
+				`ryuk.container.image = registry.example.com/testcontainers/ryuk:0.3.4`,
+				map[string]string{},
+				TestContainersConfig{
+					RyukImage: "registry.example.com/testcontainers/ryuk:0.3.4",
+				},
+			},
+			{
+				`ryuk.container.image = registry.example.com/testcontainers/ryuk:0.3.4`,
+				map[string]string{
+					"TESTCONTAINERS_RYUK_CONTAINER_IMAGE": "mirror.example.com/ryuk:0.3.4",
+				},
+				TestContainersConfig{
+					RyukImage: "mirror.example.com/ryuk:0.3.4",
+				},
+			},
+			{
+				"ryuk.connection.timeout = 2m\nryuk.reconnection.timeout = 30s",
+				map[string]string{},
+				TestContainersConfig{
+					RyukConnectionTimeout:   2 * time.Minute,
+					RyukReconnectionTimeout: 30 * time.Second,
+				},
+			},
+			{
+				`ryuk.connection.timeout = 2m`,
+				map[string]string{
+					"TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT":   "5s",
+					"TESTCONTAINERS_RYUK_RECONNECTION_TIMEOUT": "forever",
+				},
+				TestContainersConfig{
+					RyukConnectionTimeout: 5 * time.Second,
+				},
+			},
+			{
+				`docker.socket.detection.disabled=false`,
+				map[string]string{
+					"TESTCONTAINERS_DOCKER_SOCKET_DETECTION_DISABLED": "true",
+				},
+				TestContainersConfig{
+					Host:                   "",
+					TLSVerify:              0,
+					CertPath:               "",
+					DisableSocketDetection: true,
+				},
+			},
 		}
 		for i, tt := range tests {
 			t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
@@ -2262,6 +2548,57 @@ func TestContainerWithReaperNetwork(t *testing.T) {
 	assert.NotNil(t, cnt.NetworkSettings.Networks[networks[1]])
 }
 
+func TestContainerDNS(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:      "docker.io/alpine",
+			Cmd:        []string{"sleep", "30"},
+			DNS:        []string{"10.0.0.53"},
+			DNSSearch:  []string{"svc.cluster.local"},
+			DNSOptions: []string{"ndots:1"},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, c)
+
+	_, output, err := c.Exec(ctx, []string{"cat", "/etc/resolv.conf"})
+	require.NoError(t, err)
+	resolvConf, err := ioutil.ReadAll(output)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(resolvConf), "nameserver 10.0.0.53")
+	assert.Contains(t, string(resolvConf), "search svc.cluster.local")
+	assert.Contains(t, string(resolvConf), "options ndots:1")
+}
+
+func TestContainerMacAddressAndDomainname(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:      "docker.io/alpine",
+			Cmd:        []string{"sleep", "30"},
+			Hostname:   "licensed",
+			Domainname: "example.internal",
+			MacAddress: "02:42:ac:11:00:42",
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, c)
+
+	inspect, err := c.(*DockerContainer).inspectContainer(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "licensed", inspect.Config.Hostname)
+	assert.Equal(t, "example.internal", inspect.Config.Domainname)
+	assert.Equal(t, "02:42:ac:11:00:42", inspect.NetworkSettings.MacAddress)
+}
+
 func TestContainerCapAdd(t *testing.T) {
 	if providerType == ProviderPodman {
 		t.Skip("Rootless Podman does not support setting cap-add/cap-drop")
@@ -2395,6 +2732,142 @@ func TestGetGatewayIP(t *testing.T) {
 	}
 }
 
+func TestGatewayIP(t *testing.T) {
+	ctx := context.Background()
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+	defer provider.Close()
+
+	networkName := "test-gateway-" + uuid.NewString()
+	nw, err := GenericNetwork(ctx, GenericNetworkRequest{
+		NetworkRequest: NetworkRequest{
+			Name: networkName,
+			IPAM: &network.IPAM{
+				Config: []network.IPAMConfig{{Subnet: "10.111.0.0/16", Gateway: "10.111.0.254"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, nw.Remove(ctx))
+	}()
+
+	ip, err := provider.GatewayIP(ctx, networkName)
+	require.NoError(t, err)
+	assert.Equal(t, "10.111.0.254", ip)
+
+	ip, err = provider.GatewayIP(ctx, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, "10.111.0.254", ip, "the default network has a gateway of its own")
+}
+
+func TestDockerContainerGatewayIP(t *testing.T) {
+	networks := `{"backend": {"Gateway": "10.111.0.254", "IPAddress": "10.111.0.2"}}`
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id": "0123456789ab", "NetworkSettings": {"Networks": ` + networks + `}}`))
+	})
+
+	c := &DockerContainer{ID: "0123456789ab", provider: provider}
+	ctx := context.Background()
+
+	ip, err := c.GatewayIP(ctx, "backend")
+	require.NoError(t, err)
+	assert.Equal(t, "10.111.0.254", ip)
+
+	ip, err = c.GatewayIP(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.111.0.254", ip, "the gateway of the only network is used")
+
+	_, err = c.GatewayIP(ctx, "frontend")
+	assert.EqualError(t, err, "container 0123456789ab is not attached to network frontend")
+
+	networks = `{"backend": {"Gateway": "10.111.0.254"}, "frontend": {"Gateway": "10.112.0.254"}}`
+	ip, err = c.GatewayIP(ctx, "frontend")
+	require.NoError(t, err)
+	assert.Equal(t, "10.112.0.254", ip)
+}
+
+func TestTLSClientOptRequiresCertificates(t *testing.T) {
+	certDir := fs.NewDir(t, os.TempDir(),
+		fs.WithFile("ca.pem", ""),
+		fs.WithFile("cert.pem", ""),
+	)
+
+	_, err := tlsClientOpt(certDir.Path())
+	require.ErrorIs(t, err, ErrDockerCertificateNotFound)
+	assert.Contains(t, err.Error(), filepath.Join(certDir.Path(), "key.pem"))
+
+	home := fs.NewDir(t, os.TempDir())
+	env.Patch(t, "HOME", home.Path())
+
+	_, err = tlsClientOpt("")
+	require.ErrorIs(t, err, ErrDockerCertificateNotFound)
+	assert.Contains(t, err.Error(), filepath.Join(home.Path(), ".docker", "ca.pem"))
+}
+
+func TestNewDockerClientFailsWithoutCertificates(t *testing.T) {
+	home := fs.NewDir(t, os.TempDir())
+	env.Patch(t, "HOME", home.Path())
+	env.Patch(t, "DOCKER_HOST", "tcp://127.0.0.1:2376")
+	env.Patch(t, "DOCKER_TLS_VERIFY", "1")
+	env.Patch(t, "DOCKER_CERT_PATH", "")
+
+	_, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.ErrorIs(t, err, ErrDockerCertificateNotFound)
+}
+
+func TestTCHostTakesPrecedenceOverDockerHost(t *testing.T) {
+	server, _ := fakeDaemon(t)
+	tcHost := "tcp://" + server.Listener.Addr().String()
+
+	home := fs.NewDir(t, os.TempDir(), fs.WithFile(".testcontainers.properties", "tc.host = "+tcHost))
+	env.Patch(t, "HOME", home.Path())
+	env.Patch(t, "DOCKER_HOST", "tcp://127.0.0.1:1")
+	// restored after the test, an empty TC_HOST would be used as the host
+	env.Patch(t, "TC_HOST", "")
+	os.Unsetenv("TC_HOST")
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+	defer provider.Close()
+
+	assert.Equal(t, tcHost, provider.client.DaemonHost())
+
+	host, err := provider.DaemonHost(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", host, "the ports must be exposed on the host of tc.host")
+}
+
+func TestTCHostWithTLS(t *testing.T) {
+	home := fs.NewDir(t, os.TempDir(), fs.WithFile(".testcontainers.properties", `tc.host = tcp://tc.example.com:2376
+docker.tls.verify = 1`))
+	env.Patch(t, "HOME", home.Path())
+
+	_, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.ErrorIs(t, err, ErrDockerCertificateNotFound)
+}
+
+func TestDaemonHostIsNotAffectedByGatewayIP(t *testing.T) {
+	// in nested containers, e.g. DinD, TC_HOST points to the host the ports are exposed on
+	env.Patch(t, "TC_HOST", "docker-host")
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	host, err := provider.DaemonHost(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "docker-host", host)
+}
+
+func TestGatewayFromIPAM(t *testing.T) {
+	assert.Equal(t, "", gatewayFromIPAM(nil))
+	assert.Equal(t, "172.17.0.1", gatewayFromIPAM([]network.IPAMConfig{
+		{Subnet: "fd00::/64"},
+		{Subnet: "172.17.0.0/16", Gateway: "172.17.0.1"},
+	}))
+}
+
 func TestProviderHasConfig(t *testing.T) {
 	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
 	if err != nil {
@@ -2532,3 +3005,236 @@ func TestDockerProviderFindContainerByName(t *testing.T) {
 	require.NotNil(t, c)
 	assert.Contains(t, c.Names, c1Name)
 }
+
+func TestExtractFiles(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	paths, err := nginxC.ExtractFiles(ctx, t, map[string]string{
+		"nginx.conf": "/etc/nginx/nginx.conf",
+		"html/index": "/usr/share/nginx/html/index.html",
+	})
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+
+	for name, hostPath := range paths {
+		content, err := ioutil.ReadFile(hostPath)
+		require.NoError(t, err, name)
+		assert.NotEmpty(t, content, name)
+	}
+}
+
+func TestContainerFailOnUnhealthy(t *testing.T) {
+	ctx := context.Background()
+
+	start := time.Now()
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			FromDockerfile: FromDockerfile{
+				Context:    "./testresources",
+				Dockerfile: "unhealthy.Dockerfile",
+			},
+			WaitingFor:      wait.ForLog("this is never logged").WithStartupTimeout(2 * time.Minute),
+			FailOnUnhealthy: true,
+		},
+		Started: true,
+	})
+	terminateContainerOnEnd(t, ctx, c)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service not ready")
+	assert.Less(t, time.Since(start), time.Minute, "starting should fail before the wait strategy times out")
+}
+
+func TestPullGroupDeduplicatesConcurrentPulls(t *testing.T) {
+	const callers = 10
+	var key string
+	var pulls int32
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/images/create") && atomic.AddInt32(&pulls, 1) == 1 {
+			// finish the first pull once all other callers wait for it
+			assert.Eventually(t, func() bool { return imagePulls.waiting(key) == callers-1 }, 10*time.Second, 10*time.Millisecond)
+		}
+		_, _ = w.Write([]byte("{}"))
+	})
+	key = provider.host + "/nginx:alpine@"
+
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			errs <- provider.attemptToPullImage(context.Background(), "nginx:alpine", types.ImagePullOptions{})
+		}()
+	}
+
+	for i := 0; i < callers; i++ {
+		require.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&pulls))
+
+	// once finished, the image is pulled again
+	require.NoError(t, provider.attemptToPullImage(context.Background(), "nginx:alpine", types.ImagePullOptions{}))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&pulls))
+}
+
+func TestPullGroupRetriesCanceledPulls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var key string
+	var pulls int32
+	started := make(chan struct{})
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/images/create") && atomic.AddInt32(&pulls, 1) == 1 {
+			close(started)
+			// cancel the first pull once the second caller waits for it
+			assert.Eventually(t, func() bool { return imagePulls.waiting(key) == 1 }, 10*time.Second, 10*time.Millisecond)
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	})
+	key = provider.host + "/nginx:alpine@"
+
+	canceled := make(chan error)
+	go func() {
+		canceled <- provider.attemptToPullImage(ctx, "nginx:alpine", types.ImagePullOptions{})
+	}()
+	<-started
+
+	retried := make(chan error)
+	go func() {
+		retried <- provider.attemptToPullImage(context.Background(), "nginx:alpine", types.ImagePullOptions{})
+	}()
+
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+	require.NoError(t, <-retried, "the waiting caller must not fail because the context of another caller was canceled")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&pulls))
+}
+
+func TestImageMatchesPlatform(t *testing.T) {
+	tests := []struct {
+		platform string
+		image    types.ImageInspect
+		expected bool
+	}{
+		{"linux/amd64", types.ImageInspect{Os: "linux", Architecture: "amd64"}, true},
+		{"linux/arm64", types.ImageInspect{Os: "linux", Architecture: "amd64"}, false},
+		{"linux/arm64", types.ImageInspect{Os: "linux", Architecture: "arm64", Variant: "v8"}, true},
+		{"linux/arm64", types.ImageInspect{Os: "linux", Architecture: "arm", Variant: "v7"}, false},
+		{"linux/arm/v7", types.ImageInspect{Os: "linux", Architecture: "arm", Variant: "v7"}, true},
+		{"linux/arm/v7", types.ImageInspect{Os: "linux", Architecture: "arm", Variant: "v6"}, false},
+		{"windows/amd64", types.ImageInspect{Os: "linux", Architecture: "amd64"}, false},
+	}
+
+	for _, test := range tests {
+		platform, err := platforms.Parse(test.platform)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, imageMatchesPlatform(test.image, platform), "%s: %+v", test.platform, test.image)
+	}
+}
+
+func TestMappedPortProtocols(t *testing.T) {
+	ports := nat.PortMap{
+		"53/tcp":   {{HostIP: "0.0.0.0", HostPort: "49153"}},
+		"53/udp":   {{HostIP: "0.0.0.0", HostPort: "49154"}},
+		"514/udp":  {{HostIP: "0.0.0.0", HostPort: "49155"}},
+		"8125/udp": {},
+	}
+
+	for port, expected := range map[nat.Port]nat.Port{"53": "49153/tcp", "53/tcp": "49153/tcp", "53/udp": "49154/udp"} {
+		mapped, err := mappedPort(ports, port)
+		require.NoError(t, err, port)
+		assert.Equal(t, expected, mapped, port)
+	}
+
+	_, err := mappedPort(ports, "514")
+	assert.EqualError(t, err, "port 514/tcp not found, but 514/udp is exposed")
+	_, err = mappedPort(ports, "8125/udp")
+	assert.EqualError(t, err, "port not found", "ports without host bindings aren't mapped")
+}
+
+func TestUDPEndpoint(t *testing.T) {
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id": "0123456789ab", "HostConfig": {"NetworkMode": "default"}, "NetworkSettings": {"Ports": {
+			"53/tcp": [{"HostIp": "0.0.0.0", "HostPort": "49153"}],
+			"53/udp": [{"HostIp": "0.0.0.0", "HostPort": "49154"}],
+			"8125/udp": [{"HostIp": "0.0.0.0", "HostPort": "49155"}]}}}`))
+	})
+
+	c := &DockerContainer{ID: "0123456789ab", provider: provider}
+	ctx := context.Background()
+
+	endpoint, err := c.UDPEndpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:49154", endpoint, "the UDP port with the lowest number is used")
+
+	endpoint, err = c.UDPPortEndpoint(ctx, "8125")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:49155", endpoint)
+
+	endpoint, err = c.PortEndpoint(ctx, "53", "")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:49153", endpoint, "ports without protocol are TCP ports")
+}
+
+func TestMappedPortWaitsForPublishedPorts(t *testing.T) {
+	var inspections int32
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		hostPort := ""
+		if atomic.AddInt32(&inspections, 1) > 2 {
+			hostPort = "49153"
+		}
+		_, _ = w.Write([]byte(`{"Id": "0123456789ab", "State": {"Running": true},
+			"HostConfig": {"NetworkMode": "default", "PortBindings": {"80/tcp": [{"HostPort": ""}]}},
+			"NetworkSettings": {"Ports": {"80/tcp": [{"HostIp": "0.0.0.0", "HostPort": "` + hostPort + `"}]}}}`))
+	})
+
+	c := &DockerContainer{ID: "0123456789ab", provider: provider}
+
+	port, err := c.MappedPort(context.Background(), "80")
+	require.NoError(t, err)
+	assert.Equal(t, nat.Port("49153/tcp"), port)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&inspections))
+}
+
+func TestUnpublishedPort(t *testing.T) {
+	inspect := func(running bool, mode container.NetworkMode, hostPort string) *types.ContainerJSON {
+		return &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				State: &types.ContainerState{Running: running},
+				HostConfig: &container.HostConfig{
+					NetworkMode:  mode,
+					PortBindings: nat.PortMap{"80/tcp": {{}}},
+				},
+			},
+			NetworkSettings: &types.NetworkSettings{NetworkSettingsBase: types.NetworkSettingsBase{
+				Ports: nat.PortMap{"80/tcp": {{HostIP: "0.0.0.0", HostPort: hostPort}}},
+			}},
+		}
+	}
+
+	port, ok := unpublishedPort(inspect(true, "default", ""))
+	assert.True(t, ok)
+	assert.Equal(t, nat.Port("80/tcp"), port)
+
+	_, ok = unpublishedPort(inspect(true, "default", "49153"))
+	assert.False(t, ok)
+	_, ok = unpublishedPort(inspect(false, "default", ""))
+	assert.False(t, ok, "the ports of stopped containers aren't published")
+	_, ok = unpublishedPort(inspect(true, "host", ""))
+	assert.False(t, ok, "the ports of containers in the host network aren't published")
+}
@@ -0,0 +1,414 @@
+package fake
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Implement interface
+var _ testcontainers.Container = (*Container)(nil)
+
+// Container is an in-memory testcontainers.Container. Its exported fields script the responses of its methods,
+// they must be set before the container is used.
+type Container struct {
+	ID      string
+	Request testcontainers.ContainerRequest // request the container was created with
+
+	HostAddress string      // returned by Host
+	PortMap     nat.PortMap // exposed ports and the host ports they are mapped to
+	IPs         []string    // returned by ContainerIP and ContainerIPs
+
+	Log   []byte       // returned by Logs and passed to the log consumers line by line
+	Files fstest.MapFS // file system of the container, used by the Copy methods and FS
+
+	// handles Exec, by default all commands succeed without output
+	ExecFunc func(ctx context.Context, cmd []string) (int, io.Reader, error)
+
+	StartErr     error // returned by Start
+	StopErr      error // returned by Stop
+	TerminateErr error // returned by Terminate
+	ExitCode     int   // reported by State once the container is stopped
+
+	mtx        sync.Mutex
+	running    bool
+	stopped    bool
+	terminated bool
+	consumers  []testcontainers.LogConsumer
+}
+
+// notFound is returned for terminated containers, like the Docker API does for removed containers
+func (c *Container) notFound() error {
+	return errdefs.NotFound(fmt.Errorf("No such container: %s", c.ID))
+}
+
+// check returns an error if the container is terminated
+func (c *Container) check() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.terminated {
+		return c.notFound()
+	}
+	return nil
+}
+
+func (c *Container) GetContainerID() string {
+	return c.ID
+}
+
+func (c *Container) Endpoint(ctx context.Context, proto string) (string, error) {
+	for p := range c.PortMap {
+		return c.PortEndpoint(ctx, p, proto)
+	}
+	return "", errors.New("port not found")
+}
+
+func (c *Container) PortEndpoint(ctx context.Context, port nat.Port, proto string) (string, error) {
+	host, err := c.Host(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	outerPort, err := c.MappedPort(ctx, port)
+	if err != nil {
+		return "", err
+	}
+
+	protoFull := ""
+	if proto != "" {
+		protoFull = fmt.Sprintf("%s://", proto)
+	}
+
+	return fmt.Sprintf("%s%s:%s", protoFull, host, outerPort.Port()), nil
+}
+
+func (c *Container) Host(context.Context) (string, error) {
+	if err := c.check(); err != nil {
+		return "", err
+	}
+	return c.HostAddress, nil
+}
+
+func (c *Container) MappedPort(_ context.Context, port nat.Port) (nat.Port, error) {
+	if err := c.check(); err != nil {
+		return "", err
+	}
+
+	for k, p := range c.PortMap {
+		if k.Port() != port.Port() {
+			continue
+		}
+		if port.Proto() != "" && k.Proto() != port.Proto() {
+			continue
+		}
+		if len(p) == 0 {
+			continue
+		}
+		return nat.NewPort(k.Proto(), p[0].HostPort)
+	}
+
+	return "", errors.New("port not found")
+}
+
+func (c *Container) Ports(context.Context) (nat.PortMap, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return c.PortMap, nil
+}
+
+func (c *Container) SessionID() string {
+	return testcontainers.SessionID()
+}
+
+func (c *Container) IsRunning() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.running
+}
+
+// Start starts the container or returns StartErr
+func (c *Container) Start(context.Context) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	if c.StartErr != nil {
+		return c.StartErr
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.running = true
+	c.stopped = false
+	return nil
+}
+
+// Stop stops the container or returns StopErr
+func (c *Container) Stop(context.Context, *time.Duration) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	if c.StopErr != nil {
+		return c.StopErr
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.running = false
+	c.stopped = true
+	return nil
+}
+
+// Terminate removes the container or returns TerminateErr, afterwards most methods report it not found
+func (c *Container) Terminate(context.Context) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	if c.TerminateErr != nil {
+		return c.TerminateErr
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.running = false
+	c.terminated = true
+	return nil
+}
+
+func (c *Container) Logs(context.Context) (io.ReadCloser, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(c.Log)), nil
+}
+
+func (c *Container) FollowOutput(consumer testcontainers.LogConsumer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.consumers = append(c.consumers, consumer)
+}
+
+func (c *Container) UnfollowOutput(consumer testcontainers.LogConsumer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for i, existing := range c.consumers {
+		if existing == consumer {
+			c.consumers = append(c.consumers[:i], c.consumers[i+1:]...)
+			return
+		}
+	}
+}
+
+// StartLogProducer passes Log to the log consumers line by line
+func (c *Container) StartLogProducer(context.Context) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	consumers := append([]testcontainers.LogConsumer(nil), c.consumers...)
+	c.mtx.Unlock()
+
+	scanner := bufio.NewScanner(bytes.NewReader(c.Log))
+	for scanner.Scan() {
+		line := append(append([]byte(nil), scanner.Bytes()...), '\n')
+		for _, consumer := range consumers {
+			consumer.Accept(testcontainers.Log{LogType: testcontainers.StdoutLog, Content: line})
+		}
+	}
+	return scanner.Err()
+}
+
+func (c *Container) StopLogProducer() error {
+	return nil
+}
+
+func (c *Container) Name(context.Context) (string, error) {
+	if err := c.check(); err != nil {
+		return "", err
+	}
+	return "/" + c.Request.Name, nil
+}
+
+func (c *Container) State(context.Context) (*types.ContainerState, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	state := &types.ContainerState{Status: "created"}
+	switch {
+	case c.running:
+		state.Status = "running"
+		state.Running = true
+	case c.stopped:
+		state.Status = "exited"
+		state.ExitCode = c.ExitCode
+	}
+	return state, nil
+}
+
+func (c *Container) Networks(context.Context) ([]string, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return append([]string{}, c.Request.Networks...), nil
+}
+
+func (c *Container) NetworkAliases(context.Context) (map[string][]string, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+
+	aliases := map[string][]string{}
+	for _, n := range c.Request.Networks {
+		aliases[n] = c.Request.NetworkAliases[n]
+	}
+	return aliases, nil
+}
+
+// Exec executes the command using ExecFunc
+func (c *Container) Exec(ctx context.Context, cmd []string) (int, io.Reader, error) {
+	if err := c.check(); err != nil {
+		return 0, nil, err
+	}
+	if c.ExecFunc == nil {
+		return 0, bytes.NewReader(nil), nil
+	}
+	return c.ExecFunc(ctx, cmd)
+}
+
+func (c *Container) Schedule(ctx context.Context, interval time.Duration, cmd []string) (*testcontainers.ScheduledCommand, error) {
+	return testcontainers.ScheduleExec(ctx, c, interval, cmd)
+}
+
+func (c *Container) URL(ctx context.Context, scheme string, port nat.Port, path string) (*url.URL, error) {
+	return testcontainers.PortURL(ctx, c, scheme, port, path)
+}
+
+func (c *Container) HTTPEndpoint(ctx context.Context, port nat.Port, path string) (*url.URL, error) {
+	return c.URL(ctx, "http", port, path)
+}
+
+func (c *Container) ExtractFiles(ctx context.Context, tb testing.TB, spec map[string]string) (map[string]string, error) {
+	tb.Helper()
+	return testcontainers.ExtractFilesFrom(ctx, tb, c, spec)
+}
+
+// FS returns the part of Files below root
+func (c *Container) FS(_ context.Context, root string) fs.FS {
+	root = strings.Trim(path.Clean("/"+root), "/")
+	if root == "" {
+		return c.Files
+	}
+
+	sub, err := fs.Sub(c.Files, root)
+	if err != nil {
+		return fstest.MapFS{}
+	}
+	return sub
+}
+
+func (c *Container) ContainerIP(context.Context) (string, error) {
+	if err := c.check(); err != nil {
+		return "", err
+	}
+	if len(c.IPs) == 0 {
+		return "", nil
+	}
+	return c.IPs[0], nil
+}
+
+func (c *Container) ContainerIPs(context.Context) ([]string, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return append([]string{}, c.IPs...), nil
+}
+
+// CopyToContainer stores the content in Files
+func (c *Container) CopyToContainer(_ context.Context, fileContent []byte, containerFilePath string, fileMode int64, _ ...testcontainers.TarHeaderTransform) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.Files == nil {
+		c.Files = fstest.MapFS{}
+	}
+	c.Files[filesKey(containerFilePath)] = &fstest.MapFile{
+		Data:    append([]byte(nil), fileContent...),
+		Mode:    fs.FileMode(fileMode),
+		ModTime: time.Now(),
+	}
+	return nil
+}
+
+// CopyDirToContainer stores the files of the directory in Files
+func (c *Container) CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, fileMode int64, transforms ...testcontainers.TarHeaderTransform) error {
+	base := filepath.Dir(hostDirPath)
+	return filepath.Walk(hostDirPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		return c.CopyFileToContainer(ctx, p, path.Join(containerParentPath, filepath.ToSlash(rel)), fileMode, transforms...)
+	})
+}
+
+// CopyFileToContainer stores the content of the file in Files
+func (c *Container) CopyFileToContainer(ctx context.Context, hostFilePath string, containerFilePath string, fileMode int64, transforms ...testcontainers.TarHeaderTransform) error {
+	content, err := ioutil.ReadFile(hostFilePath)
+	if err != nil {
+		return err
+	}
+	return c.CopyToContainer(ctx, content, containerFilePath, fileMode, transforms...)
+}
+
+// CopyFileFromContainer returns the content of the file in Files
+func (c *Container) CopyFileFromContainer(_ context.Context, filePath string) (io.ReadCloser, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	f, ok := c.Files[filesKey(filePath)]
+	if !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("Could not find the file %s in container %s", filePath, c.ID))
+	}
+	return ioutil.NopCloser(bytes.NewReader(f.Data)), nil
+}
+
+// filesKey converts an absolute path in the container into a key of Files
+func filesKey(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
@@ -0,0 +1,262 @@
+// Package fake provides an in-memory provider implementing testcontainers.GenericProvider, so that libraries built
+// on top of testcontainers-go can unit test their orchestration logic without a Docker daemon.
+package fake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing/fstest"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Implement interface
+var _ testcontainers.GenericProvider = (*Provider)(nil)
+
+// firstHostPort is the first host port exposed ports are mapped to, like the ephemeral ports Docker picks
+const firstHostPort = 49153
+
+// Provider is an in-memory testcontainers.GenericProvider. Its exported fields script the responses of its methods,
+// they must be set before the provider is used. The provider can be passed to code expecting a provider directly,
+// or be made available to GenericContainer with testcontainers.RegisterProvider.
+type Provider struct {
+	// scripts the containers created by the provider, e.g. to make some of them fail to start.
+	// The fields left empty are filled in by the provider, by default an empty Container is used
+	NewContainer func(req testcontainers.ContainerRequest) (*Container, error)
+
+	CreateErr        error                               // returned when creating containers
+	CreateNetworkErr error                               // returned when creating networks
+	HealthErr        error                               // returned by Health
+	TCConfig         testcontainers.TestContainersConfig // returned by Config
+
+	mtx        sync.Mutex
+	containers []*Container
+	networks   []*Network
+	nextPort   int
+}
+
+// NewProvider returns a provider which creates containers successfully
+func NewProvider() *Provider {
+	return &Provider{}
+}
+
+// CreateContainer creates a container without starting it
+func (p *Provider) CreateContainer(ctx context.Context, req testcontainers.ContainerRequest) (testcontainers.Container, error) {
+	if p.CreateErr != nil {
+		return nil, p.CreateErr
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &Container{}
+	if p.NewContainer != nil {
+		var err error
+		if c, err = p.NewContainer(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.complete(c, req); err != nil {
+		return nil, err
+	}
+
+	for _, f := range req.Files {
+		err := c.CopyFileToContainer(ctx, f.HostFilePath, f.ContainerFilePath, f.FileMode)
+		if err != nil {
+			return nil, fmt.Errorf("can't copy %s to container: %w", f.HostFilePath, err)
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.containers = append(p.containers, c)
+
+	return c, nil
+}
+
+// complete fills in the fields of the container left empty
+func (p *Provider) complete(c *Container, req testcontainers.ContainerRequest) error {
+	c.Request = req
+
+	if c.ID == "" {
+		id := make([]byte, 32)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		c.ID = hex.EncodeToString(id)
+	}
+
+	if c.HostAddress == "" {
+		c.HostAddress = "localhost"
+	}
+
+	if c.Files == nil {
+		c.Files = fstest.MapFS{}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if c.PortMap == nil {
+		exposedPorts, _, err := nat.ParsePortSpecs(req.ExposedPorts)
+		if err != nil {
+			return err
+		}
+
+		c.PortMap = nat.PortMap{}
+		for port := range exposedPorts {
+			c.PortMap[port] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: strconv.Itoa(firstHostPort + p.nextPort)}}
+			p.nextPort++
+		}
+	}
+
+	if c.IPs == nil {
+		c.IPs = []string{fmt.Sprintf("172.17.0.%d", len(p.containers)+2)}
+	}
+
+	return nil
+}
+
+// ReuseOrCreateContainer returns the container with the name of the request, if it was created and not terminated yet,
+// or creates a new container otherwise
+func (p *Provider) ReuseOrCreateContainer(ctx context.Context, req testcontainers.ContainerRequest) (testcontainers.Container, error) {
+	p.mtx.Lock()
+	for _, c := range p.containers {
+		if req.Name != "" && c.Request.Name == req.Name && c.check() == nil {
+			p.mtx.Unlock()
+			return c, nil
+		}
+	}
+	p.mtx.Unlock()
+
+	return p.CreateContainer(ctx, req)
+}
+
+// RunContainer creates a container and starts it
+func (p *Provider) RunContainer(ctx context.Context, req testcontainers.ContainerRequest) (testcontainers.Container, error) {
+	c, err := p.CreateContainer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Start(ctx); err != nil {
+		return c, fmt.Errorf("%w: could not start container", err)
+	}
+
+	return c, nil
+}
+
+// Health returns HealthErr
+func (p *Provider) Health(context.Context) error {
+	return p.HealthErr
+}
+
+// Config returns TCConfig
+func (p *Provider) Config() testcontainers.TestContainersConfig {
+	return p.TCConfig
+}
+
+// Containers returns all containers created by the provider, in the order of creation
+func (p *Provider) Containers() []*Container {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return append([]*Container(nil), p.containers...)
+}
+
+// CreateNetwork creates a network, which fails if the request checks for duplicates and the name is taken
+func (p *Provider) CreateNetwork(_ context.Context, req testcontainers.NetworkRequest) (testcontainers.Network, error) {
+	if p.CreateNetworkErr != nil {
+		return nil, p.CreateNetworkErr
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if req.CheckDuplicate && p.network(req.Name) != nil {
+		return nil, errdefs.Conflict(fmt.Errorf("network with name %s already exists", req.Name))
+	}
+
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	n := &Network{ID: hex.EncodeToString(id), Request: req, provider: p}
+	p.networks = append(p.networks, n)
+
+	return n, nil
+}
+
+// GetNetwork returns the network with the name of the request
+func (p *Provider) GetNetwork(_ context.Context, req testcontainers.NetworkRequest) (types.NetworkResource, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	n := p.network(req.Name)
+	if n == nil {
+		return types.NetworkResource{}, errdefs.NotFound(fmt.Errorf("network %s not found", req.Name))
+	}
+
+	return types.NetworkResource{
+		Name:       n.Request.Name,
+		ID:         n.ID,
+		Driver:     n.Request.Driver,
+		EnableIPv6: n.Request.EnableIPv6,
+		Internal:   n.Request.Internal,
+		Attachable: n.Request.Attachable,
+		Labels:     n.Request.Labels,
+	}, nil
+}
+
+// Networks returns all networks created by the provider which are not removed, in the order of creation
+func (p *Provider) Networks() []*Network {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return append([]*Network(nil), p.networks...)
+}
+
+// network returns the network with the name, the lock must be held
+func (p *Provider) network(name string) *Network {
+	for _, n := range p.networks {
+		if n.Request.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// Network is an in-memory testcontainers.Network
+type Network struct {
+	ID        string
+	Request   testcontainers.NetworkRequest // request the network was created with
+	RemoveErr error                         // returned by Remove
+
+	provider *Provider
+}
+
+// Remove removes the network from its provider or returns RemoveErr
+func (n *Network) Remove(context.Context) error {
+	if n.RemoveErr != nil {
+		return n.RemoveErr
+	}
+
+	n.provider.mtx.Lock()
+	defer n.provider.mtx.Unlock()
+
+	for i, existing := range n.provider.networks {
+		if existing == n {
+			n.provider.networks = append(n.provider.networks[:i], n.provider.networks[i+1:]...)
+			return nil
+		}
+	}
+	return errdefs.NotFound(fmt.Errorf("network %s not found", n.Request.Name))
+}
@@ -0,0 +1,205 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestProviderRunContainer(t *testing.T) {
+	ctx := context.Background()
+	provider := NewProvider()
+
+	c, err := provider.RunContainer(ctx, testcontainers.ContainerRequest{
+		Image:        "docker.io/nginx:alpine",
+		Name:         "nginx",
+		ExposedPorts: []string{"80/tcp"},
+	})
+	require.NoError(t, err)
+	assert.True(t, c.IsRunning())
+	assert.Len(t, c.GetContainerID(), 64)
+
+	endpoint, err := c.PortEndpoint(ctx, "80/tcp", "http")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:49153", endpoint)
+
+	u, err := c.HTTPEndpoint(ctx, "80/tcp", "/health")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:49153/health", u.String())
+
+	name, err := c.Name(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "/nginx", name)
+
+	reused, err := provider.ReuseOrCreateContainer(ctx, testcontainers.ContainerRequest{Image: "docker.io/nginx:alpine", Name: "nginx"})
+	require.NoError(t, err)
+	assert.Same(t, c, reused)
+
+	require.NoError(t, c.Stop(ctx, nil))
+	state, err := c.State(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "exited", state.Status)
+
+	require.NoError(t, c.Terminate(ctx))
+	_, err = c.State(ctx)
+	assert.True(t, errdefs.IsNotFound(err))
+
+	assert.Len(t, provider.Containers(), 1)
+}
+
+func TestProviderScriptedContainers(t *testing.T) {
+	ctx := context.Background()
+	errStart := errors.New("start failed")
+
+	created := 0
+	provider := &Provider{
+		NewContainer: func(req testcontainers.ContainerRequest) (*Container, error) {
+			created++
+			if created == 1 {
+				return &Container{StartErr: errStart}, nil
+			}
+			return &Container{
+				Log: []byte("ready\n"),
+				ExecFunc: func(ctx context.Context, cmd []string) (int, io.Reader, error) {
+					return 1, bytes.NewReader([]byte("no such table")), nil
+				},
+			}, nil
+		},
+	}
+
+	req := testcontainers.ContainerRequest{Image: "docker.io/postgres:14"}
+
+	_, err := provider.RunContainer(ctx, req)
+	assert.ErrorIs(t, err, errStart)
+
+	c, err := provider.RunContainer(ctx, req)
+	require.NoError(t, err)
+
+	exitCode, r, err := c.Exec(ctx, []string{"psql", "-c", "SELECT 1 FROM t"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, exitCode)
+	out, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "no such table", string(out))
+
+	err = wait.ForLog("ready").WaitUntilReady(ctx, c)
+	assert.NoError(t, err)
+
+	provider.CreateErr = errors.New("daemon unavailable")
+	_, err = provider.CreateContainer(ctx, req)
+	assert.ErrorIs(t, err, provider.CreateErr)
+}
+
+func TestContainerFiles(t *testing.T) {
+	ctx := context.Background()
+	provider := NewProvider()
+
+	c, err := provider.CreateContainer(ctx, testcontainers.ContainerRequest{Image: "docker.io/alpine"})
+	require.NoError(t, err)
+
+	require.NoError(t, c.CopyToContainer(ctx, []byte("hello"), "/data/greeting.txt", 0o644))
+
+	r, err := c.CopyFileFromContainer(ctx, "/data/greeting.txt")
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	_, err = c.CopyFileFromContainer(ctx, "/data/missing.txt")
+	assert.True(t, errdefs.IsNotFound(err))
+
+	content, err = fs.ReadFile(c.FS(ctx, "/data"), "greeting.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+	assert.NoError(t, fstest.TestFS(c.FS(ctx, "/"), "data/greeting.txt"))
+}
+
+func TestContainerExtractFiles(t *testing.T) {
+	ctx := context.Background()
+	provider := NewProvider()
+
+	c, err := provider.CreateContainer(ctx, testcontainers.ContainerRequest{Image: "docker.io/alpine"})
+	require.NoError(t, err)
+
+	require.NoError(t, c.CopyToContainer(ctx, []byte("hello"), "/data/greeting.txt", 0o644))
+
+	paths, err := c.ExtractFiles(ctx, t, map[string]string{"out/greeting.txt": "/data/greeting.txt"})
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(paths["out/greeting.txt"])
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	_, err = c.ExtractFiles(ctx, t, map[string]string{"missing.txt": "/data/missing.txt"})
+	assert.ErrorContains(t, err, "failed to extract /data/missing.txt")
+}
+
+func TestContainerLogConsumers(t *testing.T) {
+	ctx := context.Background()
+	c := &Container{Log: []byte("first\nsecond\n")}
+
+	var lines []string
+	consumer := logConsumerFunc(func(l testcontainers.Log) {
+		lines = append(lines, string(l.Content))
+	})
+	c.FollowOutput(consumer)
+
+	require.NoError(t, c.StartLogProducer(ctx))
+	assert.Equal(t, []string{"first\n", "second\n"}, lines)
+}
+
+type logConsumerFunc func(l testcontainers.Log)
+
+func (f logConsumerFunc) Accept(l testcontainers.Log) {
+	f(l)
+}
+
+func TestProviderNetworks(t *testing.T) {
+	ctx := context.Background()
+	provider := NewProvider()
+
+	req := testcontainers.NetworkRequest{Name: "backend", Driver: "bridge", CheckDuplicate: true}
+	n, err := provider.CreateNetwork(ctx, req)
+	require.NoError(t, err)
+
+	_, err = provider.CreateNetwork(ctx, req)
+	assert.True(t, errdefs.IsConflict(err))
+
+	resource, err := provider.GetNetwork(ctx, testcontainers.NetworkRequest{Name: "backend"})
+	require.NoError(t, err)
+	assert.Equal(t, "bridge", resource.Driver)
+
+	require.NoError(t, n.Remove(ctx))
+	_, err = provider.GetNetwork(ctx, testcontainers.NetworkRequest{Name: "backend"})
+	assert.True(t, errdefs.IsNotFound(err))
+	assert.Empty(t, provider.Networks())
+}
+
+func TestProviderWithGenericContainer(t *testing.T) {
+	ctx := context.Background()
+	provider := NewProvider()
+
+	providerType := testcontainers.RegisterProvider("fake", func(opts ...testcontainers.GenericProviderOption) (testcontainers.GenericProvider, error) {
+		return provider, nil
+	}, nil)
+
+	c, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{Image: "docker.io/redis:7"},
+		ProviderType:     providerType,
+		Started:          true,
+	})
+	require.NoError(t, err)
+	assert.True(t, c.IsRunning())
+	assert.Equal(t, []*Container{c.(*Container)}, provider.Containers())
+}
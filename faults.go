@@ -0,0 +1,88 @@
+package testcontainers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fault defines how a single operation of the DockerProvider fails
+type Fault struct {
+	Err   error         // returned instead of performing the operation, nil to only delay it
+	Delay time.Duration // delay before the operation is performed or fails
+	Times int           // number of times the fault is injected, afterwards the operation succeeds. 0 means always
+}
+
+// FaultConfig defines the faults injected into the operations of a DockerProvider
+type FaultConfig struct {
+	Create Fault // injected into creating containers
+	Start  Fault // injected into starting containers
+	Pull   Fault // injected into pulling images, i.e. only if an image is actually pulled
+}
+
+// WithFaults makes the operations of the DockerProvider fail or delay them deterministically, so that
+// code built on top of testcontainers-go can test its own retry and cleanup logic
+func WithFaults(cfg FaultConfig) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.faults = &faultInjector{config: cfg}
+	})
+}
+
+type faultOperation int
+
+// operations faults are injected into
+const (
+	faultCreate faultOperation = iota
+	faultStart
+	faultPull
+)
+
+// faultInjector injects the configured faults, counting how often each was injected
+type faultInjector struct {
+	config FaultConfig
+	mtx    sync.Mutex
+	counts map[faultOperation]int
+}
+
+// inject delays the operation and returns the configured error as long as the fault applies,
+// a nil injector doesn't inject any fault
+func (f *faultInjector) inject(ctx context.Context, op faultOperation) error {
+	if f == nil {
+		return nil
+	}
+
+	fault := f.fault(op)
+
+	f.mtx.Lock()
+	if f.counts == nil {
+		f.counts = map[faultOperation]int{}
+	}
+	f.counts[op]++
+	applies := fault.Times == 0 || f.counts[op] <= fault.Times
+	f.mtx.Unlock()
+
+	if !applies {
+		return nil
+	}
+
+	if fault.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(fault.Delay):
+		}
+	}
+
+	return fault.Err
+}
+
+func (f *faultInjector) fault(op faultOperation) Fault {
+	switch op {
+	case faultCreate:
+		return f.config.Create
+	case faultStart:
+		return f.config.Start
+	default:
+		return f.config.Pull
+	}
+}
@@ -0,0 +1,53 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjector(t *testing.T) {
+	errCreate := errors.New("create failed")
+	f := &faultInjector{config: FaultConfig{
+		Create: Fault{Err: errCreate, Times: 2},
+		Start:  Fault{Delay: 50 * time.Millisecond},
+	}}
+	ctx := context.Background()
+
+	assert.ErrorIs(t, f.inject(ctx, faultCreate), errCreate)
+	assert.ErrorIs(t, f.inject(ctx, faultCreate), errCreate)
+	assert.NoError(t, f.inject(ctx, faultCreate), "the fault is only injected twice")
+
+	start := time.Now()
+	assert.NoError(t, f.inject(ctx, faultStart))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	assert.NoError(t, f.inject(ctx, faultPull), "no fault is configured for pulls")
+
+	var nilInjector *faultInjector
+	assert.NoError(t, nilInjector.inject(ctx, faultCreate))
+}
+
+func TestFaultInjectorDelayRespectsContext(t *testing.T) {
+	f := &faultInjector{config: FaultConfig{Pull: Fault{Delay: time.Minute}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, f.inject(ctx, faultPull), context.DeadlineExceeded)
+}
+
+func TestWithFaults(t *testing.T) {
+	errCreate := errors.New("create failed")
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)), WithFaults(FaultConfig{
+		Create: Fault{Err: errCreate},
+	}))
+	require.NoError(t, err)
+
+	_, err = provider.CreateContainer(context.Background(), ContainerRequest{Image: nginxAlpineImage})
+	assert.ErrorIs(t, err, errCreate)
+}
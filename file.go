@@ -7,9 +7,64 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
+// TarHeaderTransform rewrites the tar header of a file copied into a container, e.g. to change
+// its ownership so it's readable by the user a non-root image runs as
+type TarHeaderTransform func(header *tar.Header)
+
+// WithFileOwner sets the owner of the copied files to the given uid and gid
+func WithFileOwner(uid, gid int) TarHeaderTransform {
+	return func(header *tar.Header) {
+		header.Uid = uid
+		header.Gid = gid
+	}
+}
+
+// isWindowsContainerPath reports whether a path in a container is a Windows path,
+// i.e. it starts with a drive letter or contains backslashes
+func isWindowsContainerPath(p string) bool {
+	if strings.Contains(p, `\`) {
+		return true
+	}
+	return len(p) >= 2 && p[1] == ':' && ('a' <= p[0] && p[0] <= 'z' || 'A' <= p[0] && p[0] <= 'Z')
+}
+
+// containerPathDir returns the directory of a path in a container. Unlike filepath.Dir it doesn't depend on
+// the OS of the host: Windows paths are handled for Windows containers, POSIX paths for Linux containers.
+func containerPathDir(p string) string {
+	if !isWindowsContainerPath(p) {
+		return path.Dir(p)
+	}
+
+	dir := path.Dir(strings.ReplaceAll(p, `\`, "/"))
+	if len(dir) == 2 && dir[1] == ':' {
+		// keep the root of the drive
+		dir += "/"
+	}
+	return strings.ReplaceAll(dir, "/", `\`)
+}
+
+// containerPathBase returns the last element of a path in a container, see containerPathDir
+func containerPathBase(p string) string {
+	if !isWindowsContainerPath(p) {
+		return path.Base(p)
+	}
+	return path.Base(strings.ReplaceAll(p, `\`, "/"))
+}
+
+// normalizeContainerPath uses backslashes as separators in Windows paths, which the Windows daemon requires
+// for the targets of mounts, so paths like C:/data can be used in requests. POSIX paths are kept as they are.
+func normalizeContainerPath(p string) string {
+	if !isWindowsContainerPath(p) {
+		return p
+	}
+	return strings.ReplaceAll(p, "/", `\`)
+}
+
 func isDir(path string) (bool, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -30,7 +85,7 @@ func isDir(path string) (bool, error) {
 }
 
 // tarDir compress a directory using tar + gzip algorithms
-func tarDir(src string, fileMode int64) (*bytes.Buffer, error) {
+func tarDir(src string, fileMode int64, transforms ...TarHeaderTransform) (*bytes.Buffer, error) {
 	buffer := &bytes.Buffer{}
 
 	fmt.Printf(">> creating TAR file from directory: %s\n", src)
@@ -59,8 +114,12 @@ func tarDir(src string, fileMode int64) (*bytes.Buffer, error) {
 
 		// must provide real name
 		// (see https://golang.org/src/archive/tar/common.go?#L626)
-		header.Name = filepath.ToSlash(file)
+		// without the volume name, which Windows paths start with
+		header.Name = filepath.ToSlash(strings.TrimPrefix(file, filepath.VolumeName(file)))
 		header.Mode = fileMode
+		for _, transform := range transforms {
+			transform(header)
+		}
 
 		// write header
 		if err := tw.WriteHeader(header); err != nil {
@@ -94,17 +153,20 @@ func tarDir(src string, fileMode int64) (*bytes.Buffer, error) {
 }
 
 // tarFile compress a single file using tar + gzip algorithms
-func tarFile(fileContent []byte, basePath string, fileMode int64) (*bytes.Buffer, error) {
+func tarFile(fileContent []byte, basePath string, fileMode int64, transforms ...TarHeaderTransform) (*bytes.Buffer, error) {
 	buffer := &bytes.Buffer{}
 
 	zr := gzip.NewWriter(buffer)
 	tw := tar.NewWriter(zr)
 
 	hdr := &tar.Header{
-		Name: filepath.Base(basePath),
+		Name: containerPathBase(basePath),
 		Mode: fileMode,
 		Size: int64(len(fileContent)),
 	}
+	for _, transform := range transforms {
+		transform(hdr)
+	}
 	if err := tw.WriteHeader(hdr); err != nil {
 		return buffer, err
 	}
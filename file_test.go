@@ -110,6 +110,92 @@ func Test_TarFile(t *testing.T) {
 	assert.Equal(t, b, untarBytes)
 }
 
+func Test_TarFileWithWindowsPath(t *testing.T) {
+	buff, err := tarFile([]byte("hello"), `C:\data\hello.txt`, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := tarHeaders(t, buff)
+	assert.Len(t, headers, 1)
+	assert.Equal(t, "hello.txt", headers[0].Name)
+}
+
+func Test_ContainerPaths(t *testing.T) {
+	tests := []struct {
+		path       string
+		dir        string
+		base       string
+		normalized string
+	}{
+		{path: "/etc/hello.txt", dir: "/etc", base: "hello.txt", normalized: "/etc/hello.txt"},
+		{path: "/tmp/testresources/", dir: "/tmp/testresources", base: "testresources", normalized: "/tmp/testresources/"},
+		{path: `C:\data\hello.txt`, dir: `C:\data`, base: "hello.txt", normalized: `C:\data\hello.txt`},
+		{path: "C:/data/hello.txt", dir: `C:\data`, base: "hello.txt", normalized: `C:\data\hello.txt`},
+		{path: `c:\hello.txt`, dir: `c:\`, base: "hello.txt", normalized: `c:\hello.txt`},
+		{path: `C:\data\testresources\`, dir: `C:\data\testresources`, base: "testresources", normalized: `C:\data\testresources\`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			assert.Equal(t, test.dir, containerPathDir(test.path))
+			assert.Equal(t, test.base, containerPathBase(test.path))
+			assert.Equal(t, test.normalized, normalizeContainerPath(test.path))
+		})
+	}
+}
+
+func Test_TarFileWithTransforms(t *testing.T) {
+	prefix := func(header *tar.Header) {
+		header.Name = "config/" + header.Name
+	}
+
+	buff, err := tarFile([]byte("hello"), "/etc/hello.txt", 0644, WithFileOwner(1000, 1001), prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := tarHeaders(t, buff)
+	assert.Len(t, headers, 1)
+	assert.Equal(t, "config/hello.txt", headers[0].Name)
+	assert.Equal(t, 1000, headers[0].Uid)
+	assert.Equal(t, 1001, headers[0].Gid)
+}
+
+func Test_TarDirWithTransforms(t *testing.T) {
+	buff, err := tarDir(filepath.Join(".", "testresources"), 0755, WithFileOwner(1000, 1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, header := range tarHeaders(t, buff) {
+		assert.Equal(t, 1000, header.Uid, header.Name)
+		assert.Equal(t, 1000, header.Gid, header.Name)
+	}
+}
+
+// tarHeaders reads all headers of a tar + gzip archive
+func tarHeaders(t *testing.T, r io.Reader) []*tar.Header {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gzr.Close()
+
+	var headers []*tar.Header
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return headers
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, header)
+	}
+}
+
 // untar takes a destination path and a reader; a tar reader loops over the tarfile
 // creating the file structure at 'dst' along the way, and writing any files
 func untar(dst string, r io.Reader) error {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 )
 
 var (
@@ -14,23 +15,48 @@ var (
 type GenericContainerRequest struct {
 	ContainerRequest              // embedded request for provider
 	Started          bool         // whether to auto-start the container
-	ProviderType     ProviderType // which provider to use, Docker if empty
+	ProviderType     ProviderType // which provider to use, the detected one or Docker if empty
 	Logger           Logging      // provide a container specific Logging - use default global logger if empty
 	Reuse            bool         // reuse an existing container if it exists or create a new one. a container name mustn't be empty
+
+	// provider instance to use instead of ProviderType, e.g. one bound to a specific daemon by NewDockerProviderFor.
+	// It is not closed by GenericContainer
+	Provider GenericProvider
+}
+
+// ContainerCustomizer defines a common interface to modify a GenericContainerRequest before the container is created
+type ContainerCustomizer interface {
+	Customize(req *GenericContainerRequest)
+}
+
+// CustomizeRequestOption is a shorthand to implement the ContainerCustomizer interface
+type CustomizeRequestOption func(req *GenericContainerRequest)
+
+func (opt CustomizeRequestOption) Customize(req *GenericContainerRequest) {
+	opt(req)
 }
 
 // GenericNetworkRequest represents parameters to a generic network
 type GenericNetworkRequest struct {
 	NetworkRequest              // embedded request for provider
-	ProviderType   ProviderType // which provider to use, Docker if empty
+	ProviderType   ProviderType // which provider to use, the detected one or Docker if empty
+
+	// provider instance to use instead of ProviderType, it is not closed by GenericNetwork
+	Provider GenericProvider
 }
 
 // GenericNetwork creates a generic network with parameters
 func GenericNetwork(ctx context.Context, req GenericNetworkRequest) (Network, error) {
-	provider, err := req.ProviderType.GetProvider()
-	if err != nil {
-		return nil, err
+	provider := req.Provider
+	if provider == nil {
+		var err error
+		provider, err = req.ProviderType.GetProvider()
+		if err != nil {
+			return nil, err
+		}
+		defer closeProvider(provider)
 	}
+
 	network, err := provider.CreateNetwork(ctx, req.NetworkRequest)
 	if err != nil {
 		return nil, fmt.Errorf("%w: failed to create network", err)
@@ -49,12 +75,18 @@ func GenericContainer(ctx context.Context, req GenericContainerRequest) (Contain
 	if logging == nil {
 		logging = Logger
 	}
-	provider, err := req.ProviderType.GetProvider(WithLogger(logging))
-	if err != nil {
-		return nil, err
+	provider := req.Provider
+	if provider == nil {
+		var err error
+		provider, err = req.ProviderType.GetProvider(WithLogger(logging))
+		if err != nil {
+			return nil, err
+		}
+		defer closeProvider(provider)
 	}
 
 	var c Container
+	var err error
 	if req.Reuse {
 		c, err = provider.ReuseOrCreateContainer(ctx, req.ContainerRequest)
 	} else {
@@ -72,6 +104,14 @@ func GenericContainer(ctx context.Context, req GenericContainerRequest) (Contain
 	return c, nil
 }
 
+// closeProvider closes providers which hold resources, e.g. the Docker client of a DockerProvider,
+// which is kept open until the containers and networks created by the provider are done with it
+func closeProvider(provider GenericProvider) {
+	if closer, ok := provider.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
 // GenericProvider represents an abstraction for container and network providers
 type GenericProvider interface {
 	ContainerProvider
@@ -0,0 +1,53 @@
+package testcontainers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/docker/docker/api/types/versions"
+)
+
+const (
+	// HostInternal is the host name containers customized with WithHostGateway reach the test host by
+	HostInternal = "host.docker.internal"
+
+	// hostGateway is the special IP of extra hosts resolved to the gateway IP of the container by the Docker daemon
+	hostGateway = "host-gateway"
+)
+
+// WithHostGateway returns a ContainerCustomizer mapping HostInternal to the gateway of the container, so that it can
+// reach services listening on the test host by the same name on Linux, macOS and in CI. Docker Desktop resolves the
+// name on its own, Linux daemons since Docker 20.10 map it to the special host-gateway IP. The provider resolves the
+// gateway IP itself for daemons which don't support it, i.e. Podman and older Docker versions.
+func WithHostGateway() ContainerCustomizer {
+	return CustomizeRequestOption(func(req *GenericContainerRequest) {
+		for _, h := range req.ExtraHosts {
+			if strings.HasPrefix(h, HostInternal+":") {
+				return
+			}
+		}
+		req.ExtraHosts = append(req.ExtraHosts, HostInternal+":"+hostGateway)
+	})
+}
+
+// resolveHostGateway replaces host-gateway in the extra hosts with the gateway IP of the network if the daemon
+// doesn't support it. The extra hosts of the request aren't modified.
+func (p *DockerProvider) resolveHostGateway(ctx context.Context, extraHosts []string, networkName string) ([]string, error) {
+	if p.defaultBridgeNetworkName != Podman && !versions.LessThan(p.client.ClientVersion(), "1.41") {
+		return extraHosts, nil
+	}
+
+	resolved := make([]string, 0, len(extraHosts))
+	for _, h := range extraHosts {
+		name, ip, _ := strings.Cut(h, ":")
+		if ip == hostGateway {
+			gateway, err := p.GatewayIP(ctx, networkName)
+			if err != nil {
+				return nil, err
+			}
+			h = name + ":" + gateway
+		}
+		resolved = append(resolved, h)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,54 @@
+package testcontainers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHostGateway(t *testing.T) {
+	req := GenericContainerRequest{ContainerRequest: ContainerRequest{ExtraHosts: []string{"db:10.0.0.2"}}}
+	WithHostGateway().Customize(&req)
+	WithHostGateway().Customize(&req)
+	assert.Equal(t, []string{"db:10.0.0.2", "host.docker.internal:host-gateway"}, req.ExtraHosts)
+
+	req = GenericContainerRequest{ContainerRequest: ContainerRequest{ExtraHosts: []string{"host.docker.internal:192.168.1.10"}}}
+	WithHostGateway().Customize(&req)
+	assert.Equal(t, []string{"host.docker.internal:192.168.1.10"}, req.ExtraHosts, "explicit mappings are kept")
+}
+
+func TestResolveHostGateway(t *testing.T) {
+	server := fakeDaemonServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.41/networks/podman", "/v1.40/networks/bridge":
+			_, _ = w.Write([]byte(`{"IPAM": {"Config": [{"Subnet": "10.88.0.0/16", "Gateway": "10.88.0.1"}]}}`))
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+
+	extraHosts := []string{"db:10.0.0.2", "host.docker.internal:host-gateway"}
+	for _, tc := range []struct {
+		name     string
+		version  string
+		opts     []DockerProviderOption
+		expected []string
+	}{
+		{name: "docker", version: "1.41", expected: extraHosts},
+		{name: "docker before 20.10", version: "1.40", opts: []DockerProviderOption{DefaultNetwork("bridge")}, expected: []string{"db:10.0.0.2", "host.docker.internal:10.88.0.1"}},
+		{name: "podman", version: "1.41", opts: []DockerProviderOption{WithDefaultBridgeNetwork(Podman), DefaultNetwork(Podman)}, expected: []string{"db:10.0.0.2", "host.docker.internal:10.88.0.1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := NewDockerProvider(append(tc.opts, WithDockerClient(fakeDaemonClient(t, server, tc.version)))...)
+			require.NoError(t, err)
+			defer provider.Close()
+
+			resolved, err := provider.resolveHostGateway(context.Background(), extraHosts, "")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resolved)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package testcontainers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// SaveImages writes the images, with all their layers and tags, as a tar archive to w, like docker save.
+// CI pipelines can cache the archive between jobs and load it with LoadImage before running the tests.
+func (p *DockerProvider) SaveImages(ctx context.Context, w io.Writer, images ...string) error {
+	if len(images) == 0 {
+		return errors.New("no images to save")
+	}
+
+	archive, err := p.client.ImageSave(ctx, images)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	_, err = io.Copy(w, archive)
+	return err
+}
+
+// LoadImage loads the images of a tar archive written by SaveImages or docker save, like docker load.
+// It returns the references of the loaded images.
+func (p *DockerProvider) LoadImage(ctx context.Context, r io.Reader) ([]string, error) {
+	resp, err := p.client.ImageLoad(ctx, r, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var images []string
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg jsonmessage.JSONMessage
+		err := decoder.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+
+		// the daemon reports each image as "Loaded image: redis:6", or "Loaded image ID: sha256:..." if untagged
+		for _, prefix := range []string{"Loaded image: ", "Loaded image ID: "} {
+			if strings.HasPrefix(msg.Stream, prefix) {
+				images = append(images, strings.TrimSpace(strings.TrimPrefix(msg.Stream, prefix)))
+			}
+		}
+	}
+
+	return images, nil
+}
@@ -0,0 +1,46 @@
+package testcontainers
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoadImages(t *testing.T) {
+	var saved []string
+	var loaded []byte
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.41/images/get":
+			saved = r.URL.Query()["names"]
+			_, _ = w.Write([]byte("archive"))
+		case "/v1.41/images/load":
+			loaded, _ = ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"stream":"Loaded image: redis:6\n"}
+{"stream":"Loaded image ID: sha256:c7a4e4382001\n"}
+`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+
+	ctx := context.Background()
+	archive := &bytes.Buffer{}
+	require.NoError(t, provider.SaveImages(ctx, archive, "redis:6", "nginx:alpine"))
+	assert.Equal(t, []string{"redis:6", "nginx:alpine"}, saved)
+	assert.Equal(t, "archive", archive.String())
+
+	images, err := provider.LoadImage(ctx, archive)
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(loaded))
+	assert.Equal(t, []string{"redis:6", "sha256:c7a4e4382001"}, images)
+
+	assert.Error(t, provider.SaveImages(ctx, archive), "at least one image must be saved")
+}
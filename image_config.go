@@ -0,0 +1,110 @@
+package testcontainers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// ContainerCommand is what a container executes when it starts
+type ContainerCommand struct {
+	Entrypoint []string
+	Cmd        []string // arguments passed to the entrypoint
+	Env        []string // KEY=value pairs
+}
+
+// Args returns the entrypoint followed by its arguments
+func (c ContainerCommand) Args() []string {
+	args := make([]string, 0, len(c.Entrypoint)+len(c.Cmd))
+	args = append(args, c.Entrypoint...)
+	return append(args, c.Cmd...)
+}
+
+// String renders the command the way it could be typed into a shell, prefixed with its environment
+func (c ContainerCommand) String() string {
+	parts := make([]string, 0, len(c.Env)+len(c.Entrypoint)+len(c.Cmd))
+	for _, e := range c.Env {
+		name, value, _ := strings.Cut(e, "=")
+		parts = append(parts, name+"="+shellQuote(value))
+	}
+	for _, arg := range c.Args() {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote quotes s with single quotes, if it contains characters a shell would interpret
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`|&;<>()*?[]#~!{}") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// EffectiveCommand renders the entrypoint, command and environment the container will run with,
+// applying the request on top of the configuration of its image the same way the Docker daemon does:
+// the entrypoint of the request replaces the one of the image and drops the command of the image,
+// the command of the request replaces the one of the image and its environment is merged into the one of the image.
+func (c *ContainerRequest) EffectiveCommand(imageConfig *container.Config) ContainerCommand {
+	if imageConfig == nil {
+		imageConfig = &container.Config{}
+	}
+
+	cmd := ContainerCommand{
+		Entrypoint: c.Entrypoint,
+		Cmd:        c.Cmd,
+	}
+	if len(c.Entrypoint) == 0 {
+		cmd.Entrypoint = imageConfig.Entrypoint
+		if len(c.Cmd) == 0 {
+			cmd.Cmd = imageConfig.Cmd
+		}
+	}
+
+	keys := make([]string, 0, len(c.Env))
+	for k := range c.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	applied := map[string]bool{}
+	for _, e := range imageConfig.Env {
+		key := strings.SplitN(e, "=", 2)[0]
+		if v, ok := c.Env[key]; ok {
+			e = key + "=" + v
+			applied[key] = true
+		}
+		cmd.Env = append(cmd.Env, e)
+	}
+	for _, k := range keys {
+		if !applied[k] {
+			cmd.Env = append(cmd.Env, k+"="+c.Env[k])
+		}
+	}
+
+	return cmd
+}
+
+// ImageConfig returns the configuration of the image, e.g. to render the command a container will run
+// with using ContainerRequest.EffectiveCommand. The image is pulled if it isn't available locally.
+func (p *DockerProvider) ImageConfig(ctx context.Context, ref string) (*container.Config, error) {
+	image, _, err := p.client.ImageInspectWithRaw(ctx, ref)
+	if client.IsErrNotFound(err) {
+		if err = p.attemptToPullImage(ctx, ref, types.ImagePullOptions{}); err != nil {
+			return nil, err
+		}
+		image, _, err = p.client.ImageInspectWithRaw(ctx, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if image.Config == nil {
+		return &container.Config{}, nil
+	}
+	return image.Config, nil
+}
@@ -0,0 +1,111 @@
+package testcontainers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerRequestEffectiveCommand(t *testing.T) {
+	imageConfig := &container.Config{
+		Entrypoint: []string{"/docker-entrypoint.sh"},
+		Cmd:        []string{"nginx", "-g", "daemon off;"},
+		Env:        []string{"PATH=/usr/bin", "NGINX_VERSION=1.23.1"},
+	}
+
+	tests := []struct {
+		name     string
+		req      ContainerRequest
+		config   *container.Config
+		expected ContainerCommand
+	}{
+		{
+			name:   "image defaults",
+			req:    ContainerRequest{},
+			config: imageConfig,
+			expected: ContainerCommand{
+				Entrypoint: []string{"/docker-entrypoint.sh"},
+				Cmd:        []string{"nginx", "-g", "daemon off;"},
+				Env:        []string{"PATH=/usr/bin", "NGINX_VERSION=1.23.1"},
+			},
+		},
+		{
+			name:   "cmd replaces the cmd of the image",
+			req:    ContainerRequest{Cmd: []string{"nginx-debug"}},
+			config: imageConfig,
+			expected: ContainerCommand{
+				Entrypoint: []string{"/docker-entrypoint.sh"},
+				Cmd:        []string{"nginx-debug"},
+				Env:        []string{"PATH=/usr/bin", "NGINX_VERSION=1.23.1"},
+			},
+		},
+		{
+			name:   "entrypoint drops the cmd of the image",
+			req:    ContainerRequest{Entrypoint: []string{"sh"}},
+			config: imageConfig,
+			expected: ContainerCommand{
+				Entrypoint: []string{"sh"},
+				Env:        []string{"PATH=/usr/bin", "NGINX_VERSION=1.23.1"},
+			},
+		},
+		{
+			name:   "env is merged into the env of the image",
+			req:    ContainerRequest{Env: map[string]string{"PATH": "/bin", "B": "2", "A": "1"}},
+			config: imageConfig,
+			expected: ContainerCommand{
+				Entrypoint: []string{"/docker-entrypoint.sh"},
+				Cmd:        []string{"nginx", "-g", "daemon off;"},
+				Env:        []string{"PATH=/bin", "NGINX_VERSION=1.23.1", "A=1", "B=2"},
+			},
+		},
+		{
+			name:   "no image config",
+			req:    ContainerRequest{Cmd: []string{"echo", "hello"}},
+			config: nil,
+			expected: ContainerCommand{
+				Cmd: []string{"echo", "hello"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.req.EffectiveCommand(tt.config))
+		})
+	}
+}
+
+func TestContainerCommandString(t *testing.T) {
+	cmd := ContainerCommand{
+		Entrypoint: []string{"/docker-entrypoint.sh"},
+		Cmd:        []string{"nginx", "-g", "daemon off;", "it's"},
+		Env:        []string{"GREETING=hello world"},
+	}
+
+	assert.Equal(t, []string{"/docker-entrypoint.sh", "nginx", "-g", "daemon off;", "it's"}, cmd.Args())
+	assert.Equal(t, `GREETING='hello world' /docker-entrypoint.sh nginx -g 'daemon off;' 'it'\''s'`, cmd.String())
+}
+
+func TestDockerProviderImageConfig(t *testing.T) {
+	ctx := context.Background()
+
+	provider, err := NewDockerProvider()
+	require.NoError(t, err)
+	defer provider.Close()
+
+	imageConfig, err := provider.ImageConfig(ctx, nginxAlpineImage)
+	require.NoError(t, err)
+
+	req := ContainerRequest{
+		Image: nginxAlpineImage,
+		Env:   map[string]string{"FOO": "bar"},
+	}
+	cmd := req.EffectiveCommand(imageConfig)
+
+	assert.Equal(t, []string{"/docker-entrypoint.sh"}, cmd.Entrypoint)
+	assert.Contains(t, cmd.Cmd, "nginx")
+	assert.Contains(t, cmd.Env, "FOO=bar")
+}
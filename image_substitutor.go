@@ -0,0 +1,93 @@
+package testcontainers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImageSubstitutor rewrites the references of the images used by testcontainers, e.g. to pull all images through
+// an internal registry mirror. It's applied to the images of containers, including the reaper, and of compose services.
+type ImageSubstitutor interface {
+	// Description describes the substitution, for logs and errors
+	Description() string
+	// Substitute returns the reference of the image to use instead of the given one, or the given one to keep it
+	Substitute(image string) (string, error)
+}
+
+// ImageSubstitutorsOption registers image substitutors on a DockerProvider or a LocalDockerCompose
+type ImageSubstitutorsOption struct {
+	substitutors []ImageSubstitutor
+}
+
+// WithImageSubstitutors applies the substitutors, in order, to all images after the prefix configured with
+// hub.image.name.prefix
+func WithImageSubstitutors(substitutors ...ImageSubstitutor) ImageSubstitutorsOption {
+	return ImageSubstitutorsOption{substitutors: substitutors}
+}
+
+func (o ImageSubstitutorsOption) ApplyDockerTo(opts *DockerProviderOptions) {
+	opts.imageSubstitutors = append(opts.imageSubstitutors, o.substitutors...)
+}
+
+func (o ImageSubstitutorsOption) ApplyToLocalCompose(opts *LocalDockerComposeOptions) {
+	opts.ImageSubstitutors = append(opts.ImageSubstitutors, o.substitutors...)
+}
+
+// PrefixSubstitutor prefixes the references of Docker Hub images, e.g. with "registry.example.com/mirror/"
+// redis:6 is pulled as registry.example.com/mirror/redis:6. Images of other registries are kept.
+func PrefixSubstitutor(prefix string) ImageSubstitutor {
+	return prefixSubstitutor{prefix: prefix}
+}
+
+type prefixSubstitutor struct {
+	prefix string
+}
+
+func (s prefixSubstitutor) Description() string {
+	return fmt.Sprintf("PrefixSubstitutor(%s)", s.prefix)
+}
+
+func (s prefixSubstitutor) Substitute(image string) (string, error) {
+	name, ok := dockerHubImageName(image)
+	if !ok || s.prefix == "" {
+		return image, nil
+	}
+	return s.prefix + name, nil
+}
+
+// dockerHubImageName returns the reference of an image of Docker Hub without its registry,
+// e.g. library/redis:6 for docker.io/library/redis:6, or false if the image is hosted by another registry
+func dockerHubImageName(image string) (string, bool) {
+	for _, registry := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		if strings.HasPrefix(image, registry) {
+			return strings.TrimPrefix(image, registry), true
+		}
+	}
+
+	// like the Docker CLI, the first component of the name is a registry if it looks like a host name
+	idx := strings.Index(image, "/")
+	if idx < 0 {
+		return image, true
+	}
+	first := image[:idx]
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return "", false
+	}
+	return image, true
+}
+
+// substituteImage applies the prefix configured with hub.image.name.prefix, then the substitutors to the image
+func substituteImage(config TestContainersConfig, substitutors []ImageSubstitutor, image string) (string, error) {
+	if config.HubImageNamePrefix != "" {
+		substitutors = append([]ImageSubstitutor{PrefixSubstitutor(config.HubImageNamePrefix)}, substitutors...)
+	}
+
+	for _, s := range substitutors {
+		substituted, err := s.Substitute(image)
+		if err != nil {
+			return "", fmt.Errorf("%w: failed to substitute image %s with %s", err, image, s.Description())
+		}
+		image = substituted
+	}
+	return image, nil
+}
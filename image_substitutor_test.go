@@ -0,0 +1,73 @@
+package testcontainers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixSubstitutor(t *testing.T) {
+	s := PrefixSubstitutor("registry.example.com/mirror/")
+
+	for image, expected := range map[string]string{
+		"redis:6":                            "registry.example.com/mirror/redis:6",
+		"testcontainers/ryuk:0.3.3":          "registry.example.com/mirror/testcontainers/ryuk:0.3.3",
+		"docker.io/library/redis:6":          "registry.example.com/mirror/library/redis:6",
+		"quay.io/prometheus/prometheus":      "quay.io/prometheus/prometheus",
+		"localhost/app:test":                 "localhost/app:test",
+		"registry.example.com:5000/app:test": "registry.example.com:5000/app:test",
+	} {
+		substituted, err := s.Substitute(image)
+		require.NoError(t, err)
+		assert.Equal(t, expected, substituted, image)
+	}
+}
+
+// registrySubstitutor replaces the registry of all images, or fails for the images of untrusted registries
+type registrySubstitutor struct{}
+
+func (registrySubstitutor) Description() string {
+	return "registrySubstitutor"
+}
+
+func (registrySubstitutor) Substitute(image string) (string, error) {
+	if image == "untrusted.example.com/app" {
+		return "", errors.New("untrusted registry")
+	}
+	return "registry.internal/" + image, nil
+}
+
+func TestSubstituteImage(t *testing.T) {
+	config := TestContainersConfig{HubImageNamePrefix: "mirror.example.com/"}
+
+	image, err := substituteImage(config, nil, "redis:6")
+	require.NoError(t, err)
+	assert.Equal(t, "mirror.example.com/redis:6", image)
+
+	image, err = substituteImage(config, []ImageSubstitutor{registrySubstitutor{}}, "redis:6")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.internal/mirror.example.com/redis:6", image, "the substitutors are applied after the prefix")
+
+	_, err = substituteImage(TestContainersConfig{}, []ImageSubstitutor{registrySubstitutor{}}, "untrusted.example.com/app")
+	assert.EqualError(t, err, "untrusted registry: failed to substitute image untrusted.example.com/app with registrySubstitutor")
+}
+
+func TestComposeImageSubstitutions(t *testing.T) {
+	compose := NewLocalDockerCompose(nil, "substitutions",
+		WithStackContent([]byte(`services:
+  redis:
+    image: redis:6
+  app:
+    image: ${APP_IMAGE}
+  internal:
+    image: registry.example.com/internal:1
+`)),
+		WithImageSubstitutors(PrefixSubstitutor("mirror.example.com/")),
+	)
+	require.NoError(t, compose.stackErr)
+
+	require.Len(t, compose.stacks, 2, "the substitutions are added as a stack of their own")
+	assert.Equal(t, "services:\n    redis:\n        image: mirror.example.com/redis:6\n", string(compose.stacks[1]))
+}
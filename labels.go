@@ -0,0 +1,108 @@
+package testcontainers
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+// labels applied to the resources created by testcontainers, e.g. to filter them in cleanup tooling
+const (
+	TestcontainerLabel          = "org.testcontainers.golang"
+	TestcontainerLabelSessionID = TestcontainerLabel + ".sessionId"
+	TestcontainerLabelIsReaper  = TestcontainerLabel + ".reaper"
+	TestcontainerLabelLang      = "org.testcontainers.lang"
+
+	// labels of the volumes storing the entries of a Session
+	TestcontainerLabelSessionNamespace = TestcontainerLabel + ".session.namespace"
+	TestcontainerLabelSessionEntry     = TestcontainerLabel + ".session.entry"
+	TestcontainerLabelSessionKey       = TestcontainerLabel + ".session.key"
+	TestcontainerLabelSessionValue     = TestcontainerLabel + ".session.value"
+	TestcontainerLabelSessionRevision  = TestcontainerLabel + ".session.revision"
+
+	// TestcontainerLang is the value of the TestcontainerLabelLang label
+	TestcontainerLang = "go"
+
+	// standard labels shared by the Testcontainers implementations of all languages, which correlate the resources
+	// with the test run even if they aren't cleaned up by the reaper
+	TestcontainersLabel          = "org.testcontainers"
+	TestcontainersLabelSessionID = TestcontainersLabel + ".sessionId"
+	TestcontainersLabelVersion   = TestcontainersLabel + ".version"
+)
+
+// fallbackVersion is the version of the latest release, used if the build info doesn't contain the version of the
+// module, e.g. in its own tests or when it is replaced by a local copy. Update it with latest_version in mkdocs.yml.
+const fallbackVersion = "0.14.0"
+
+const modulePath = "github.com/testcontainers/testcontainers-go"
+
+// Version is the version of testcontainers-go, the value of the TestcontainersLabelVersion label. It is read from
+// the build info of the binary, falling back to the version of the latest release.
+var Version = moduleVersion(debug.ReadBuildInfo())
+
+// moduleVersion returns the version of testcontainers-go required by the build, without the leading v
+func moduleVersion(info *debug.BuildInfo, ok bool) string {
+	if !ok {
+		return fallbackVersion
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath || dep.Replace != nil {
+			continue
+		}
+		if dep.Version != "" && dep.Version != "(devel)" {
+			return strings.TrimPrefix(dep.Version, "v")
+		}
+	}
+	return fallbackVersion
+}
+
+// SessionID returns the ID of the current test session, which is shared by all resources created by this process
+func SessionID() string {
+	return sessionID().String()
+}
+
+// SessionLabels returns the labels applied to all resources cleaned up by the reaper in the current test session
+func SessionLabels() map[string]string {
+	return sessionLabels(SessionID())
+}
+
+// StandardLabels returns the standard labels of the current test session, which are applied to all resources
+// created by testcontainers, including the ones skipping the reaper
+func StandardLabels() map[string]string {
+	return map[string]string{
+		TestcontainersLabel:          "true",
+		TestcontainersLabelSessionID: SessionID(),
+		TestcontainersLabelVersion:   Version,
+		TestcontainerLabelLang:       TestcontainerLang,
+	}
+}
+
+func sessionLabels(sessionID string) map[string]string {
+	return map[string]string{
+		TestcontainerLabel:          "true",
+		TestcontainerLabelSessionID: sessionID,
+		TestcontainerLabelLang:      TestcontainerLang,
+	}
+}
+
+// LabelFilters converts the labels into filters.Args, e.g. to list the containers or networks having all of them
+func LabelFilters(labels map[string]string) filters.Args {
+	f := filters.NewArgs()
+	for k, v := range labels {
+		f.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+	return f
+}
+
+// SessionFilters returns filters.Args matching all resources created in the current test session
+func SessionFilters() filters.Args {
+	return LabelFilters(SessionLabels())
+}
+
+// TestcontainersFilters returns filters.Args matching all resources created by testcontainers in any test session
+func TestcontainersFilters() filters.Args {
+	return LabelFilters(map[string]string{TestcontainerLabel: "true"})
+}
@@ -0,0 +1,48 @@
+package testcontainers
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionLabels(t *testing.T) {
+	labels := SessionLabels()
+
+	assert.Equal(t, "true", labels[TestcontainerLabel])
+	assert.Equal(t, SessionID(), labels[TestcontainerLabelSessionID])
+	assert.Equal(t, "go", labels[TestcontainerLabelLang])
+}
+
+func TestStandardLabels(t *testing.T) {
+	labels := StandardLabels()
+
+	assert.Equal(t, map[string]string{
+		"org.testcontainers":           "true",
+		"org.testcontainers.sessionId": SessionID(),
+		"org.testcontainers.version":   Version,
+		"org.testcontainers.lang":      "go",
+	}, labels)
+}
+
+func TestModuleVersion(t *testing.T) {
+	dep := func(version string, replace *debug.Module) *debug.Module {
+		return &debug.Module{Path: "github.com/testcontainers/testcontainers-go", Version: version, Replace: replace}
+	}
+
+	assert.Equal(t, "0.15.0", moduleVersion(&debug.BuildInfo{Deps: []*debug.Module{dep("v0.15.0", nil)}}, true))
+	assert.Equal(t, fallbackVersion, moduleVersion(&debug.BuildInfo{Deps: []*debug.Module{dep("v0.15.0", &debug.Module{Path: "../testcontainers-go"})}}, true))
+	assert.Equal(t, fallbackVersion, moduleVersion(&debug.BuildInfo{Deps: []*debug.Module{dep("(devel)", nil)}}, true))
+	assert.Equal(t, fallbackVersion, moduleVersion(&debug.BuildInfo{}, true))
+	assert.Equal(t, fallbackVersion, moduleVersion(nil, false))
+}
+
+func TestSessionFilters(t *testing.T) {
+	f := SessionFilters()
+
+	assert.True(t, f.ExactMatch("label", TestcontainerLabel+"=true"))
+	assert.True(t, f.ExactMatch("label", TestcontainerLabelSessionID+"="+SessionID()))
+	assert.True(t, f.ExactMatch("label", TestcontainerLabelLang+"=go"))
+	assert.Len(t, f.Get("label"), 3)
+}
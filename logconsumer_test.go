@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"gotest.tools/v3/assert"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -239,7 +240,7 @@ func TestContainerLogWithErrClosed(t *testing.T) {
 
 	client.NegotiateAPIVersion(ctx)
 
-	provider := &DockerProvider{client: client, DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{Logger: TestLogger(t)}}}
+	provider := &DockerProvider{client: newSharedClient(client), DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{Logger: TestLogger(t)}}}
 
 	nginx, err := provider.CreateContainer(ctx, ContainerRequest{Image: "nginx", ExposedPorts: []string{"80/tcp"}})
 	if err != nil {
@@ -332,3 +333,57 @@ func TestContainerLogsShouldBeWithoutStreamHeader(t *testing.T) {
 	}
 	assert.Equal(t, "0", strings.TrimSpace(string(b)))
 }
+
+func Test_StopLogProducerReturnsErrorOfRemovedContainer(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+
+	// the producer can't attach to the logs of a removed container,
+	// which must be reported through StopLogProducer instead of crashing the test binary
+	dockerContainer := c.(*DockerContainer)
+	require.NoError(t, dockerContainer.provider.client.ContainerRemove(ctx, c.GetContainerID(), types.ContainerRemoveOptions{Force: true}))
+
+	require.NoError(t, c.StartLogProducer(ctx))
+	assert.ErrorContains(t, c.StopLogProducer(), "No such container")
+}
+
+func Test_FollowOutputWhileProducing(t *testing.T) {
+	c := &DockerContainer{}
+
+	first := &TestLogConsumer{}
+	second := &TestLogConsumer{}
+
+	// consumers can be changed while the producer reads them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = c.logConsumers()
+		}
+	}()
+
+	c.FollowOutput(first)
+	c.FollowOutput(second)
+	<-done
+
+	consumers := c.logConsumers()
+	assert.Equal(t, 2, len(consumers))
+
+	c.UnfollowOutput(first)
+	consumers = c.logConsumers()
+	assert.Equal(t, 1, len(consumers))
+	assert.Equal(t, LogConsumer(second), consumers[0])
+
+	// removing an unknown consumer is a no-op
+	c.UnfollowOutput(first)
+	assert.Equal(t, 1, len(c.logConsumers()))
+}
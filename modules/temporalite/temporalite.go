@@ -0,0 +1,114 @@
+// Package temporalite runs the Temporal development server, so services depending on Temporal workflows
+// can be integration tested against a real frontend.
+package temporalite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/go-connections/nat"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// DefaultImage is the image of the Temporal CLI, which ships the development server.
+	// It's pinned to keep the tests reproducible, use WithImage to run another version.
+	DefaultImage = "docker.io/temporalio/temporal:1.1.2"
+
+	// DefaultNamespace is the namespace the development server always registers
+	DefaultNamespace = "default"
+
+	frontendPort = nat.Port("7233/tcp")
+	uiPort       = nat.Port("8233/tcp")
+)
+
+type options struct {
+	req        testcontainers.GenericContainerRequest
+	namespaces []string
+}
+
+// Option customizes the Temporal container
+type Option func(*options)
+
+// WithImage sets the Temporal CLI image to run, e.g. to pin a version
+func WithImage(image string) Option {
+	return func(o *options) {
+		o.req.Image = image
+	}
+}
+
+// WithNamespaces registers the namespaces on startup in addition to the default namespace
+func WithNamespaces(namespaces ...string) Option {
+	return func(o *options) {
+		o.namespaces = append(o.namespaces, namespaces...)
+	}
+}
+
+// Container represents a running Temporal development server
+type Container struct {
+	testcontainers.Container
+	HostPort  string // address of the frontend gRPC endpoint, e.g. localhost:49153, to be used as client HostPort
+	UIURI     string // base URI of the web UI and the HTTP API
+	Namespace string // the namespace to use by default
+}
+
+// RunContainer starts the Temporal development server with an in-memory store
+func RunContainer(ctx context.Context, opts ...Option) (*Container, error) {
+	o := options{
+		req: testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Image:        DefaultImage,
+				ExposedPorts: []string{string(frontendPort), string(uiPort)},
+				// the UI is served once the frontend accepts connections
+				WaitingFor: wait.ForHTTP("/").WithPort(uiPort),
+			},
+			Started: true,
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cmd := []string{"server", "start-dev", "--ip", "0.0.0.0"}
+	for _, ns := range o.namespaces {
+		cmd = append(cmd, "--namespace", ns)
+	}
+	o.req.Cmd = cmd
+
+	container, err := testcontainers.GenericContainer(ctx, o.req)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	frontend, err := container.MappedPort(ctx, frontendPort)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	ui, err := container.MappedPort(ctx, uiPort)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	c := &Container{
+		Container: container,
+		HostPort:  fmt.Sprintf("%s:%s", host, frontend.Port()),
+		UIURI:     fmt.Sprintf("http://%s:%s", host, ui.Port()),
+		Namespace: DefaultNamespace,
+	}
+	if len(o.namespaces) > 0 {
+		c.Namespace = o.namespaces[0]
+	}
+
+	return c, nil
+}
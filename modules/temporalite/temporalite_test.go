@@ -0,0 +1,43 @@
+package temporalite
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemporalite(t *testing.T) {
+	ctx := context.Background()
+
+	container, err := RunContainer(ctx, WithNamespaces("orders"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, container.Terminate(ctx))
+	})
+
+	assert.Equal(t, "orders", container.Namespace)
+
+	conn, err := net.DialTimeout("tcp", container.HostPort, 5*time.Second)
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	resp, err := http.Get(container.UIURI + "/api/v1/namespaces/orders")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var namespace struct {
+		NamespaceInfo struct {
+			Name string `json:"name"`
+		} `json:"namespaceInfo"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&namespace))
+	assert.Equal(t, "orders", namespace.NamespaceInfo.Name)
+}
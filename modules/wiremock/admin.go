@@ -0,0 +1,100 @@
+package wiremock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const adminPath = "/__admin"
+
+// RequestPattern matches the requests a stub mapping applies to, see https://wiremock.org/docs/request-matching/
+type RequestPattern struct {
+	Method          string                 `json:"method,omitempty"`
+	URL             string                 `json:"url,omitempty"`
+	URLPath         string                 `json:"urlPath,omitempty"`
+	URLPattern      string                 `json:"urlPattern,omitempty"`
+	URLPathPattern  string                 `json:"urlPathPattern,omitempty"`
+	QueryParameters map[string]interface{} `json:"queryParameters,omitempty"`
+	Headers         map[string]interface{} `json:"headers,omitempty"`
+	BodyPatterns    []interface{}          `json:"bodyPatterns,omitempty"`
+}
+
+// ResponseDefinition describes the response of a stub mapping, see https://wiremock.org/docs/stubbing/
+type ResponseDefinition struct {
+	Status   int               `json:"status,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	JSONBody interface{}       `json:"jsonBody,omitempty"`
+	// FixedDelayMilliseconds delays the response, e.g. to test timeouts
+	FixedDelayMilliseconds int `json:"fixedDelayMilliseconds,omitempty"`
+}
+
+// Mapping is a stub mapping, responding to all requests matching the request pattern with the response
+type Mapping struct {
+	Name     string             `json:"name,omitempty"`
+	Priority int                `json:"priority,omitempty"`
+	Request  RequestPattern     `json:"request"`
+	Response ResponseDefinition `json:"response"`
+}
+
+// Stub registers the stub mapping, e.g. for a single test
+func (c *Container) Stub(ctx context.Context, m Mapping) error {
+	return c.admin(ctx, http.MethodPost, "/mappings", m, http.StatusCreated, nil)
+}
+
+// Reset removes the stub mappings registered at runtime and clears the request journal.
+// Mappings loaded from files on startup are kept.
+func (c *Container) Reset(ctx context.Context) error {
+	return c.admin(ctx, http.MethodPost, "/reset", nil, http.StatusOK, nil)
+}
+
+// CountRequests returns the number of received requests matching the pattern, e.g. to verify a request was sent
+func (c *Container) CountRequests(ctx context.Context, pattern RequestPattern) (int, error) {
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := c.admin(ctx, http.MethodPost, "/requests/count", pattern, http.StatusOK, &result); err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// admin sends a request to the admin API of WireMock and decodes the response into result, if not nil
+func (c *Container) admin(ctx context.Context, method, path string, body interface{}, expectedStatus int, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URI+adminPath+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to call the WireMock admin API", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("WireMock admin API %s %s returned status %d: %s", method, path, resp.StatusCode, msg)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,114 @@
+// Package wiremock runs a WireMock stub server, so tests can stub and verify the HTTP APIs their code depends on.
+package wiremock
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// DefaultImage is the WireMock image used if no other image is configured
+	DefaultImage = "docker.io/wiremock/wiremock:2.35.0"
+
+	port        = nat.Port("8080/tcp")
+	mappingsDir = "/home/wiremock/mappings"
+)
+
+type options struct {
+	req      testcontainers.GenericContainerRequest
+	mappings []Mapping
+}
+
+// Option customizes the WireMock container
+type Option func(*options)
+
+// WithImage sets the WireMock image to run, e.g. to use a mirror
+func WithImage(image string) Option {
+	return func(o *options) {
+		o.req.Image = image
+	}
+}
+
+// WithMappingFile loads the stub mappings of the JSON file at hostPath on startup.
+// The file must follow the mapping format of WireMock, either a single mapping or {"mappings": [...]}.
+func WithMappingFile(hostPath string) Option {
+	return func(o *options) {
+		o.req.Files = append(o.req.Files, testcontainers.ContainerFile{
+			HostFilePath:      hostPath,
+			ContainerFilePath: mappingsDir + "/" + filepath.Base(hostPath),
+			FileMode:          0644,
+		})
+	}
+}
+
+// WithMappings registers the stub mappings once WireMock is ready
+func WithMappings(mappings ...Mapping) Option {
+	return func(o *options) {
+		o.mappings = append(o.mappings, mappings...)
+	}
+}
+
+// Container represents a running WireMock stub server
+type Container struct {
+	testcontainers.Container
+	URI string // base URI of the stub server, e.g. http://localhost:49153
+}
+
+// RunContainer starts WireMock and registers the given stub mappings
+func RunContainer(ctx context.Context, opts ...Option) (*Container, error) {
+	o := options{
+		req: testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Image:        DefaultImage,
+				ExposedPorts: []string{string(port)},
+				WaitingFor:   wait.ForHTTP(adminPath + "/mappings").WithPort(port),
+			},
+			Started: true,
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, o.req)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, port)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	c := &Container{
+		Container: container,
+		URI:       fmt.Sprintf("http://%s:%s", host, mappedPort.Port()),
+	}
+
+	for _, m := range o.mappings {
+		if err := c.Stub(ctx, m); err != nil {
+			return c, err
+		}
+	}
+
+	return c, nil
+}
+
+// URL returns the URL of the given path on the stub server
+func (c *Container) URL(path string) string {
+	return c.URI + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,71 @@
+package wiremock
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWireMock(t *testing.T) {
+	ctx := context.Background()
+
+	container, err := RunContainer(ctx,
+		WithMappingFile("testdata/hello.json"),
+		WithMappings(Mapping{
+			Request:  RequestPattern{Method: http.MethodGet, URLPath: "/users/1"},
+			Response: ResponseDefinition{Status: http.StatusOK, JSONBody: map[string]string{"name": "gopher"}},
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, container.Terminate(ctx))
+	})
+
+	t.Run("mapping file", func(t *testing.T) {
+		assertResponse(t, container.URL("/hello"), http.StatusOK, "Hello, world!")
+	})
+
+	t.Run("mapping", func(t *testing.T) {
+		assertResponse(t, container.URL("/users/1"), http.StatusOK, `{"name":"gopher"}`)
+	})
+
+	t.Run("stub and verify", func(t *testing.T) {
+		err := container.Stub(ctx, Mapping{
+			Request:  RequestPattern{Method: http.MethodGet, URL: "/teapot"},
+			Response: ResponseDefinition{Status: http.StatusTeapot},
+		})
+		require.NoError(t, err)
+
+		assertResponse(t, container.URL("/teapot"), http.StatusTeapot, "")
+
+		count, err := container.CountRequests(ctx, RequestPattern{Method: http.MethodGet, URL: "/teapot"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		require.NoError(t, container.Reset(ctx))
+
+		assertResponse(t, container.URL("/hello"), http.StatusOK, "Hello, world!")
+		assertResponse(t, container.URL("/teapot"), http.StatusNotFound, "")
+	})
+}
+
+func assertResponse(t *testing.T, url string, status int, body string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, status, resp.StatusCode)
+	if body != "" {
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(b))
+	}
+}
@@ -0,0 +1,505 @@
+package nerdctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Implement interface
+var _ testcontainers.Container = (*Container)(nil)
+
+// Container is a container run by nerdctl
+type Container struct {
+	ID string
+
+	provider *Provider
+	req      testcontainers.ContainerRequest
+
+	mtx          sync.Mutex
+	running      bool
+	consumers    []testcontainers.LogConsumer
+	stopProducer context.CancelFunc
+	producerDone chan struct{}
+	tmpDirs      []string // snapshots of the file system returned by FS, removed on Terminate
+}
+
+func (c *Container) GetContainerID() string {
+	return c.ID
+}
+
+func (c *Container) Endpoint(ctx context.Context, proto string) (string, error) {
+	ports, err := c.Ports(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// use the lowest exposed port, so that the endpoint is stable
+	exposed := make([]string, 0, len(ports))
+	for p := range ports {
+		exposed = append(exposed, string(p))
+	}
+	if len(exposed) == 0 {
+		return "", errors.New("port not found")
+	}
+	sort.Strings(exposed)
+
+	return c.PortEndpoint(ctx, nat.Port(exposed[0]), proto)
+}
+
+func (c *Container) PortEndpoint(ctx context.Context, port nat.Port, proto string) (string, error) {
+	host, err := c.Host(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	outerPort, err := c.MappedPort(ctx, port)
+	if err != nil {
+		return "", err
+	}
+
+	protoFull := ""
+	if proto != "" {
+		protoFull = fmt.Sprintf("%s://", proto)
+	}
+
+	return fmt.Sprintf("%s%s:%s", protoFull, host, outerPort.Port()), nil
+}
+
+func (c *Container) Host(context.Context) (string, error) {
+	return c.provider.Host, nil
+}
+
+func (c *Container) MappedPort(ctx context.Context, port nat.Port) (nat.Port, error) {
+	ports, err := c.Ports(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for k, p := range ports {
+		if k.Port() != port.Port() {
+			continue
+		}
+		if port.Proto() != "" && k.Proto() != port.Proto() {
+			continue
+		}
+		if len(p) == 0 {
+			continue
+		}
+		return nat.NewPort(k.Proto(), p[0].HostPort)
+	}
+
+	return "", errors.New("port not found")
+}
+
+func (c *Container) Ports(ctx context.Context) (nat.PortMap, error) {
+	inspect, err := c.provider.inspectContainer(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.NetworkSettings == nil {
+		return nat.PortMap{}, nil
+	}
+	return inspect.NetworkSettings.Ports, nil
+}
+
+func (c *Container) SessionID() string {
+	return testcontainers.SessionID()
+}
+
+func (c *Container) IsRunning() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.running
+}
+
+// Start starts the container and waits for it to be ready, if the request has a wait strategy
+func (c *Container) Start(ctx context.Context) error {
+	c.provider.logger.Printf("Starting container id: %s image: %s", c.shortID(), c.req.Image)
+
+	if _, err := c.provider.run(ctx, "start", c.ID); err != nil {
+		return err
+	}
+
+	if c.req.WaitingFor != nil {
+		c.provider.logger.Printf("Waiting for container id %s image: %s", c.shortID(), c.req.Image)
+		if err := c.req.WaitingFor.WaitUntilReady(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	c.provider.logger.Printf("Container is ready id: %s image: %s", c.shortID(), c.req.Image)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.running = true
+	return nil
+}
+
+func (c *Container) shortID() string {
+	if len(c.ID) > 12 {
+		return c.ID[:12]
+	}
+	return c.ID
+}
+
+// Stop stops the container, killing it after the timeout if it's not nil
+func (c *Container) Stop(ctx context.Context, timeout *time.Duration) error {
+	args := []string{"stop"}
+	if timeout != nil {
+		args = append(args, "--time", strconv.Itoa(int(timeout.Seconds())))
+	}
+
+	if _, err := c.provider.run(ctx, append(args, c.ID)...); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.running = false
+	return nil
+}
+
+// Terminate removes the container along with its anonymous volumes
+func (c *Container) Terminate(ctx context.Context) error {
+	_ = c.StopLogProducer()
+
+	if _, err := c.provider.run(ctx, "rm", "--force", "--volumes", c.ID); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.running = false
+	for _, dir := range c.tmpDirs {
+		_ = os.RemoveAll(dir)
+	}
+	c.tmpDirs = nil
+	return nil
+}
+
+// Logs returns the stdout and stderr of the container, interleaved like on a terminal
+func (c *Container) Logs(ctx context.Context) (io.ReadCloser, error) {
+	// both streams are written to the same pipe, so that they're interleaved in the order nerdctl wrote them
+	out, err := c.provider.command(ctx, "logs", c.ID).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%w: nerdctl logs: %s", err, strings.TrimSpace(string(out)))
+	}
+	return ioutil.NopCloser(bytes.NewReader(out)), nil
+}
+
+func (c *Container) FollowOutput(consumer testcontainers.LogConsumer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.consumers = append(c.consumers, consumer)
+}
+
+func (c *Container) UnfollowOutput(consumer testcontainers.LogConsumer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for i, existing := range c.consumers {
+		if existing == consumer {
+			c.consumers = append(c.consumers[:i], c.consumers[i+1:]...)
+			return
+		}
+	}
+}
+
+// StartLogProducer follows the logs of the container with `nerdctl logs --follow`, passing them to the consumers
+// line by line until StopLogProducer is called
+func (c *Container) StartLogProducer(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	cmd := c.provider.command(ctx, "logs", "--follow", c.ID)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return err
+	}
+
+	done := make(chan struct{})
+	c.mtx.Lock()
+	c.stopProducer = cancel
+	c.producerDone = done
+	c.mtx.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go c.produce(&wg, stdout, testcontainers.StdoutLog)
+	go c.produce(&wg, stderr, testcontainers.StderrLog)
+	go func() {
+		wg.Wait()
+		_ = cmd.Wait()
+		close(done)
+	}()
+
+	return nil
+}
+
+// produce passes the lines read from r to the consumers
+func (c *Container) produce(wg *sync.WaitGroup, r io.Reader, logType string) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := append(append([]byte(nil), scanner.Bytes()...), '\n')
+
+		c.mtx.Lock()
+		consumers := append([]testcontainers.LogConsumer(nil), c.consumers...)
+		c.mtx.Unlock()
+
+		for _, consumer := range consumers {
+			consumer.Accept(testcontainers.Log{LogType: logType, Content: line})
+		}
+	}
+}
+
+// StopLogProducer stops following the logs of the container
+func (c *Container) StopLogProducer() error {
+	c.mtx.Lock()
+	stop, done := c.stopProducer, c.producerDone
+	c.stopProducer, c.producerDone = nil, nil
+	c.mtx.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+	return nil
+}
+
+func (c *Container) Name(ctx context.Context) (string, error) {
+	inspect, err := c.provider.inspectContainer(ctx, c.ID)
+	if err != nil {
+		return "", err
+	}
+	return inspect.Name, nil
+}
+
+func (c *Container) State(ctx context.Context) (*types.ContainerState, error) {
+	inspect, err := c.provider.inspectContainer(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.State == nil {
+		return &types.ContainerState{}, nil
+	}
+	return inspect.State, nil
+}
+
+// Networks returns the networks of the request, as nerdctl reports the network interfaces of containers instead
+func (c *Container) Networks(context.Context) ([]string, error) {
+	if c.req.NetworkMode != "" {
+		return []string{string(c.req.NetworkMode)}, nil
+	}
+	return append([]string{}, c.req.Networks...), nil
+}
+
+// NetworkAliases returns the aliases of the request, nerdctl doesn't support network aliases though
+func (c *Container) NetworkAliases(context.Context) (map[string][]string, error) {
+	aliases := map[string][]string{}
+	for _, n := range c.req.Networks {
+		aliases[n] = c.req.NetworkAliases[n]
+	}
+	return aliases, nil
+}
+
+// Exec executes the command in the container, returning its exit code and combined output
+func (c *Container) Exec(ctx context.Context, cmd []string) (int, io.Reader, error) {
+	var output bytes.Buffer
+	execCmd := c.provider.command(ctx, append([]string{"exec", c.ID}, cmd...)...)
+	execCmd.Stdout = &output
+	execCmd.Stderr = &output
+
+	err := execCmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), &output, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return 0, &output, nil
+}
+
+func (c *Container) Schedule(ctx context.Context, interval time.Duration, cmd []string) (*testcontainers.ScheduledCommand, error) {
+	return testcontainers.ScheduleExec(ctx, c, interval, cmd)
+}
+
+func (c *Container) URL(ctx context.Context, scheme string, port nat.Port, path string) (*url.URL, error) {
+	return testcontainers.PortURL(ctx, c, scheme, port, path)
+}
+
+func (c *Container) HTTPEndpoint(ctx context.Context, port nat.Port, path string) (*url.URL, error) {
+	return c.URL(ctx, "http", port, path)
+}
+
+func (c *Container) ExtractFiles(ctx context.Context, tb testing.TB, spec map[string]string) (map[string]string, error) {
+	tb.Helper()
+	return testcontainers.ExtractFilesFrom(ctx, tb, c, spec)
+}
+
+// FS returns a snapshot of the file system of the container below root, copied when FS is called
+func (c *Container) FS(ctx context.Context, root string) fs.FS {
+	dir, err := ioutil.TempDir("", "testcontainers-nerdctl")
+	if err != nil {
+		return errFS{err: err}
+	}
+
+	c.mtx.Lock()
+	c.tmpDirs = append(c.tmpDirs, dir)
+	c.mtx.Unlock()
+
+	snapshot := filepath.Join(dir, "fs")
+	if _, err := c.provider.run(ctx, "cp", c.ID+":"+path.Clean("/"+root), snapshot); err != nil {
+		return errFS{err: err}
+	}
+	return os.DirFS(snapshot)
+}
+
+// errFS is a file system failing to open any file, returned if the snapshot of the file system failed
+type errFS struct {
+	err error
+}
+
+func (f errFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: f.err}
+}
+
+func (c *Container) ContainerIP(ctx context.Context) (string, error) {
+	ips, err := c.ContainerIPs(ctx)
+	if err != nil || len(ips) == 0 {
+		return "", err
+	}
+	return ips[0], nil
+}
+
+func (c *Container) ContainerIPs(ctx context.Context) ([]string, error) {
+	inspect, err := c.provider.inspectContainer(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.NetworkSettings == nil {
+		return nil, nil
+	}
+
+	var ips []string
+	if inspect.NetworkSettings.IPAddress != "" {
+		ips = append(ips, inspect.NetworkSettings.IPAddress)
+	}
+
+	names := make([]string, 0, len(inspect.NetworkSettings.Networks))
+	for name := range inspect.NetworkSettings.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ip := inspect.NetworkSettings.Networks[name].IPAddress
+		if ip != "" && (len(ips) == 0 || ips[0] != ip) {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, nil
+}
+
+// CopyToContainer copies the content to a file in the container, tar header transforms aren't supported
+func (c *Container) CopyToContainer(ctx context.Context, fileContent []byte, containerFilePath string, fileMode int64, transforms ...testcontainers.TarHeaderTransform) error {
+	if len(transforms) > 0 {
+		return errors.New("nerdctl doesn't support tar header transforms")
+	}
+
+	dir, err := ioutil.TempDir("", "testcontainers-nerdctl")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	hostFilePath := filepath.Join(dir, path.Base(containerFilePath))
+	if err := ioutil.WriteFile(hostFilePath, fileContent, fs.FileMode(fileMode)); err != nil {
+		return err
+	}
+	// the mode passed to WriteFile is subject to the umask
+	if err := os.Chmod(hostFilePath, fs.FileMode(fileMode)); err != nil {
+		return err
+	}
+
+	_, err = c.provider.run(ctx, "cp", hostFilePath, c.ID+":"+containerFilePath)
+	return err
+}
+
+// CopyDirToContainer copies the directory into the container, the mode of the files is kept
+func (c *Container) CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, _ int64, transforms ...testcontainers.TarHeaderTransform) error {
+	if len(transforms) > 0 {
+		return errors.New("nerdctl doesn't support tar header transforms")
+	}
+
+	_, err := c.provider.run(ctx, "cp", hostDirPath, c.ID+":"+containerParentPath)
+	return err
+}
+
+// CopyFileToContainer copies a file or directory from the host to the container
+func (c *Container) CopyFileToContainer(ctx context.Context, hostFilePath string, containerFilePath string, fileMode int64, transforms ...testcontainers.TarHeaderTransform) error {
+	info, err := os.Stat(hostFilePath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return c.CopyDirToContainer(ctx, hostFilePath, containerFilePath, fileMode, transforms...)
+	}
+
+	content, err := ioutil.ReadFile(hostFilePath)
+	if err != nil {
+		return err
+	}
+	return c.CopyToContainer(ctx, content, containerFilePath, fileMode, transforms...)
+}
+
+// CopyFileFromContainer copies the file from the container
+func (c *Container) CopyFileFromContainer(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	dir, err := ioutil.TempDir("", "testcontainers-nerdctl")
+	if err != nil {
+		return nil, err
+	}
+
+	hostFilePath := filepath.Join(dir, "file")
+	if _, err := c.provider.run(ctx, "cp", c.ID+":"+filePath, hostFilePath); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(hostFilePath)
+	os.RemoveAll(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
@@ -0,0 +1,486 @@
+// Package nerdctl provides an experimental provider running containers with nerdctl, the Docker-compatible CLI of
+// containerd, for environments like k3s nodes or Lima VMs which don't run dockerd. nerdctl talks to containerd
+// directly and sets up the networking of the containers with CNI.
+package nerdctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Implement interface
+var _ testcontainers.GenericProvider = (*Provider)(nil)
+
+var (
+	registerOnce sync.Once
+	providerType testcontainers.ProviderType
+)
+
+// ProviderType returns the provider type of the nerdctl provider, registering it with testcontainers.RegisterProvider
+// on first use. The provider is also detected for requests using the default provider type, if nerdctl is installed
+// and neither DOCKER_HOST nor the default Docker socket exist.
+func ProviderType() testcontainers.ProviderType {
+	registerOnce.Do(func() {
+		providerType = testcontainers.RegisterProvider("nerdctl", func(opts ...testcontainers.GenericProviderOption) (testcontainers.GenericProvider, error) {
+			return NewProvider(opts...)
+		}, detect)
+	})
+	return providerType
+}
+
+// detect reports whether nerdctl should be used instead of Docker
+func detect(context.Context) bool {
+	if os.Getenv("DOCKER_HOST") != "" {
+		return false
+	}
+	if _, err := os.Stat("/var/run/docker.sock"); err == nil {
+		return false
+	}
+	_, err := exec.LookPath("nerdctl")
+	return err == nil
+}
+
+// Provider runs containers with the nerdctl CLI. Its exported fields must be set before the provider is used.
+// Features relying on the Docker API aren't supported: the reaper, network aliases, tar header transforms,
+// VerifyPortsReachable and FailOnUnhealthy are ignored, and containers must be terminated by the tests.
+type Provider struct {
+	Binary    string // path of the nerdctl binary, defaults to nerdctl on the PATH
+	Address   string // address of containerd, nerdctl's default is used if empty
+	Namespace string // namespace of the containers in containerd, nerdctl's default is used if empty
+	Host      string // host the ports of containers are exposed on, defaults to TC_HOST or localhost
+
+	logger         testcontainers.Logging
+	defaultNetwork string
+}
+
+// NewProvider returns a provider using the nerdctl binary on the PATH
+func NewProvider(opts ...testcontainers.GenericProviderOption) (*Provider, error) {
+	o := &testcontainers.GenericProviderOptions{
+		Logger: testcontainers.Logger,
+	}
+	for _, opt := range opts {
+		opt.ApplyGenericTo(o)
+	}
+
+	binary, err := exec.LookPath("nerdctl")
+	if err != nil {
+		return nil, fmt.Errorf("%w: nerdctl is not installed", err)
+	}
+
+	host := os.Getenv("TC_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+
+	return &Provider{
+		Binary:         binary,
+		Host:           host,
+		logger:         o.Logger,
+		defaultNetwork: o.DefaultNetwork,
+	}, nil
+}
+
+// command returns the nerdctl command with the arguments
+func (p *Provider) command(ctx context.Context, args ...string) *exec.Cmd {
+	var global []string
+	if p.Address != "" {
+		global = append(global, "--address", p.Address)
+	}
+	if p.Namespace != "" {
+		global = append(global, "--namespace", p.Namespace)
+	}
+
+	binary := p.Binary
+	if binary == "" {
+		binary = "nerdctl"
+	}
+	return exec.CommandContext(ctx, binary, append(global, args...)...)
+}
+
+// run runs nerdctl and returns its stdout. Errors about missing containers or networks are reported as errdefs.NotFound.
+func (p *Provider) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := p.command(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		err = fmt.Errorf("%w: nerdctl %s: %s", err, strings.Join(args, " "), msg)
+		if strings.Contains(msg, "no such") || strings.Contains(msg, "not found") {
+			return nil, errdefs.NotFound(err)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateContainer creates a container without starting it
+func (p *Provider) CreateContainer(ctx context.Context, req testcontainers.ContainerRequest) (testcontainers.Container, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if req.ShouldBuildImage() {
+		tag, err := p.buildImage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		req.Image = tag
+	}
+
+	out, err := p.run(ctx, append([]string{"create"}, createArgs(req, p.defaultNetwork)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	c := &Container{
+		ID:       strings.TrimSpace(lines[len(lines)-1]),
+		provider: p,
+		req:      req,
+	}
+
+	for _, f := range req.Files {
+		err := c.CopyFileToContainer(ctx, f.HostFilePath, f.ContainerFilePath, f.FileMode)
+		if err != nil {
+			// the container isn't returned, so nothing else would remove it
+			_ = c.Terminate(ctx)
+			return nil, fmt.Errorf("can't copy %s to container: %w", f.HostFilePath, err)
+		}
+	}
+
+	return c, nil
+}
+
+// buildImage builds the image of the request from its Dockerfile, which requires BuildKit
+func (p *Provider) buildImage(ctx context.Context, req testcontainers.ContainerRequest) (string, error) {
+	if req.FromDockerfile.Context == "" {
+		return "", errors.New("nerdctl can only build images from a context directory")
+	}
+
+	tag := fmt.Sprintf("%s:%s", req.GetRepo(), req.GetTag())
+	// nerdctl resolves the Dockerfile against the working directory, unlike the Docker API against the context
+	args := []string{"build", "-t", tag, "-f", filepath.Join(req.FromDockerfile.Context, req.GetDockerfile())}
+
+	buildArgs := map[string]string{}
+	for k, v := range req.GetBuildArgs() {
+		if v != nil {
+			buildArgs[k] = *v
+		}
+	}
+	for _, k := range sortedKeys(buildArgs) {
+		args = append(args, "--build-arg", k+"="+buildArgs[k])
+	}
+	labels := map[string]string{}
+	for k, v := range req.GetBuildLabels() {
+		labels[k] = v
+	}
+	for k, v := range testcontainers.SessionLabels() {
+		labels[k] = v
+	}
+	for k, v := range testcontainers.StandardLabels() {
+		labels[k] = v
+	}
+	for _, k := range sortedKeys(labels) {
+		args = append(args, "--label", k+"="+labels[k])
+	}
+	if target := req.GetTarget(); target != "" {
+		args = append(args, "--target", target)
+	}
+	for _, image := range req.GetCacheFrom() {
+		args = append(args, "--cache-from", image)
+	}
+	if mode := req.GetNetworkMode(); mode != "" {
+		args = append(args, "--network", mode)
+	}
+	if platform := req.GetPlatform(); platform != "" {
+		args = append(args, "--platform", platform)
+	}
+
+	if _, err := p.run(ctx, append(args, req.FromDockerfile.Context)...); err != nil {
+		return "", err
+	}
+	return tag, nil
+}
+
+// createArgs returns the arguments of `nerdctl create` for the request
+func createArgs(req testcontainers.ContainerRequest, defaultNetwork string) []string {
+	var args []string
+	if req.Name != "" {
+		args = append(args, "--name", req.Name)
+	}
+	if req.Hostname != "" {
+		args = append(args, "--hostname", req.Hostname)
+	}
+	if req.Domainname != "" {
+		args = append(args, "--domainname", req.Domainname)
+	}
+	if req.MacAddress != "" {
+		args = append(args, "--mac-address", req.MacAddress)
+	}
+	if req.User != "" {
+		args = append(args, "--user", req.User)
+	}
+	if req.Privileged {
+		args = append(args, "--privileged")
+	}
+	if req.AutoRemove {
+		args = append(args, "--rm")
+	}
+	if req.AlwaysPullImage {
+		args = append(args, "--pull", "always")
+	}
+	if req.ImagePlatform != "" {
+		args = append(args, "--platform", req.ImagePlatform)
+	}
+	if req.ShmSize > 0 {
+		args = append(args, "--shm-size", strconv.FormatInt(req.ShmSize, 10))
+	}
+	if req.Resources.Memory > 0 {
+		args = append(args, "--memory", strconv.FormatInt(req.Resources.Memory, 10))
+	}
+	if req.Resources.NanoCPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(float64(req.Resources.NanoCPUs)/1e9, 'f', -1, 64))
+	}
+
+	for _, k := range sortedKeys(req.Env) {
+		args = append(args, "-e", k+"="+req.Env[k])
+	}
+
+	labels := testcontainers.SessionLabels()
+	for k, v := range testcontainers.StandardLabels() {
+		labels[k] = v
+	}
+	for k, v := range req.Labels {
+		labels[k] = v
+	}
+	for _, k := range sortedKeys(labels) {
+		args = append(args, "--label", k+"="+labels[k])
+	}
+
+	for _, port := range req.ExposedPorts {
+		args = append(args, "-p", port)
+	}
+
+	switch {
+	case req.NetworkMode != "":
+		args = append(args, "--network", string(req.NetworkMode))
+	case len(req.Networks) > 0:
+		for _, n := range req.Networks {
+			args = append(args, "--network", n)
+		}
+		// nerdctl only supports static addresses on a single network
+		if ipam := req.NetworkIPAM[req.Networks[0]]; ipam != nil {
+			if ipam.IPv4Address != "" {
+				args = append(args, "--ip", ipam.IPv4Address)
+			}
+			if ipam.IPv6Address != "" {
+				args = append(args, "--ip6", ipam.IPv6Address)
+			}
+		}
+	case defaultNetwork != "":
+		args = append(args, "--network", defaultNetwork)
+	}
+
+	for _, h := range req.ExtraHosts {
+		args = append(args, "--add-host", h)
+	}
+	for _, d := range req.DNS {
+		args = append(args, "--dns", d)
+	}
+	for _, d := range req.DNSSearch {
+		args = append(args, "--dns-search", d)
+	}
+	for _, o := range req.DNSOptions {
+		args = append(args, "--dns-option", o)
+	}
+	for _, c := range req.CapAdd {
+		args = append(args, "--cap-add", c)
+	}
+	for _, c := range req.CapDrop {
+		args = append(args, "--cap-drop", c)
+	}
+
+	for _, b := range req.Binds {
+		args = append(args, "-v", b)
+	}
+	for _, m := range req.Mounts {
+		switch m.Source.Type() {
+		case testcontainers.MountTypeBind, testcontainers.MountTypeVolume:
+			v := m.Source.Source() + ":" + m.Target.Target()
+			if m.ReadOnly {
+				v += ":ro"
+			}
+			args = append(args, "-v", v)
+		case testcontainers.MountTypeTmpfs:
+			args = append(args, "--tmpfs", m.Target.Target())
+		}
+	}
+	for _, k := range sortedKeys(req.Tmpfs) {
+		t := k
+		if req.Tmpfs[k] != "" {
+			t += ":" + req.Tmpfs[k]
+		}
+		args = append(args, "--tmpfs", t)
+	}
+
+	// like for the Docker CLI, the first element is the entrypoint and the others are prepended to the command
+	cmd := req.Cmd
+	if len(req.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", req.Entrypoint[0])
+		cmd = append(append([]string{}, req.Entrypoint[1:]...), req.Cmd...)
+	}
+
+	args = append(args, req.Image)
+	return append(args, cmd...)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ReuseOrCreateContainer returns the container with the name of the request, if it exists,
+// or creates a new container otherwise
+func (p *Provider) ReuseOrCreateContainer(ctx context.Context, req testcontainers.ContainerRequest) (testcontainers.Container, error) {
+	if req.Name != "" {
+		inspect, err := p.inspectContainer(ctx, req.Name)
+		if err == nil {
+			return &Container{ID: inspect.ID, provider: p, req: req, running: inspect.State != nil && inspect.State.Running}, nil
+		}
+		if !errdefs.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return p.CreateContainer(ctx, req)
+}
+
+// RunContainer creates a container and starts it
+func (p *Provider) RunContainer(ctx context.Context, req testcontainers.ContainerRequest) (testcontainers.Container, error) {
+	c, err := p.CreateContainer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Start(ctx); err != nil {
+		return c, fmt.Errorf("%w: could not start container", err)
+	}
+
+	return c, nil
+}
+
+// Health checks that nerdctl can talk to containerd
+func (p *Provider) Health(ctx context.Context) error {
+	_, err := p.run(ctx, "info")
+	return err
+}
+
+// Config returns an empty configuration, the properties of testcontainers only apply to Docker
+func (p *Provider) Config() testcontainers.TestContainersConfig {
+	return testcontainers.TestContainersConfig{}
+}
+
+// inspectContainer returns the Docker-compatible inspection of the container nerdctl reports
+func (p *Provider) inspectContainer(ctx context.Context, id string) (types.ContainerJSON, error) {
+	out, err := p.run(ctx, "container", "inspect", id)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+
+	var inspected []types.ContainerJSON
+	if err := json.Unmarshal(out, &inspected); err != nil {
+		return types.ContainerJSON{}, err
+	}
+	if len(inspected) == 0 {
+		return types.ContainerJSON{}, errdefs.NotFound(fmt.Errorf("no such container: %s", id))
+	}
+	return inspected[0], nil
+}
+
+// CreateNetwork creates a CNI network, internal networks aren't supported
+func (p *Provider) CreateNetwork(ctx context.Context, req testcontainers.NetworkRequest) (testcontainers.Network, error) {
+	if req.Internal {
+		return nil, errors.New("nerdctl doesn't support internal networks")
+	}
+	if req.ConfigOnly || req.ConfigFrom != "" {
+		return nil, errors.New("nerdctl doesn't support config-only networks")
+	}
+
+	args := []string{"network", "create"}
+	if req.Driver != "" {
+		args = append(args, "--driver", req.Driver)
+	}
+	if req.EnableIPv6 {
+		args = append(args, "--ipv6")
+	}
+	for _, k := range sortedKeys(req.Options) {
+		args = append(args, "--opt", k+"="+req.Options[k])
+	}
+
+	labels := testcontainers.SessionLabels()
+	for k, v := range testcontainers.StandardLabels() {
+		labels[k] = v
+	}
+	for k, v := range req.Labels {
+		labels[k] = v
+	}
+	for _, k := range sortedKeys(labels) {
+		args = append(args, "--label", k+"="+labels[k])
+	}
+
+	if _, err := p.run(ctx, append(args, req.Name)...); err != nil {
+		return nil, err
+	}
+	return &Network{Name: req.Name, provider: p}, nil
+}
+
+// GetNetwork returns the network with the name of the request
+func (p *Provider) GetNetwork(ctx context.Context, req testcontainers.NetworkRequest) (types.NetworkResource, error) {
+	out, err := p.run(ctx, "network", "inspect", req.Name)
+	if err != nil {
+		return types.NetworkResource{}, err
+	}
+
+	var inspected []types.NetworkResource
+	if err := json.Unmarshal(out, &inspected); err != nil {
+		return types.NetworkResource{}, err
+	}
+	if len(inspected) == 0 {
+		return types.NetworkResource{}, errdefs.NotFound(fmt.Errorf("network %s not found", req.Name))
+	}
+	return inspected[0], nil
+}
+
+// Network is a CNI network created by nerdctl
+type Network struct {
+	Name string
+
+	provider *Provider
+}
+
+// Remove removes the network
+func (n *Network) Remove(ctx context.Context) error {
+	_, err := n.provider.run(ctx, "network", "rm", n.Name)
+	return err
+}
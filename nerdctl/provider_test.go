@@ -0,0 +1,203 @@
+package nerdctl
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// fakeNerdctl is a shell script standing in for nerdctl, which records its arguments and answers with canned output
+const fakeNerdctl = `#!/bin/sh
+echo "$@" >> "$(dirname "$0")/calls"
+case "$*" in
+  "--namespace testing create"*) echo "0123456789abcdef0123" ;;
+  "--namespace testing container inspect 0123456789abcdef0123")
+    echo '[{"Id": "0123456789abcdef0123", "Name": "redis", "State": {"Status": "running", "Running": true},
+      "NetworkSettings": {"IPAddress": "10.4.0.2", "Ports": {"6379/tcp": [{"HostIp": "0.0.0.0", "HostPort": "49153"}]},
+      "Networks": {"unknown-eth0": {"IPAddress": "10.4.0.2"}}}}]' ;;
+  "--namespace testing exec 0123456789abcdef0123 false") echo "failed" >&2; exit 3 ;;
+  "--namespace testing logs 0123456789abcdef0123") echo "starting"; echo "warning" >&2; echo "ready" ;;
+  "--namespace testing network inspect missing") echo "network missing not found" >&2; exit 1 ;;
+esac
+`
+
+func fakeProvider(t *testing.T) (*Provider, func() []string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake nerdctl is a shell script")
+	}
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "nerdctl")
+	require.NoError(t, ioutil.WriteFile(binary, []byte(fakeNerdctl), 0o755))
+
+	p := &Provider{
+		Binary:    binary,
+		Namespace: "testing",
+		Host:      "localhost",
+		logger:    testcontainers.TestLogger(t),
+	}
+
+	calls := func() []string {
+		content, err := ioutil.ReadFile(filepath.Join(dir, "calls"))
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(content)), "\n")
+	}
+	return p, calls
+}
+
+func TestCreateArgs(t *testing.T) {
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:6",
+		Name:         "redis",
+		Entrypoint:   []string{"redis-server", "--appendonly"},
+		Cmd:          []string{"yes"},
+		Env:          map[string]string{"B": "2", "A": "1"},
+		Labels:       map[string]string{"app": "redis"},
+		ExposedPorts: []string{"6379/tcp"},
+		Networks:     []string{"backend"},
+		Mounts: testcontainers.Mounts(
+			testcontainers.BindMount("/data", "/data"),
+			testcontainers.ContainerMount{Source: testcontainers.GenericVolumeMountSource{Name: "cache"}, Target: "/cache", ReadOnly: true},
+		),
+		Resources: container.Resources{Memory: 64 * 1024 * 1024, NanoCPUs: 500000000},
+	}
+
+	args := strings.Join(createArgs(req, "default"), " ")
+
+	assert.True(t, strings.HasPrefix(args, "--name redis --memory 67108864 --cpus 0.5 -e A=1 -e B=2 --label app=redis "), args)
+	assert.Contains(t, args, "--label "+testcontainers.TestcontainerLabelSessionID+"="+testcontainers.SessionID())
+	assert.Contains(t, args, "--label "+testcontainers.TestcontainersLabelSessionID+"="+testcontainers.SessionID())
+	assert.True(t, strings.HasSuffix(args, " -p 6379/tcp --network backend -v /data:/data -v cache:/cache:ro --entrypoint redis-server redis:6 --appendonly yes"), args)
+
+	req.Domainname = "example.internal"
+	req.MacAddress = "02:42:0a:04:00:02"
+	assert.Contains(t, strings.Join(createArgs(req, "default"), " "), "--domainname example.internal --mac-address 02:42:0a:04:00:02 ")
+
+	req.DNS = []string{"10.4.0.53"}
+	req.DNSSearch = []string{"svc.cluster.local"}
+	req.DNSOptions = []string{"ndots:1"}
+	assert.Contains(t, strings.Join(createArgs(req, "default"), " "), "--dns 10.4.0.53 --dns-search svc.cluster.local --dns-option ndots:1 ")
+
+	req.NetworkIPAM = map[string]*network.EndpointIPAMConfig{"backend": {IPv4Address: "10.4.0.10"}}
+	assert.Contains(t, strings.Join(createArgs(req, "default"), " "), "--network backend --ip 10.4.0.10 ")
+
+	req.Networks = nil
+	assert.Contains(t, createArgs(req, "default"), "default", "the default network is used without networks in the request")
+}
+
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	p, calls := fakeProvider(t)
+
+	c, err := p.RunContainer(ctx, testcontainers.ContainerRequest{Image: "redis:6", ExposedPorts: []string{"6379/tcp"}})
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef0123", c.GetContainerID())
+	assert.True(t, c.IsRunning())
+
+	port, err := c.MappedPort(ctx, "6379")
+	require.NoError(t, err)
+	assert.Equal(t, nat.Port("49153/tcp"), port)
+
+	endpoint, err := c.Endpoint(ctx, "redis")
+	require.NoError(t, err)
+	assert.Equal(t, "redis://localhost:49153", endpoint)
+
+	ips, err := c.ContainerIPs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.4.0.2"}, ips)
+
+	state, err := c.State(ctx)
+	require.NoError(t, err)
+	assert.True(t, state.Running)
+
+	exitCode, output, err := c.Exec(ctx, []string{"false"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+	content, err := ioutil.ReadAll(output)
+	require.NoError(t, err)
+	assert.Equal(t, "failed\n", string(content))
+
+	require.NoError(t, c.Terminate(ctx))
+	assert.False(t, c.IsRunning())
+
+	recorded := calls()
+	assert.True(t, strings.HasPrefix(recorded[0], "--namespace testing create "), recorded[0])
+	assert.Equal(t, "--namespace testing start 0123456789abcdef0123", recorded[1])
+	assert.Equal(t, "--namespace testing rm --force --volumes 0123456789abcdef0123", recorded[len(recorded)-1])
+}
+
+func TestProviderLogs(t *testing.T) {
+	p, _ := fakeProvider(t)
+	c := &Container{ID: "0123456789abcdef0123", provider: p}
+
+	logs, err := c.Logs(context.Background())
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(logs)
+	require.NoError(t, err)
+	assert.Equal(t, "starting\nwarning\nready\n", string(content), "stdout and stderr must be interleaved")
+}
+
+func TestProviderBuildAndCopyFailure(t *testing.T) {
+	ctx := context.Background()
+	p, calls := fakeProvider(t)
+
+	_, err := p.CreateContainer(ctx, testcontainers.ContainerRequest{
+		FromDockerfile: testcontainers.FromDockerfile{
+			Context:    "./testresources",
+			Dockerfile: "echo.Dockerfile",
+			Repo:       "echo",
+			Tag:        "test",
+		},
+		Files: []testcontainers.ContainerFile{{HostFilePath: "./missing.conf", ContainerFilePath: "/etc/missing.conf"}},
+	})
+	assert.Error(t, err)
+
+	recorded := calls()
+	assert.True(t, strings.HasPrefix(recorded[0], "--namespace testing build -t echo:test -f "+filepath.Join("testresources", "echo.Dockerfile")+" "), recorded[0])
+	assert.True(t, strings.HasSuffix(recorded[0], " ./testresources"), recorded[0])
+	assert.Equal(t, "--namespace testing rm --force --volumes 0123456789abcdef0123", recorded[len(recorded)-1], "the container must be removed if copying a file fails")
+}
+
+func TestProviderNetworks(t *testing.T) {
+	ctx := context.Background()
+	p, calls := fakeProvider(t)
+
+	n, err := p.CreateNetwork(ctx, testcontainers.NetworkRequest{Name: "backend", Driver: "bridge"})
+	require.NoError(t, err)
+	require.NoError(t, n.Remove(ctx))
+
+	_, err = p.CreateNetwork(ctx, testcontainers.NetworkRequest{Name: "internal", Internal: true})
+	assert.Error(t, err, "internal networks aren't supported")
+
+	_, err = p.GetNetwork(ctx, testcontainers.NetworkRequest{Name: "missing"})
+	assert.True(t, errdefs.IsNotFound(err), "missing networks must be reported as not found: %v", err)
+
+	recorded := calls()
+	assert.True(t, strings.HasPrefix(recorded[0], "--namespace testing network create --driver bridge --label "), recorded[0])
+	assert.True(t, strings.HasSuffix(recorded[0], " backend"), recorded[0])
+	assert.Equal(t, "--namespace testing network rm backend", recorded[1])
+
+	_, err = p.CreateNetwork(ctx, testcontainers.NetworkRequest{
+		Name:    "l2",
+		Driver:  "macvlan",
+		Options: map[string]string{"parent": "eth0", "mtu": "1400"},
+	})
+	require.NoError(t, err)
+	recorded = calls()
+	assert.True(t, strings.HasPrefix(recorded[len(recorded)-1], "--namespace testing network create --driver macvlan --opt mtu=1400 --opt parent=eth0 --label "), recorded[len(recorded)-1])
+
+	_, err = p.CreateNetwork(ctx, testcontainers.NetworkRequest{Name: "l2-config", ConfigOnly: true})
+	assert.Error(t, err, "config-only networks aren't supported")
+}
@@ -0,0 +1,164 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// NetemHelperImage is the image used to shape the network of containers that don't ship the tc binary,
+// pinned to a release shipping iproute2 so the helper doesn't change between runs
+const NetemHelperImage = "docker.io/nicolaka/netshoot:v0.8"
+
+// NetemOptions describes the impairments applied to the network of a container using tc/netem
+type NetemOptions struct {
+	Interface string        // the network interface within the container, defaults to eth0
+	Delay     time.Duration // delay added to every outgoing packet
+	Jitter    time.Duration // random variation of the delay, requires Delay to be set
+	Loss      float64       // percentage of dropped outgoing packets, e.g. 2.5
+	Rate      string        // bandwidth limit in tc units, e.g. 1mbit
+}
+
+func (o NetemOptions) networkInterface() string {
+	if o.Interface == "" {
+		return "eth0"
+	}
+	return o.Interface
+}
+
+// args builds the tc arguments applying the impairments to the root qdisc of the interface
+func (o NetemOptions) args() ([]string, error) {
+	args := []string{"qdisc", "replace", "dev", o.networkInterface(), "root", "netem"}
+
+	if o.Jitter > 0 && o.Delay <= 0 {
+		return nil, errors.New("netem jitter requires a delay")
+	}
+	if o.Delay > 0 {
+		args = append(args, "delay", formatNetemDuration(o.Delay))
+		if o.Jitter > 0 {
+			args = append(args, formatNetemDuration(o.Jitter))
+		}
+	}
+
+	if o.Loss < 0 || o.Loss > 100 {
+		return nil, fmt.Errorf("netem loss must be a percentage between 0 and 100, got %g", o.Loss)
+	}
+	if o.Loss > 0 {
+		args = append(args, "loss", fmt.Sprintf("%g%%", o.Loss))
+	}
+
+	if o.Rate != "" {
+		args = append(args, "rate", o.Rate)
+	}
+
+	if len(args) == 6 {
+		return nil, errors.New("no network impairment specified")
+	}
+
+	return args, nil
+}
+
+func formatNetemDuration(d time.Duration) string {
+	return fmt.Sprintf("%dus", d.Microseconds())
+}
+
+// ShapeNetwork applies latency, packet loss and bandwidth limits to the network of the container using tc/netem.
+// The tc command is executed within the container if possible, which requires the tc binary and the NET_ADMIN capability.
+// Otherwise, a privileged helper container sharing the network namespace of the container applies the impairments.
+// Calling ShapeNetwork again replaces the previously applied impairments.
+func (c *DockerContainer) ShapeNetwork(ctx context.Context, opts NetemOptions) error {
+	args, err := opts.args()
+	if err != nil {
+		return err
+	}
+
+	if err := c.runTC(ctx, args); err != nil {
+		return err
+	}
+	c.netem = opts
+	return nil
+}
+
+// AddNetworkLatency delays every outgoing packet of the container by d, keeping the other impairments applied before
+func (c *DockerContainer) AddNetworkLatency(ctx context.Context, d time.Duration) error {
+	opts := c.netem
+	opts.Delay = d
+	return c.ShapeNetwork(ctx, opts)
+}
+
+// AddPacketLoss drops the percentage of outgoing packets of the container, e.g. 2.5, keeping the other impairments
+// applied before
+func (c *DockerContainer) AddPacketLoss(ctx context.Context, percent float64) error {
+	opts := c.netem
+	opts.Loss = percent
+	return c.ShapeNetwork(ctx, opts)
+}
+
+// ClearNetworkImpairments removes the impairments applied by ShapeNetwork, AddNetworkLatency and AddPacketLoss.
+// It does nothing if no impairments were applied.
+func (c *DockerContainer) ClearNetworkImpairments(ctx context.Context) error {
+	if c.netem == (NetemOptions{}) {
+		return nil
+	}
+
+	if err := c.runTC(ctx, []string{"qdisc", "del", "dev", c.netem.networkInterface(), "root"}); err != nil {
+		return err
+	}
+	c.netem = NetemOptions{}
+	return nil
+}
+
+// runTC runs the tc binary with the given arguments within the network namespace of the container
+func (c *DockerContainer) runTC(ctx context.Context, args []string) error {
+	exitCode, _, err := c.Exec(ctx, append([]string{"tc"}, args...))
+	if err == nil && exitCode == 0 {
+		return nil
+	}
+
+	c.logger.Printf("tc is not usable within container %s, falling back to helper container", shortContainerID(c.ID))
+
+	return c.runTCHelper(ctx, args)
+}
+
+// runTCHelper runs tc in a helper container joining the network namespace of the container
+func (c *DockerContainer) runTCHelper(ctx context.Context, args []string) error {
+	helper, err := c.provider.RunContainer(ctx, ContainerRequest{
+		Image:       NetemHelperImage,
+		Entrypoint:  []string{"tc"},
+		Cmd:         args,
+		NetworkMode: container.NetworkMode("container:" + c.ID),
+		CapAdd:      []string{"NET_ADMIN"},
+		WaitingFor:  wait.ForExit(),
+	})
+	if helper != nil {
+		defer func() {
+			_ = helper.Terminate(ctx)
+		}()
+	}
+	if err != nil {
+		return fmt.Errorf("%w: failed to run tc helper container", err)
+	}
+
+	state, err := helper.State(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state.ExitCode != 0 {
+		output := ""
+		if logs, err := helper.Logs(ctx); err == nil {
+			b, _ := ioutil.ReadAll(logs)
+			output = strings.TrimSpace(string(b))
+		}
+		return fmt.Errorf("tc %s exited with code %d: %s", strings.Join(args, " "), state.ExitCode, output)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package testcontainers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestNetemOptionsArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     NetemOptions
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "delay",
+			opts:     NetemOptions{Delay: 100 * time.Millisecond},
+			expected: []string{"qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100000us"},
+		},
+		{
+			name:     "delay with jitter on custom interface",
+			opts:     NetemOptions{Interface: "eth1", Delay: time.Second, Jitter: 10 * time.Millisecond},
+			expected: []string{"qdisc", "replace", "dev", "eth1", "root", "netem", "delay", "1000000us", "10000us"},
+		},
+		{
+			name:     "loss and rate",
+			opts:     NetemOptions{Loss: 2.5, Rate: "1mbit"},
+			expected: []string{"qdisc", "replace", "dev", "eth0", "root", "netem", "loss", "2.5%", "rate", "1mbit"},
+		},
+		{
+			name: "jitter without delay",
+			opts: NetemOptions{Jitter: time.Millisecond},
+			err:  true,
+		},
+		{
+			name: "invalid loss",
+			opts: NetemOptions{Loss: 101},
+			err:  true,
+		},
+		{
+			name: "no impairment",
+			opts: NetemOptions{},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := tt.opts.args()
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
+
+func TestDockerContainerShapeNetwork(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	// nginx:alpine doesn't ship tc, so the helper container is used
+	err = nginxC.(*DockerContainer).ShapeNetwork(ctx, NetemOptions{Delay: 200 * time.Millisecond})
+	require.NoError(t, err)
+
+	endpoint, err := nginxC.PortEndpoint(ctx, nginxDefaultPort, "http")
+	require.NoError(t, err)
+
+	start := time.Now()
+	resp, err := http.Get(endpoint)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestDockerContainerNetworkImpairmentsWithoutContainer(t *testing.T) {
+	ctx := context.Background()
+	c := &DockerContainer{}
+
+	assert.NoError(t, c.ClearNetworkImpairments(ctx), "clearing without impairments does nothing")
+	assert.EqualError(t, c.AddPacketLoss(ctx, 150), "netem loss must be a percentage between 0 and 100, got 150")
+	assert.Equal(t, NetemOptions{}, c.netem, "invalid impairments aren't recorded")
+}
+
+func TestShortContainerID(t *testing.T) {
+	assert.Equal(t, "0123456789ab", shortContainerID("0123456789abcdef0123"))
+	assert.Equal(t, "fake", shortContainerID("fake"))
+}
+
+func TestDockerContainerNetworkImpairments(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+	c := nginxC.(*DockerContainer)
+
+	endpoint, err := nginxC.PortEndpoint(ctx, nginxDefaultPort, "http")
+	require.NoError(t, err)
+	get := func() time.Duration {
+		start := time.Now()
+		resp, err := http.Get(endpoint)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return time.Since(start)
+	}
+
+	require.NoError(t, c.AddPacketLoss(ctx, 0.1))
+	require.NoError(t, c.AddNetworkLatency(ctx, 200*time.Millisecond))
+	assert.Equal(t, NetemOptions{Delay: 200 * time.Millisecond, Loss: 0.1}, c.netem, "the impairments are combined")
+	assert.GreaterOrEqual(t, get(), 200*time.Millisecond)
+
+	require.NoError(t, c.ClearNetworkImpairments(ctx))
+	assert.Less(t, get(), 200*time.Millisecond)
+}
@@ -2,9 +2,13 @@ package testcontainers
 
 import (
 	"context"
-	"github.com/docker/docker/api/types/network"
+	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
 )
 
 // NetworkProvider allows the creation of networks on an arbitrary system
@@ -38,7 +42,110 @@ type NetworkRequest struct {
 	Labels         map[string]string
 	Attachable     bool
 	IPAM           *network.IPAM
+	Options        map[string]string // driver specific options, e.g. parent and mtu of macvlan networks
+	Scope          string            // scope of the network, e.g. local or swarm
+	ConfigOnly     bool              // create a network holding only the configuration for networks created with ConfigFrom
+	ConfigFrom     string            // the config-only network to take the configuration from
 
 	SkipReaper  bool   // indicates whether we skip setting up a reaper for this
 	ReaperImage string //alternative reaper registry
 }
+
+// sessionNetworks are the names of the networks shared within the current session by daemon host and requested name
+var (
+	sessionNetworksMtx sync.Mutex
+	sessionNetworks    = map[string]string{}
+)
+
+// SessionNetworkName is the name of the network all containers are attached to if session.network.enabled is set
+// or the DockerProvider was created with WithSessionNetwork
+const SessionNetworkName = "testcontainers-session"
+
+// WithNetwork returns a ContainerCustomizer attaching containers created with GenericContainer to a network shared by
+// all containers customized with the same name in the current test session, optionally with network aliases so the
+// containers can reach each other by name. The network is created on the first call for the name and removed by the
+// reaper at the end of the session.
+func WithNetwork(ctx context.Context, name string, aliases ...string) (ContainerCustomizer, error) {
+	provider, err := NewDockerProvider()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new Docker Provider: %w", err)
+	}
+	defer provider.Close()
+
+	networkName, err := provider.sessionNetwork(ctx, name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return networkCustomizer(networkName, aliases), nil
+}
+
+// WithSessionNetwork attaches all containers created by the DockerProvider to a network shared within the test
+// session, so that they can reach each other by name without creating networks in each test. The network is created
+// with the first container and removed by the reaper at the end of the session. Containers with a NetworkMode aren't
+// attached to it.
+func WithSessionNetwork() DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.attachSessionNetwork = true
+	})
+}
+
+// sessionNetwork returns the name of the network shared within the session by the given name, creating it on first
+// use. Its name is suffixed with the session ID, so that concurrent sessions on the same daemon don't share it.
+// The network always carries the session labels, so it's removed by the reaper of the session even if the container
+// creating it skipped the reaper.
+func (p *DockerProvider) sessionNetwork(ctx context.Context, name string, skipReaper bool) (string, error) {
+	sessionNetworksMtx.Lock()
+	defer sessionNetworksMtx.Unlock()
+
+	key := p.host + "/" + name
+	if networkName, ok := sessionNetworks[key]; ok {
+		return networkName, nil
+	}
+
+	networkName := fmt.Sprintf("%s-%s", name, SessionID()[:8])
+	_, err := p.GetNetwork(ctx, NetworkRequest{Name: networkName})
+	if client.IsErrNotFound(err) {
+		_, err = p.CreateNetwork(ctx, NetworkRequest{
+			Name:           networkName,
+			CheckDuplicate: true,
+			Attachable:     true,
+			Labels:         SessionLabels(),
+			SkipReaper:     skipReaper,
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to create network %s", err, networkName)
+	}
+
+	sessionNetworks[key] = networkName
+	return networkName, nil
+}
+
+// networkCustomizer attaches containers to the network with the aliases
+func networkCustomizer(network string, aliases []string) ContainerCustomizer {
+	return CustomizeRequestOption(func(req *GenericContainerRequest) {
+		req.Networks = append(req.Networks, network)
+		if len(aliases) > 0 {
+			if req.NetworkAliases == nil {
+				req.NetworkAliases = map[string][]string{}
+			}
+			req.NetworkAliases[network] = append(req.NetworkAliases[network], aliases...)
+		}
+	})
+}
+
+// aliasesOfAllNetworks merges the aliases of the networks, ordered by network name
+func aliasesOfAllNetworks(networkAliases map[string][]string) []string {
+	networks := make([]string, 0, len(networkAliases))
+	for n := range networkAliases {
+		networks = append(networks, n)
+	}
+	sort.Strings(networks)
+
+	var aliases []string
+	for _, n := range networks {
+		aliases = append(aliases, networkAliases[n]...)
+	}
+	return aliases
+}
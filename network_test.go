@@ -2,12 +2,20 @@ package testcontainers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -162,3 +170,310 @@ func Test_MultipleContainersInTheNewNetwork(t *testing.T) {
 	fmt.Println(postgres.GetContainerID())
 	fmt.Println(rabbitmq.GetContainerID())
 }
+
+func TestDockerContainerNetworkConnection(t *testing.T) {
+	var requests []string
+	var aliases []string
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/networks/") {
+			return
+		}
+
+		var body types.NetworkConnect
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, r.URL.Path+" "+body.Container)
+		if body.EndpointConfig != nil {
+			aliases = append(aliases, body.EndpointConfig.Aliases...)
+		}
+
+		if strings.Contains(r.URL.Path, "/missing/") {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "network missing not found"}`))
+		}
+	})
+
+	c := &DockerContainer{ID: "0123456789ab", provider: provider}
+	ctx := context.Background()
+
+	require.NoError(t, c.DisconnectFromNetwork(ctx, "backend", true))
+	require.NoError(t, c.ConnectToNetwork(ctx, "backend", "db", "primary"))
+
+	err := c.ConnectToNetwork(ctx, "missing")
+	assert.True(t, errdefs.IsNotFound(err), "missing networks must be reported as not found: %v", err)
+
+	assert.Equal(t, []string{
+		"/v1.41/networks/backend/disconnect 0123456789ab",
+		"/v1.41/networks/backend/connect 0123456789ab",
+		"/v1.41/networks/missing/connect 0123456789ab",
+	}, requests)
+	assert.Equal(t, []string{"db", "primary"}, aliases)
+}
+
+func TestNetworkCustomizer(t *testing.T) {
+	req := GenericContainerRequest{ContainerRequest: ContainerRequest{
+		Networks:       []string{"backend"},
+		NetworkAliases: map[string][]string{"cluster": {"node"}},
+	}}
+
+	networkCustomizer("cluster", []string{"primary"}).Customize(&req)
+	networkCustomizer("frontend", nil).Customize(&req)
+
+	assert.Equal(t, []string{"backend", "cluster", "frontend"}, req.Networks)
+	assert.Equal(t, map[string][]string{"cluster": {"node", "primary"}}, req.NetworkAliases)
+}
+
+func TestWithNetwork(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := WithNetwork(ctx, "cluster", "server")
+	require.NoError(t, err)
+	probe, err := WithNetwork(ctx, "cluster")
+	require.NoError(t, err)
+
+	serverReq := GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:      nginxAlpineImage,
+			WaitingFor: wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	}
+	server.Customize(&serverReq)
+	nginxC, err := GenericContainer(ctx, serverReq)
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	probeReq := GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image: "docker.io/alpine",
+			Cmd:   []string{"sleep", "30"},
+		},
+		Started: true,
+	}
+	probe.Customize(&probeReq)
+	assert.Equal(t, serverReq.Networks, probeReq.Networks, "the network must be shared")
+	probeC, err := GenericContainer(ctx, probeReq)
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, probeC)
+
+	exitCode, _, err := probeC.Exec(ctx, []string{"wget", "-q", "-O", "/dev/null", "http://server"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+}
+
+func TestDockerNetworkInspection(t *testing.T) {
+	const networkID = "8c8a5e8e9f4d5f1b2f3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192"
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.41/networks/" + networkID, "/v1.41/networks/8c8a5e8e9f4d", "/v1.41/networks/backend":
+			_, _ = w.Write([]byte(`{"Name": "backend", "Id": "` + networkID + `", "Driver": "bridge",
+				"IPAM": {"Config": [{"Subnet": "172.28.0.0/16", "Gateway": "172.28.0.1"}]},
+				"Containers": {"0123456789ab": {"Name": "db", "IPv4Address": "172.28.0.2/16"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "network not found"}`))
+		}
+	})
+
+	ctx := context.Background()
+	n := &DockerNetwork{ID: networkID, Name: "backend", provider: provider}
+
+	resource, err := n.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []network.IPAMConfig{{Subnet: "172.28.0.0/16", Gateway: "172.28.0.1"}}, resource.IPAM.Config)
+
+	containers, err := n.ConnectedContainers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.EndpointResource{"0123456789ab": {Name: "db", IPv4Address: "172.28.0.2/16"}}, containers)
+
+	for _, id := range []string{networkID, "8c8a5e8e9f4d"} {
+		resource, err = provider.GetNetworkByID(ctx, id)
+		require.NoError(t, err, id)
+		assert.Equal(t, "backend", resource.Name)
+	}
+
+	for _, id := range []string{"backend", "ffffffffffff"} {
+		_, err = provider.GetNetworkByID(ctx, id)
+		assert.True(t, errdefs.IsNotFound(err), "%s must not be found: %v", id, err)
+	}
+}
+
+func TestSessionNetworkIsCreatedOnce(t *testing.T) {
+	var requests []string
+	var labels map[string]string
+	created := false
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/v1.41/networks/create":
+			created = true
+			var body types.NetworkCreateRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+				labels = body.Labels
+			}
+			_, _ = w.Write([]byte(`{"Id": "8c8a5e8e9f4d"}`))
+		case strings.HasPrefix(r.URL.Path, "/v1.41/networks/") && !created:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "network not found"}`))
+		default:
+			_, _ = w.Write([]byte(`{"Name": "bridge"}`))
+		}
+	}, DefaultNetwork(Bridge))
+
+	ctx := context.Background()
+	name := "cluster-" + t.Name()
+	expected := name + "-" + SessionID()[:8]
+
+	networkName, err := provider.sessionNetwork(ctx, name, true)
+	require.NoError(t, err)
+	assert.Equal(t, expected, networkName)
+
+	networkName, err = provider.sessionNetwork(ctx, name, true)
+	require.NoError(t, err)
+	assert.Equal(t, expected, networkName)
+
+	assert.Equal(t, []string{
+		"GET /v1.41/networks/" + expected,
+		"POST /v1.41/networks/create",
+	}, requests[len(requests)-2:], "the network is only looked up and created once")
+	for k, v := range SessionLabels() {
+		assert.Equal(t, v, labels[k], "the network must be removed with the session, even if it skipped the reaper")
+	}
+}
+
+func TestAliasesOfAllNetworks(t *testing.T) {
+	aliases := aliasesOfAllNetworks(map[string][]string{
+		"bridge": {"web", "www"},
+		"":       {"frontend"},
+	})
+	assert.Equal(t, []string{"frontend", "web", "www"}, aliases)
+}
+
+func TestNetworkAliasesOnDefaultBridge(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:          nginxAlpineImage,
+			NetworkAliases: map[string][]string{Bridge: {"web"}},
+			WaitingFor:     wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	networks, err := nginxC.Networks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{SessionNetworkName + "-" + SessionID()[:8]}, networks)
+
+	probeC, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:          "docker.io/alpine",
+			Cmd:            []string{"sleep", "30"},
+			NetworkAliases: map[string][]string{Bridge: {"probe"}},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, probeC)
+
+	exitCode, _, err := probeC.Exec(ctx, []string{"wget", "-q", "-O", "/dev/null", "http://web"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+}
+
+func TestNetworkRequestDriverOptions(t *testing.T) {
+	var created []types.NetworkCreateRequest
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1.41/networks/create" {
+			var body types.NetworkCreateRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			created = append(created, body)
+		}
+		_, _ = w.Write([]byte(`{"Id": "8c8a5e8e9f4d"}`))
+	}, DefaultNetwork(Bridge))
+
+	ctx := context.Background()
+	_, err := provider.CreateNetwork(ctx, NetworkRequest{
+		Name:       "l2-config",
+		Options:    map[string]string{"parent": "eth0", "mtu": "1400"},
+		Scope:      "local",
+		ConfigOnly: true,
+		SkipReaper: true,
+	})
+	require.NoError(t, err)
+	_, err = provider.CreateNetwork(ctx, NetworkRequest{
+		Name:       "l2",
+		Driver:     "macvlan",
+		ConfigFrom: "l2-config",
+		SkipReaper: true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, created, 2)
+	assert.Equal(t, map[string]string{"parent": "eth0", "mtu": "1400"}, created[0].Options)
+	assert.Equal(t, "local", created[0].Scope)
+	assert.True(t, created[0].ConfigOnly)
+	assert.Nil(t, created[0].ConfigFrom)
+	assert.Equal(t, &network.ConfigReference{Network: "l2-config"}, created[1].ConfigFrom)
+}
+
+func TestNetworkAliasesOnUserDefinedDefaultNetwork(t *testing.T) {
+	var requests []string
+	var endpoints map[string]*network.EndpointSettings
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v1.41/containers/create":
+			var body struct {
+				NetworkingConfig network.NetworkingConfig
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+				endpoints = body.NetworkingConfig.EndpointsConfig
+			}
+			_, _ = w.Write([]byte(`{"Id": "0123456789ab"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}, DefaultNetwork("custom"))
+
+	_, err := provider.CreateContainer(context.Background(), ContainerRequest{
+		Image:          nginxAlpineImage,
+		ExposedPorts:   []string{nginxDefaultPort},
+		NetworkAliases: map[string][]string{"custom": {"web"}},
+		SkipReaper:     true,
+	})
+	require.NoError(t, err)
+
+	assert.NotContains(t, requests, "POST /v1.41/networks/create", "no session network is created")
+	require.Contains(t, endpoints, "custom")
+	assert.Equal(t, []string{"web"}, endpoints["custom"].Aliases)
+}
+
+func TestDefaultNetworkIsLookedUpOnce(t *testing.T) {
+	var lookups int32
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1.41/networks" {
+			atomic.AddInt32(&lookups, 1)
+			_, _ = w.Write([]byte(`[{"Name": "bridge"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}, WithDefaultBridgeNetwork(Bridge))
+
+	const callers = 10
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			name, err := provider.defaultNetwork(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, Bridge, name)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+	assert.Equal(t, Bridge, provider.DefaultNetwork)
+}
@@ -0,0 +1,222 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultPoolSize = 1
+)
+
+var (
+	ErrPoolClosed    = errors.New("container pool is closed")
+	ErrPoolReuse     = errors.New("container pool can't reuse containers by name")
+	ErrPoolNotLeased = errors.New("container wasn't leased from the pool")
+)
+
+// ContainerPoolOptions represents additional options for a ContainerPool
+type ContainerPoolOptions struct {
+	Size int // count of containers kept ready. If field empty(zero), default value will be 'defaultPoolSize'
+	// Reset re-initializes the state of a released container, so it can be leased again, e.g. by truncating tables.
+	// If it is nil or fails, released containers are terminated and replaced by a freshly started one.
+	Reset func(ctx context.Context, c Container) error
+}
+
+type poolEntry struct {
+	container Container
+	err       error
+}
+
+// ContainerPool keeps started containers of the same request ready in the background and hands them out on demand,
+// cutting the startup latency of heavyweight containers from the tests using them.
+// Every leased container must be handed back with Release.
+type ContainerPool struct {
+	req     GenericContainerRequest
+	opt     ContainerPoolOptions
+	entries chan poolEntry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{} // closed by Close, wakes the goroutines waiting for entries or room for them
+	wg      sync.WaitGroup
+	mtx     sync.Mutex
+	closed  bool
+	leased  map[Container]struct{}
+}
+
+// NewContainerPool creates a pool and starts its containers in the background
+func NewContainerPool(req GenericContainerRequest, opt ContainerPoolOptions) (*ContainerPool, error) {
+	if req.Reuse {
+		return nil, ErrPoolReuse
+	}
+	if opt.Size == 0 {
+		opt.Size = defaultPoolSize
+	}
+	if opt.Size < 0 {
+		return nil, fmt.Errorf("container pool size must be positive, got %d", opt.Size)
+	}
+
+	req.Started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &ContainerPool{
+		req: req,
+		opt: opt,
+		// containers are either starting, ready or leased, so there are never more than Size entries
+		entries: make(chan poolEntry, opt.Size),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		leased:  map[Container]struct{}{},
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for i := 0; i < opt.Size; i++ {
+		p.start()
+	}
+
+	return p, nil
+}
+
+// start starts a container in the background, the caller must hold the lock
+func (p *ContainerPool) start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		c, err := GenericContainer(p.ctx, p.req)
+		if err != nil && c != nil {
+			_ = c.Terminate(context.Background())
+			c = nil
+		}
+
+		p.put(poolEntry{container: c, err: err})
+	}()
+}
+
+// put adds the entry to the pool, or terminates its container if the pool is closed in the meantime
+func (p *ContainerPool) put(entry poolEntry) {
+	select {
+	case p.entries <- entry:
+	case <-p.done:
+		if entry.container != nil {
+			_ = entry.container.Terminate(context.Background())
+		}
+	}
+}
+
+// Lease hands out a ready container, waiting for one if all of them are starting or leased
+func (p *ContainerPool) Lease(ctx context.Context) (Container, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.done:
+		return nil, ErrPoolClosed
+	case entry, ok := <-p.entries:
+		if !ok {
+			return nil, ErrPoolClosed
+		}
+
+		p.mtx.Lock()
+		closed := p.closed
+		if !closed && entry.err != nil {
+			p.start()
+		} else if !closed {
+			p.leased[entry.container] = struct{}{}
+		}
+		p.mtx.Unlock()
+
+		if closed {
+			// the pool was closed while the entry was received, so Close doesn't terminate its container
+			if entry.container != nil {
+				_ = entry.container.Terminate(ctx)
+			}
+			return nil, ErrPoolClosed
+		}
+
+		if entry.err != nil {
+			return nil, fmt.Errorf("%w: failed to start pooled container", entry.err)
+		}
+
+		return entry.container, nil
+	}
+}
+
+// Release hands a leased container back to the pool. The container is reset to be leased again,
+// or terminated and replaced if it can't be reset. After the pool was closed, the container is terminated.
+// Containers which weren't leased from the pool, or were released already, are rejected with ErrPoolNotLeased.
+func (p *ContainerPool) Release(ctx context.Context, c Container) error {
+	p.mtx.Lock()
+	_, ok := p.leased[c]
+	delete(p.leased, c)
+	p.mtx.Unlock()
+	if !ok {
+		return ErrPoolNotLeased
+	}
+
+	var resetErr error
+	if p.opt.Reset != nil {
+		resetErr = p.opt.Reset(ctx, c)
+	}
+
+	p.mtx.Lock()
+	closed := p.closed
+	reuse := !closed && p.opt.Reset != nil && resetErr == nil
+	if reuse {
+		// the container was leased, so there's room for it
+		p.entries <- poolEntry{container: c}
+	} else if !closed {
+		p.start()
+	}
+	p.mtx.Unlock()
+
+	if reuse {
+		return nil
+	}
+
+	if err := c.Terminate(ctx); err != nil {
+		return err
+	}
+
+	if resetErr != nil && !closed {
+		return fmt.Errorf("%w: failed to reset pooled container", resetErr)
+	}
+
+	return nil
+}
+
+// Close stops starting containers and terminates all ready ones.
+// Containers leased at the time are terminated once they are released.
+func (p *ContainerPool) Close(ctx context.Context) error {
+	p.mtx.Lock()
+	if p.closed {
+		p.mtx.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	p.mtx.Unlock()
+
+	p.cancel()
+	p.wg.Wait()
+	close(p.entries)
+
+	var errs []error
+	for entry := range p.entries {
+		if entry.container == nil {
+			continue
+		}
+		if err := entry.container.Terminate(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to terminate %d pooled containers: %v", len(errs), errs)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestNewContainerPoolInvalidOptions(t *testing.T) {
+	_, err := NewContainerPool(GenericContainerRequest{Reuse: true}, ContainerPoolOptions{})
+	assert.ErrorIs(t, err, ErrPoolReuse)
+
+	_, err = NewContainerPool(GenericContainerRequest{}, ContainerPoolOptions{Size: -1})
+	assert.Error(t, err)
+}
+
+// newTestContainerPool returns a pool of the given size which doesn't start any containers
+func newTestContainerPool(size int, opt ContainerPoolOptions) *ContainerPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ContainerPool{
+		opt:     opt,
+		entries: make(chan poolEntry, size),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		leased:  map[Container]struct{}{},
+	}
+}
+
+func TestContainerPoolReleaseRejectsForeignContainers(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestContainerPool(1, ContainerPoolOptions{Reset: func(ctx context.Context, c Container) error { return nil }})
+	pooled := &DockerContainer{ID: "pooled"}
+	pool.entries <- poolEntry{container: pooled}
+
+	assert.ErrorIs(t, pool.Release(ctx, &DockerContainer{ID: "0123456789ab"}), ErrPoolNotLeased)
+
+	c, err := pool.Lease(ctx)
+	require.NoError(t, err)
+	assert.Same(t, pooled, c)
+
+	require.NoError(t, pool.Release(ctx, c))
+	assert.ErrorIs(t, pool.Release(ctx, c), ErrPoolNotLeased, "a container can't be released twice")
+}
+
+func TestContainerPoolCloseWakesWaiters(t *testing.T) {
+	removed := make(chan string, 1)
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			removed <- r.URL.Path
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx := context.Background()
+
+	// a lease waiting for a container
+	empty := newTestContainerPool(1, ContainerPoolOptions{})
+	leased := make(chan error, 1)
+	go func() {
+		_, err := empty.Lease(ctx)
+		leased <- err
+	}()
+
+	require.NoError(t, empty.Close(ctx))
+	select {
+	case err := <-leased:
+		assert.ErrorIs(t, err, ErrPoolClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("leasing from the closed pool blocked")
+	}
+
+	// a container started while the pool is full of ready ones
+	full := newTestContainerPool(1, ContainerPoolOptions{})
+	full.entries <- poolEntry{}
+	full.wg.Add(1)
+	go func() {
+		defer full.wg.Done()
+		full.put(poolEntry{container: &DockerContainer{ID: "0123456789ab", provider: provider}})
+	}()
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- full.Close(ctx)
+	}()
+
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the pool blocked")
+	}
+	assert.Equal(t, "/v1.41/containers/0123456789ab", <-removed, "the started container must be terminated")
+}
+
+func TestContainerPool(t *testing.T) {
+	ctx := context.Background()
+
+	failingID := ""
+	pool, err := NewContainerPool(GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+	}, ContainerPoolOptions{
+		Size: 2,
+		Reset: func(ctx context.Context, c Container) error {
+			if c.GetContainerID() == failingID {
+				return errors.New("reset failed")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Close(ctx))
+	})
+
+	leaseCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	first, err := pool.Lease(leaseCtx)
+	require.NoError(t, err)
+	second, err := pool.Lease(leaseCtx)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.GetContainerID(), second.GetContainerID())
+
+	state, err := first.State(ctx)
+	require.NoError(t, err)
+	assert.True(t, state.Running)
+
+	// all containers are leased
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	_, err = pool.Lease(shortCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the container was reset, so it's leased again
+	require.NoError(t, pool.Release(ctx, first))
+	leased, err := pool.Lease(leaseCtx)
+	require.NoError(t, err)
+	assert.Equal(t, first.GetContainerID(), leased.GetContainerID())
+
+	// the container couldn't be reset, so it's replaced
+	failingID = second.GetContainerID()
+	assert.Error(t, pool.Release(ctx, second))
+	replaced, err := pool.Lease(leaseCtx)
+	require.NoError(t, err)
+	assert.NotEqual(t, second.GetContainerID(), replaced.GetContainerID())
+
+	require.NoError(t, pool.Release(ctx, leased))
+	require.NoError(t, pool.Close(ctx))
+
+	// leased containers are terminated on release after the pool was closed
+	require.NoError(t, pool.Release(ctx, replaced))
+
+	_, err = pool.Lease(ctx)
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
@@ -0,0 +1,62 @@
+package testcontainers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+)
+
+const (
+	// portReachabilityTimeout limits how long the mapped ports are dialed until they are reachable
+	portReachabilityTimeout = 30 * time.Second
+	// portReachabilityInterval is the time between two attempts to dial an unreachable port
+	portReachabilityInterval = 100 * time.Millisecond
+)
+
+// verifyPortsReachable checks that the mapped TCP ports of the container can be dialed from the test host
+func (c *DockerContainer) verifyPortsReachable(ctx context.Context) error {
+	host, err := c.Host(ctx)
+	if err != nil {
+		return err
+	}
+
+	ports, err := c.Ports(ctx)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	return waitForPortsReachable(ctx, host, ports, dialer.DialContext)
+}
+
+// waitForPortsReachable dials the first host binding of all TCP ports until each one accepts a connection
+func waitForPortsReachable(ctx context.Context, host string, ports nat.PortMap, dial func(ctx context.Context, network, address string) (net.Conn, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, portReachabilityTimeout)
+	defer cancel()
+
+	for port, bindings := range ports {
+		if port.Proto() != "tcp" || len(bindings) == 0 {
+			continue
+		}
+
+		address := net.JoinHostPort(host, bindings[0].HostPort)
+		for {
+			conn, err := dial(ctx, "tcp", address)
+			if err == nil {
+				conn.Close()
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("port %s mapped to %s is not reachable: %w", port, address, err)
+			case <-time.After(portReachabilityInterval):
+			}
+		}
+	}
+
+	return nil
+}
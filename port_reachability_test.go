@@ -0,0 +1,50 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForPortsReachable(t *testing.T) {
+	ports := nat.PortMap{
+		"80/tcp":  []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "49153"}},
+		"53/udp":  []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "49154"}},
+		"443/tcp": nil,
+	}
+
+	t.Run("retries until reachable", func(t *testing.T) {
+		var dialed []string
+		dial := func(_ context.Context, network, address string) (net.Conn, error) {
+			dialed = append(dialed, network+"://"+address)
+			if len(dialed) < 3 {
+				return nil, errors.New("connection refused")
+			}
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		}
+
+		err := waitForPortsReachable(context.Background(), "localhost", ports, dial)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tcp://localhost:49153", "tcp://localhost:49153", "tcp://localhost:49153"}, dialed)
+	})
+
+	t.Run("fails if unreachable", func(t *testing.T) {
+		dial := func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		err := waitForPortsReachable(ctx, "localhost", ports, dial)
+		assert.ErrorContains(t, err, "port 80/tcp mapped to localhost:49153 is not reachable: connection refused")
+	})
+}
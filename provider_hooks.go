@@ -0,0 +1,113 @@
+package testcontainers
+
+import (
+	"context"
+)
+
+// RequestHook is called before the DockerProvider creates a container. It can mutate the request, e.g. to add
+// mandatory labels, rewrite images to an internal registry or apply a seccomp profile. An error aborts the creation.
+type RequestHook func(ctx context.Context, req *ContainerRequest) error
+
+// ContainerHook is called before an operation on a container, an error aborts the operation
+type ContainerHook func(ctx context.Context, c Container) error
+
+// ResultHook observes the outcome of an operation on a container. The container is nil if creating it failed.
+type ResultHook func(ctx context.Context, c Container, err error)
+
+// providerHooks are the hooks registered on a DockerProvider, which are called in the order of registration
+type providerHooks struct {
+	create     []RequestHook
+	created    []ResultHook
+	start      []ContainerHook
+	started    []ResultHook
+	terminate  []ContainerHook
+	terminated []ResultHook
+}
+
+// WithCreateHook registers a hook called before each container is created, which can mutate the request
+func WithCreateHook(hook RequestHook) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.hooks.create = append(opts.hooks.create, hook)
+	})
+}
+
+// WithCreatedHook registers a hook called after each attempt to create a container
+func WithCreatedHook(hook ResultHook) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.hooks.created = append(opts.hooks.created, hook)
+	})
+}
+
+// WithStartHook registers a hook called before each container is started
+func WithStartHook(hook ContainerHook) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.hooks.start = append(opts.hooks.start, hook)
+	})
+}
+
+// WithStartedHook registers a hook called after each attempt to start a container, including waiting for it
+func WithStartedHook(hook ResultHook) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.hooks.started = append(opts.hooks.started, hook)
+	})
+}
+
+// WithTerminateHook registers a hook called before each container is terminated
+func WithTerminateHook(hook ContainerHook) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.hooks.terminate = append(opts.hooks.terminate, hook)
+	})
+}
+
+// WithTerminatedHook registers a hook called after each attempt to terminate a container
+func WithTerminatedHook(hook ResultHook) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.hooks.terminated = append(opts.hooks.terminated, hook)
+	})
+}
+
+func (h *providerHooks) beforeCreate(ctx context.Context, req *ContainerRequest) error {
+	for _, hook := range h.create {
+		if err := hook(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *providerHooks) afterCreate(ctx context.Context, c Container, err error) {
+	observe(ctx, h.created, c, err)
+}
+
+func (h *providerHooks) beforeStart(ctx context.Context, c Container) error {
+	return intercept(ctx, h.start, c)
+}
+
+func (h *providerHooks) afterStart(ctx context.Context, c Container, err error) {
+	observe(ctx, h.started, c, err)
+}
+
+func (h *providerHooks) beforeTerminate(ctx context.Context, c Container) error {
+	return intercept(ctx, h.terminate, c)
+}
+
+func (h *providerHooks) afterTerminate(ctx context.Context, c Container, err error) {
+	observe(ctx, h.terminated, c, err)
+}
+
+// intercept calls the hooks until one of them fails
+func intercept(ctx context.Context, hooks []ContainerHook, c Container) error {
+	for _, hook := range hooks {
+		if err := hook(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observe calls all hooks with the outcome of the operation
+func observe(ctx context.Context, hooks []ResultHook, c Container, err error) {
+	for _, hook := range hooks {
+		hook(ctx, c, err)
+	}
+}
@@ -0,0 +1,121 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderHooks(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+
+	opts := &DockerProviderOptions{}
+	for _, opt := range []DockerProviderOption{
+		WithCreateHook(func(_ context.Context, req *ContainerRequest) error {
+			calls = append(calls, "create")
+			req.Labels = map[string]string{"team": "payments"}
+			return nil
+		}),
+		WithCreateHook(func(_ context.Context, req *ContainerRequest) error {
+			calls = append(calls, "create "+req.Labels["team"])
+			return nil
+		}),
+		WithStartHook(func(context.Context, Container) error {
+			calls = append(calls, "start")
+			return errors.New("not allowed")
+		}),
+		WithStartHook(func(context.Context, Container) error {
+			calls = append(calls, "start again")
+			return nil
+		}),
+		WithStartedHook(func(_ context.Context, _ Container, err error) {
+			calls = append(calls, "started: "+err.Error())
+		}),
+		WithStartedHook(func(_ context.Context, _ Container, err error) {
+			calls = append(calls, "started again")
+		}),
+	} {
+		opt.ApplyDockerTo(opts)
+	}
+
+	req := &ContainerRequest{}
+	require.NoError(t, opts.hooks.beforeCreate(ctx, req))
+	assert.Equal(t, "payments", req.Labels["team"])
+
+	assert.EqualError(t, opts.hooks.beforeStart(ctx, nil), "not allowed", "the first failing hook aborts the operation")
+	opts.hooks.afterStart(ctx, nil, errors.New("failed"))
+
+	assert.Equal(t, []string{"create", "create payments", "start", "started: failed", "started again"}, calls)
+}
+
+func TestWithCreateHookAbortsCreation(t *testing.T) {
+	server, paths := fakeDaemon(t)
+
+	var observed error
+	provider, err := NewDockerProvider(
+		WithDockerClient(fakeDaemonClient(t, server, "1.41")),
+		WithLogger(TestLogger(t)),
+		WithCreateHook(func(_ context.Context, req *ContainerRequest) error {
+			if req.Labels["team"] == "" {
+				return errors.New("containers must be labeled with their team")
+			}
+			return nil
+		}),
+		WithCreatedHook(func(_ context.Context, c Container, err error) {
+			assert.Nil(t, c)
+			observed = err
+		}),
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	requests := len(*paths)
+	_, err = provider.CreateContainer(context.Background(), ContainerRequest{Image: "docker.io/nginx:alpine"})
+	assert.EqualError(t, err, "containers must be labeled with their team")
+	assert.Nil(t, observed, "the created hooks aren't called if the creation is aborted")
+	assert.Len(t, *paths, requests, "the daemon must not be called")
+}
+
+func TestProviderHooksWithContainer(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+
+	provider, err := NewDockerProvider(
+		WithLogger(TestLogger(t)),
+		WithCreateHook(func(_ context.Context, req *ContainerRequest) error {
+			req.Labels["team"] = "payments"
+			return nil
+		}),
+		WithCreatedHook(func(_ context.Context, _ Container, err error) {
+			require.NoError(t, err)
+			calls = append(calls, "created")
+		}),
+		WithStartedHook(func(_ context.Context, _ Container, err error) {
+			require.NoError(t, err)
+			calls = append(calls, "started")
+		}),
+		WithTerminatedHook(func(_ context.Context, _ Container, err error) {
+			require.NoError(t, err)
+			calls = append(calls, "terminated")
+		}),
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	c, err := provider.RunContainer(ctx, ContainerRequest{
+		Image:  nginxAlpineImage,
+		Labels: map[string]string{},
+	})
+	require.NoError(t, err)
+
+	inspect, err := c.(*DockerContainer).inspectContainer(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "payments", inspect.Config.Labels["team"])
+
+	require.NoError(t, c.Terminate(ctx))
+	assert.Equal(t, []string{"created", "started", "terminated"}, calls)
+}
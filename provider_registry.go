@@ -0,0 +1,86 @@
+package testcontainers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ProviderFactory creates a provider registered with RegisterProvider
+type ProviderFactory func(opts ...GenericProviderOption) (GenericProvider, error)
+
+// ProviderDetector reports whether a registered provider is usable in the current environment,
+// e.g. by checking for environment variables or probing its endpoint. It should return quickly,
+// as it is called whenever a provider is requested without an explicit provider type.
+type ProviderDetector func(ctx context.Context) bool
+
+type registeredProvider struct {
+	name     string
+	factory  ProviderFactory
+	detector ProviderDetector
+}
+
+var (
+	providersMtx sync.RWMutex
+	providers    []registeredProvider
+)
+
+// RegisterProvider makes a provider available under the returned ProviderType, so downstream projects can plug in
+// their own providers, e.g. for a corporate container farm. If the detector is not nil, the provider takes part
+// in the detection chain: requests using the default ProviderType get the first registered provider whose detector
+// reports it usable, in the order of registration, before falling back to Docker.
+// RegisterProvider panics if the name is already registered or the factory is nil, like sql.Register.
+func RegisterProvider(name string, factory ProviderFactory, detector ProviderDetector) ProviderType {
+	if factory == nil {
+		panic("testcontainers: RegisterProvider factory is nil")
+	}
+
+	providersMtx.Lock()
+	defer providersMtx.Unlock()
+
+	if name == "docker" || name == "podman" {
+		panic("testcontainers: RegisterProvider called for built-in provider " + name)
+	}
+	for _, p := range providers {
+		if p.name == name {
+			panic("testcontainers: RegisterProvider called twice for provider " + name)
+		}
+	}
+
+	providers = append(providers, registeredProvider{name: name, factory: factory, detector: detector})
+	return ProviderPodman + ProviderType(len(providers))
+}
+
+// registeredProviderOf returns the registered provider of the provider type
+func registeredProviderOf(t ProviderType) (registeredProvider, bool) {
+	providersMtx.RLock()
+	defer providersMtx.RUnlock()
+
+	idx := int(t - ProviderPodman - 1)
+	if idx < 0 || idx >= len(providers) {
+		return registeredProvider{}, false
+	}
+	return providers[idx], true
+}
+
+// detectProvider returns the first registered provider which is detected to be usable
+func detectProvider(ctx context.Context) (registeredProvider, bool) {
+	providersMtx.RLock()
+	candidates := append([]registeredProvider(nil), providers...)
+	providersMtx.RUnlock()
+
+	for _, p := range candidates {
+		if p.detector != nil && p.detector(ctx) {
+			return p, true
+		}
+	}
+	return registeredProvider{}, false
+}
+
+func (p registeredProvider) newProvider(opts ...GenericProviderOption) (GenericProvider, error) {
+	provider, err := p.factory(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w, failed to create %s provider", err, p.name)
+	}
+	return provider, nil
+}
@@ -0,0 +1,126 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFakeProvider = errors.New("created by fake provider")
+
+// fakeProvider is a provider which fails creating containers, to tell which provider was used
+type fakeProvider struct {
+	GenericProvider
+	name string
+}
+
+func (p *fakeProvider) CreateContainer(context.Context, ContainerRequest) (Container, error) {
+	return nil, errFakeProvider
+}
+
+// restoreProviders restores the registered providers after the test
+func restoreProviders(t *testing.T) {
+	providersMtx.Lock()
+	registered := providers
+	providers = nil
+	providersMtx.Unlock()
+
+	t.Cleanup(func() {
+		providersMtx.Lock()
+		providers = registered
+		providersMtx.Unlock()
+	})
+}
+
+func fakeProviderFactory(name string) ProviderFactory {
+	return func(opts ...GenericProviderOption) (GenericProvider, error) {
+		return &fakeProvider{name: name}, nil
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	restoreProviders(t)
+
+	farm := RegisterProvider("farm", fakeProviderFactory("farm"), nil)
+	other := RegisterProvider("other", fakeProviderFactory("other"), nil)
+	assert.NotEqual(t, farm, other)
+	assert.NotEqual(t, ProviderDocker, farm)
+	assert.NotEqual(t, ProviderPodman, farm)
+
+	provider, err := farm.GetProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "farm", provider.(*fakeProvider).name)
+
+	provider, err = other.GetProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "other", provider.(*fakeProvider).name)
+
+	_, err = (other + 1).GetProvider()
+	assert.EqualError(t, err, "unknown provider")
+
+	assert.Panics(t, func() { RegisterProvider("farm", fakeProviderFactory("farm"), nil) })
+	assert.Panics(t, func() { RegisterProvider("docker", fakeProviderFactory("docker"), nil) })
+	assert.Panics(t, func() { RegisterProvider("nil", nil, nil) })
+}
+
+func TestRegisterProviderFactoryError(t *testing.T) {
+	restoreProviders(t)
+
+	errFactory := errors.New("farm unavailable")
+	farm := RegisterProvider("farm", func(opts ...GenericProviderOption) (GenericProvider, error) {
+		return nil, errFactory
+	}, nil)
+
+	_, err := farm.GetProvider()
+	assert.ErrorIs(t, err, errFactory)
+	assert.ErrorContains(t, err, "failed to create farm provider")
+}
+
+func TestProviderDetectionChain(t *testing.T) {
+	restoreProviders(t)
+
+	var detected []string
+	detector := func(name string, usable bool) ProviderDetector {
+		return func(ctx context.Context) bool {
+			detected = append(detected, name)
+			return usable
+		}
+	}
+
+	RegisterProvider("undetectable", fakeProviderFactory("undetectable"), nil)
+	RegisterProvider("unusable", fakeProviderFactory("unusable"), detector("unusable", false))
+	RegisterProvider("farm", fakeProviderFactory("farm"), detector("farm", true))
+	RegisterProvider("later", fakeProviderFactory("later"), detector("later", true))
+
+	provider, err := ProviderDefault.GetProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "farm", provider.(*fakeProvider).name)
+	assert.Equal(t, []string{"unusable", "farm"}, detected)
+
+	detected = nil
+	provider, err = ProviderDocker.GetProvider()
+	if err == nil {
+		_, isDocker := provider.(*DockerProvider)
+		assert.True(t, isDocker, "an explicit ProviderDocker must not be replaced by a detected provider")
+	}
+	assert.Empty(t, detected, "an explicit ProviderDocker must skip the detection")
+
+	_, err = GenericContainer(context.Background(), GenericContainerRequest{
+		ContainerRequest: ContainerRequest{Image: nginxAlpineImage},
+	})
+	assert.ErrorIs(t, err, errFakeProvider)
+}
+
+func TestGenericContainerWithProvider(t *testing.T) {
+	provider := &fakeProvider{name: "instance"}
+
+	_, err := GenericContainer(context.Background(), GenericContainerRequest{
+		ContainerRequest: ContainerRequest{Image: "redis:6"},
+		ProviderType:     ProviderPodman,
+		Provider:         provider,
+	})
+	assert.ErrorIs(t, err, errFakeProvider, "the provider instance takes precedence over the provider type")
+}
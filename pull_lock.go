@@ -0,0 +1,75 @@
+package testcontainers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pullLockPollInterval is the interval a process waiting for the pull lock of another process checks it at
+const pullLockPollInterval = 100 * time.Millisecond
+
+// pullLockDir returns the directory of the files locking pulls across processes, in the cache directory of the user,
+// e.g. $XDG_CACHE_HOME/testcontainers/pull-locks on Linux
+func pullLockDir() string {
+	return lockDir("pull-locks")
+}
+
+// lockDir returns the directory of the files locking name across processes, in the cache directory of the user.
+// It falls back to the temporary directory if the cache directory is unknown, e.g. if neither $XDG_CACHE_HOME
+// nor $HOME is set in a CI container.
+func lockDir(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "testcontainers", name)
+}
+
+// acquirePullLock locks the pull of the image identified by the key across processes, e.g. the test binaries of
+// the packages run by go test ./..., waiting until the lock is free or the context is done.
+// waited reports whether another process held the lock, which might have pulled the image in the meantime.
+func acquirePullLock(ctx context.Context, key string) (unlock func(), waited bool, err error) {
+	return acquireFileLock(ctx, pullLockDir(), key)
+}
+
+// acquireFileLock locks the key across processes with a file in the directory, waiting until the lock is free
+// or the context is done. waited reports whether another process held the lock.
+func acquireFileLock(ctx context.Context, dir string, key string) (unlock func(), waited bool, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, false, err
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	f, err := os.OpenFile(filepath.Join(dir, hex.EncodeToString(hash[:16])+".lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, false, err
+		}
+		if locked {
+			break
+		}
+
+		waited = true
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, waited, ctx.Err()
+		case <-time.After(pullLockPollInterval):
+		}
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, waited, nil
+}
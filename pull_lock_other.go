@@ -0,0 +1,16 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package testcontainers
+
+import (
+	"os"
+)
+
+// tryLockFile doesn't lock on platforms without file locks, pulls are only deduplicated within the process there
+func tryLockFile(_ *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(_ *os.File) error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package testcontainers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/env"
+)
+
+func TestAcquirePullLock(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("file locks are tested on Linux and macOS")
+	}
+	env.Patch(t, "HOME", t.TempDir())
+	env.Patch(t, "XDG_CACHE_HOME", t.TempDir())
+
+	ctx := context.Background()
+	unlock, waited, err := acquirePullLock(ctx, "redis:6")
+	require.NoError(t, err)
+	assert.False(t, waited)
+
+	other, otherWaited, err := acquirePullLock(ctx, "nginx:alpine")
+	require.NoError(t, err)
+	assert.False(t, otherWaited, "the pulls of other images aren't locked")
+	other()
+
+	timeout, cancel := context.WithTimeout(ctx, 2*pullLockPollInterval)
+	defer cancel()
+	_, _, err = acquirePullLock(timeout, "redis:6")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan bool)
+	go func() {
+		unlock, waited, err := acquirePullLock(ctx, "redis:6")
+		if err == nil {
+			unlock()
+		}
+		acquired <- waited
+	}()
+
+	time.Sleep(2 * pullLockPollInterval)
+	unlock()
+	assert.True(t, <-acquired, "the lock must be acquired once released, after waiting for it")
+}
+
+func TestLockReaperWithoutCacheDir(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the cache directory is derived from $XDG_CACHE_HOME and $HOME on Linux")
+	}
+	env.Patch(t, "XDG_CACHE_HOME", "")
+	env.Patch(t, "HOME", "")
+	env.Patch(t, "TMPDIR", t.TempDir())
+	assert.Equal(t, filepath.Join(os.TempDir(), "testcontainers", "reaper-locks"), lockDir("reaper-locks"))
+
+	provider := &DockerProvider{
+		DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{Logger: TestLogger(t)}},
+		host:                  "unix:///var/run/docker.sock",
+	}
+	unlock, err := provider.lockReaper(context.Background())
+	require.NoError(t, err)
+	assert.DirExists(t, lockDir("reaper-locks"))
+	unlock()
+
+	// the creation of the reaper is only locked within the process if the lock file can't be created
+	notADir := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(notADir, nil, 0o644))
+	env.Patch(t, "TMPDIR", notADir)
+	unlock, err = provider.lockReaper(context.Background())
+	require.NoError(t, err)
+	unlock()
+}
@@ -0,0 +1,145 @@
+package testcontainers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/go-units"
+)
+
+// PullLogMode defines how the progress of pulling images is logged by a DockerProvider
+type PullLogMode string
+
+// possible pull log modes
+const (
+	PullLogQuiet    PullLogMode = "quiet"    // log nothing, the default
+	PullLogProgress PullLogMode = "progress" // log a summary of the progress periodically, e.g. on CI
+	PullLogVerbose  PullLogMode = "verbose"  // log every progress message of the daemon
+)
+
+// defaultPullProgressInterval is the interval the summary of the progress is logged at in PullLogProgress mode
+const defaultPullProgressInterval = 10 * time.Second
+
+var pullProgressInterval = defaultPullProgressInterval
+
+// PullProgressFunc is called with each progress message of the Docker daemon while pulling an image
+type PullProgressFunc func(image string, msg jsonmessage.JSONMessage)
+
+// WithPullProgress registers a function called with each progress message while pulling an image
+func WithPullProgress(fn PullProgressFunc) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.pullProgress = append(opts.pullProgress, fn)
+	})
+}
+
+// WithPullLogMode sets how the progress of pulling images is logged to the logger of the provider
+func WithPullLogMode(mode PullLogMode) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.pullLogMode = mode
+	})
+}
+
+// pullProgress sums up the progress of the layers of an image
+type pullProgress struct {
+	current map[string]int64 // downloaded bytes by layer
+	total   map[string]int64 // size by layer
+	done    map[string]bool  // layers which were pulled or already existed
+}
+
+func newPullProgress() *pullProgress {
+	return &pullProgress{current: map[string]int64{}, total: map[string]int64{}, done: map[string]bool{}}
+}
+
+func (p *pullProgress) update(msg jsonmessage.JSONMessage) {
+	if msg.ID == "" {
+		return
+	}
+
+	switch msg.Status {
+	case "Pulling fs layer", "Waiting":
+		p.done[msg.ID] = false
+	case "Downloading":
+		p.done[msg.ID] = false
+		if msg.Progress != nil {
+			p.current[msg.ID] = msg.Progress.Current
+			p.total[msg.ID] = msg.Progress.Total
+		}
+	case "Download complete", "Verifying Checksum":
+		p.current[msg.ID] = p.total[msg.ID]
+	case "Pull complete", "Already exists":
+		p.done[msg.ID] = true
+		p.current[msg.ID] = p.total[msg.ID]
+	}
+}
+
+// summary returns the number of complete layers, the number of layers, and the downloaded and total bytes
+func (p *pullProgress) summary() (int, int, int64, int64) {
+	var complete int
+	for _, done := range p.done {
+		if done {
+			complete++
+		}
+	}
+
+	var current, total int64
+	for id := range p.total {
+		current += p.current[id]
+		total += p.total[id]
+	}
+	return complete, len(p.done), current, total
+}
+
+// readPullProgress reads the progress messages of pulling the image until the pull is complete,
+// reporting them to the registered functions and logging them according to the pull log mode
+func (p *DockerProvider) readPullProgress(image string, stream io.Reader) error {
+	if p.pullLogMode != "" && p.pullLogMode != PullLogQuiet {
+		p.Logger.Printf("Pulling image %s", image)
+	}
+
+	progress := newPullProgress()
+	lastLog := time.Now()
+	decoder := json.NewDecoder(stream)
+	for {
+		var msg jsonmessage.JSONMessage
+		err := decoder.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		for _, fn := range p.pullProgress {
+			fn(image, msg)
+		}
+
+		switch p.pullLogMode {
+		case PullLogVerbose:
+			if msg.Progress != nil && msg.Progress.Total > 0 {
+				p.Logger.Printf("Pulling image %s: %s %s %s of %s", image, msg.ID, msg.Status,
+					units.HumanSize(float64(msg.Progress.Current)), units.HumanSize(float64(msg.Progress.Total)))
+			} else {
+				p.Logger.Printf("Pulling image %s: %s %s", image, msg.ID, msg.Status)
+			}
+		case PullLogProgress:
+			progress.update(msg)
+			if time.Since(lastLog) >= pullProgressInterval {
+				complete, layers, current, total := progress.summary()
+				p.Logger.Printf("Pulling image %s: %d of %d layers complete, %s of %s downloaded",
+					image, complete, layers, units.HumanSize(float64(current)), units.HumanSize(float64(total)))
+				lastLog = time.Now()
+			}
+		}
+	}
+
+	if p.pullLogMode != "" && p.pullLogMode != PullLogQuiet {
+		p.Logger.Printf("Pulled image %s", image)
+	}
+	return nil
+}
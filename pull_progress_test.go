@@ -0,0 +1,71 @@
+package testcontainers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pullStream is the progress of pulling an image with two layers, one of which already exists
+const pullStream = `{"status":"Pulling from library/redis","id":"6"}
+{"status":"Already exists","id":"a2abf6c4d29d"}
+{"status":"Pulling fs layer","id":"c7a4e4382001"}
+{"status":"Downloading","progressDetail":{"current":1048576,"total":2097152},"id":"c7a4e4382001"}
+{"status":"Download complete","id":"c7a4e4382001"}
+{"status":"Pull complete","id":"c7a4e4382001"}
+{"status":"Status: Downloaded newer image for redis:6"}
+`
+
+// recordingLogger records the logged lines
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestReadPullProgress(t *testing.T) {
+	var statuses []string
+	logger := &recordingLogger{}
+	opts := &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{Logger: logger}}
+	WithPullProgress(func(image string, msg jsonmessage.JSONMessage) {
+		statuses = append(statuses, image+" "+msg.Status)
+	}).ApplyDockerTo(opts)
+
+	p := &DockerProvider{DockerProviderOptions: opts}
+	require.NoError(t, p.readPullProgress("redis:6", strings.NewReader(pullStream)))
+	assert.Len(t, statuses, 7)
+	assert.Equal(t, "redis:6 Pull complete", statuses[5])
+	assert.Empty(t, logger.lines, "nothing is logged in quiet mode")
+
+	t.Run("verbose", func(t *testing.T) {
+		logger.lines = nil
+		WithPullLogMode(PullLogVerbose).ApplyDockerTo(opts)
+
+		require.NoError(t, p.readPullProgress("redis:6", strings.NewReader(pullStream)))
+		assert.Len(t, logger.lines, 9)
+		assert.Equal(t, "Pulling image redis:6: c7a4e4382001 Downloading 1.049MB of 2.097MB", logger.lines[4])
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		pullProgressInterval = 0
+		defer func() { pullProgressInterval = defaultPullProgressInterval }()
+
+		logger.lines = nil
+		WithPullLogMode(PullLogProgress).ApplyDockerTo(opts)
+
+		require.NoError(t, p.readPullProgress("redis:6", strings.NewReader(pullStream)))
+		assert.Equal(t, "Pulling image redis:6: 1 of 2 layers complete, 1.049MB of 2.097MB downloaded", logger.lines[4])
+		assert.Equal(t, "Pulled image redis:6", logger.lines[len(logger.lines)-1])
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := p.readPullProgress("private/app", strings.NewReader(`{"errorDetail":{"message":"pull access denied"},"error":"pull access denied"}`))
+		assert.EqualError(t, err, "pull access denied")
+	})
+}
@@ -3,24 +3,29 @@ package testcontainers
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
 const (
-	TestcontainerLabel          = "org.testcontainers.golang"
-	TestcontainerLabelSessionID = TestcontainerLabel + ".sessionId"
-	TestcontainerLabelIsReaper  = TestcontainerLabel + ".reaper"
-
 	ReaperDefaultImage = "docker.io/testcontainers/ryuk:0.3.4"
 )
 
@@ -28,7 +33,7 @@ type reaperContextKey string
 
 var (
 	dockerHostContextKey = reaperContextKey("docker_host")
-	reaper               *Reaper // We would like to create reaper only once
+	reapers              = map[string]*Reaper{} // We would like to create the reaper only once per daemon
 	mutex                sync.Mutex
 )
 
@@ -44,22 +49,56 @@ func NewReaper(ctx context.Context, sessionID string, provider ReaperProvider, r
 	mutex.Lock()
 	defer mutex.Unlock()
 	// If reaper already exists re-use it
-	if reaper != nil {
+	key := reaperKey(provider)
+	if reaper, ok := reapers[key]; ok {
 		return reaper, nil
 	}
 
+	tcConfig := provider.Config()
+
+	// the test binaries of go test ./... share the reaper of the daemon, which one of them starts
+	if p, ok := provider.(*DockerProvider); ok {
+		unlock, err := p.lockReaper(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to lock the creation of the reaper", err)
+		}
+		defer unlock()
+
+		containerID, endpoint, err := p.findReaper(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if endpoint != "" {
+			reaper := &Reaper{
+				Provider:          provider,
+				SessionID:         sessionID,
+				Endpoint:          endpoint,
+				containerID:       containerID,
+				connectionTimeout: tcConfig.RyukConnectionTimeout,
+			}
+			reapers[key] = reaper
+			return reaper, nil
+		}
+	}
+
 	dockerHost := extractDockerHost(ctx)
 
+	resources, err := reaperResources(tcConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	// Otherwise create a new one
-	reaper = &Reaper{
-		Provider:  provider,
-		SessionID: sessionID,
+	reaper := &Reaper{
+		Provider:          provider,
+		SessionID:         sessionID,
+		connectionTimeout: tcConfig.RyukConnectionTimeout,
 	}
 
 	listeningPort := nat.Port("8080/tcp")
 
 	req := ContainerRequest{
-		Image:        reaperImage(reaperImageName),
+		Image:        reaperImage(reaperImageName, tcConfig),
 		ExposedPorts: []string{string(listeningPort)},
 		NetworkMode:  Bridge,
 		Labels: map[string]string{
@@ -69,6 +108,7 @@ func NewReaper(ctx context.Context, sessionID string, provider ReaperProvider, r
 		Mounts:     Mounts(BindMount(dockerHost, "/var/run/docker.sock")),
 		AutoRemove: true,
 		WaitingFor: wait.ForListeningPort(listeningPort),
+		Resources:  resources,
 	}
 
 	// include reaper-specific labels to the reaper container
@@ -76,12 +116,27 @@ func NewReaper(ctx context.Context, sessionID string, provider ReaperProvider, r
 		req.Labels[k] = v
 	}
 
-	tcConfig := provider.Config()
 	req.Privileged = tcConfig.RyukPrivileged
 
+	// Ryuk uses its own defaults for the timeouts unless they're configured
+	if tcConfig.RyukConnectionTimeout > 0 {
+		req.Env = map[string]string{"RYUK_CONNECTION_TIMEOUT": tcConfig.RyukConnectionTimeout.String()}
+		req.WaitingFor = wait.ForListeningPort(listeningPort).WithStartupTimeout(tcConfig.RyukConnectionTimeout)
+	}
+	if tcConfig.RyukReconnectionTimeout > 0 {
+		if req.Env == nil {
+			req.Env = map[string]string{}
+		}
+		req.Env["RYUK_RECONNECTION_TIMEOUT"] = tcConfig.RyukReconnectionTimeout.String()
+	}
+
 	// Attach reaper container to a requested network if it is specified
 	if p, ok := provider.(*DockerProvider); ok {
-		req.Networks = append(req.Networks, p.DefaultNetwork)
+		defaultNetwork, err := p.defaultNetwork(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Networks = append(req.Networks, defaultNetwork)
 	}
 
 	c, err := provider.RunContainer(ctx, req)
@@ -94,64 +149,353 @@ func NewReaper(ctx context.Context, sessionID string, provider ReaperProvider, r
 		return nil, err
 	}
 	reaper.Endpoint = endpoint
+	reaper.containerID = c.GetContainerID()
+
+	// the reaper container is never terminated, so it must not keep the client of the provider open
+	if dc, ok := c.(*DockerContainer); ok {
+		dc.release()
+	}
 
+	reapers[key] = reaper
 	return reaper, nil
 }
 
+// reaperKey identifies the daemon of the provider, so that each daemon gets a reaper of its own
+func reaperKey(provider ReaperProvider) string {
+	if p, ok := provider.(*DockerProvider); ok {
+		return p.host
+	}
+	return ""
+}
+
+// lockReaper locks the creation of the reaper of the daemon across processes. If the lock file can't be created,
+// e.g. on a read-only file system, the creation is only locked within the process.
+func (p *DockerProvider) lockReaper(ctx context.Context) (unlock func(), err error) {
+	unlock, _, err = acquireFileLock(ctx, lockDir("reaper-locks"), p.host)
+	if err != nil && ctx.Err() == nil {
+		p.Logger.Printf("Failed to lock the creation of the reaper across processes, other test processes might start a reaper of their own: %v", err)
+		return func() {}, nil
+	}
+	return unlock, err
+}
+
+// findReaper returns the ID and the endpoint of a reaper running on the daemon, e.g. one started by another test
+// process, or empty strings if there is none
+func (p *DockerProvider) findReaper(ctx context.Context) (containerID string, endpoint string, err error) {
+	containers, err := p.client.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", TestcontainerLabelIsReaper+"=true"), filters.Arg("status", "running")),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("%w: failed to look up the reaper", err)
+	}
+
+	for _, c := range containers {
+		for _, port := range c.Ports {
+			if port.PrivatePort != 8080 || port.Type != "tcp" || port.PublicPort == 0 {
+				continue
+			}
+
+			host, err := p.daemonHost(ctx)
+			if err != nil {
+				return "", "", err
+			}
+			endpoint := net.JoinHostPort(host, strconv.Itoa(int(port.PublicPort)))
+
+			// the reaper exits shortly after the processes it served disconnected
+			conn, err := net.DialTimeout("tcp", endpoint, time.Second)
+			if err != nil {
+				continue
+			}
+			_ = conn.Close()
+			return c.ID, endpoint, nil
+		}
+	}
+	return "", "", nil
+}
+
+// ErrReaperDisabled is returned by the provider for the reaper if it's disabled by ryuk.disabled
+var ErrReaperDisabled = errors.New("the reaper is disabled by ryuk.disabled")
+
+// Reaper returns the reaper of the daemon, starting it if it isn't running yet,
+// or ErrReaperDisabled if the reaper is disabled
+func (p *DockerProvider) Reaper(ctx context.Context) (*Reaper, error) {
+	if p.config.RyukDisabled {
+		return nil, ErrReaperDisabled
+	}
+	return NewReaper(context.WithValue(ctx, dockerHostContextKey, p.host), SessionID(), p, "")
+}
+
+// RegisterReaperFilter makes the reaper of the daemon remove the containers, networks, volumes and images labeled
+// with all of the labels along with the resources of the session, e.g. the ones the tests create with the Docker
+// client directly. The filter is registered until the process exits. ErrReaperDisabled is returned if the reaper
+// is disabled, as nothing would remove the resources.
+func (p *DockerProvider) RegisterReaperFilter(ctx context.Context, labels map[string]string) error {
+	r, err := p.Reaper(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: creating reaper failed", err)
+	}
+	if _, err := r.ConnectWithFilter(labels); err != nil {
+		return fmt.Errorf("%w: connecting to reaper failed", err)
+	}
+	return nil
+}
+
 // Reaper is used to start a sidecar container that cleans up resources
 type Reaper struct {
 	Provider  ReaperProvider
 	SessionID string
 	Endpoint  string
+
+	containerID       string        // the ID of the reaper container, empty if the provider isn't a DockerProvider
+	connectionTimeout time.Duration // how long to wait for connecting to the reaper, 10s if not set
+
+	mtx           sync.Mutex
+	conn          net.Conn             // the connection shared by the registrations, nil while reconnecting
+	registrations []reaperRegistration // the registrations which aren't terminated yet
 }
 
-// Connect runs a goroutine which can be terminated by sending true into the returned channel
-func (r *Reaper) Connect() (chan bool, error) {
-	conn, err := net.DialTimeout("tcp", r.Endpoint, 10*time.Second)
+// reaperRegistration is a label filter registered with Connect or ConnectWithFilter,
+// until true is sent into its termination signal
+type reaperRegistration struct {
+	filter            string
+	terminationSignal chan bool
+}
+
+// ContainerID returns the ID of the reaper container, e.g. to inspect it when the cleanup misbehaves
+func (r *Reaper) ContainerID() string {
+	return r.containerID
+}
+
+// FollowLogs streams the logs of the reaper container to the logger until the context is done or the reaper exits,
+// showing which filters it received and what it pruned
+func (r *Reaper) FollowLogs(ctx context.Context, logger Logging) error {
+	p, ok := r.Provider.(*DockerProvider)
+	if !ok || r.containerID == "" {
+		return errors.New("the logs of the reaper can only be followed with a DockerProvider")
+	}
+
+	logs, err := p.client.ContainerLogs(ctx, r.containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%w: Connecting to Ryuk on %s failed", err, r.Endpoint)
+		return err
 	}
 
-	terminationSignal := make(chan bool)
-	go func(conn net.Conn) {
-		sock := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
-		defer conn.Close()
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		_ = logs.Close()
+		_ = pw.CloseWithError(err)
+	}()
+	go func() {
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			logger.Printf("Ryuk: %s", scanner.Text())
+		}
+		_ = pr.Close()
+	}()
+	return nil
+}
+
+// reaperHeartbeatInterval is how often the connection to the reaper is checked, well within the
+// reconnection timeout of Ryuk, after which it removes the resources of the session
+var reaperHeartbeatInterval = 2 * time.Second
 
-		labelFilters := []string{}
-		for l, v := range r.Labels() {
-			labelFilters = append(labelFilters, fmt.Sprintf("label=%s=%s", l, v))
+// Connect registers the label filters of the session, until true is sent into the returned channel.
+// All registrations of the reaper share one connection, which a single goroutine checks periodically,
+// reconnecting and registering the label filters again if it dropped.
+func (r *Reaper) Connect() (chan bool, error) {
+	return r.ConnectWithFilter(r.Labels())
+}
+
+// ConnectWithFilter connects to the reaper like Connect, but registers a filter of its own. The resources labeled
+// with all of the labels are removed like the ones of the session, e.g. resources created with the Docker client
+// directly, once the connection is terminated.
+func (r *Reaper) ConnectWithFilter(labels map[string]string) (chan bool, error) {
+	if len(labels) == 0 {
+		return nil, errors.New("the filter of the reaper must have at least one label, it would match all resources otherwise")
+	}
+
+	filter := reaperFilter(labels)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.registrations) == 0 {
+		conn, err := r.dial()
+		if err != nil {
+			return nil, err
 		}
+		r.conn = conn
+	}
+	if r.conn != nil {
+		// a filter which isn't acknowledged is sent again by the heartbeat, on a new connection if needed
+		_ = r.register(r.conn, filter, 3)
+	}
+
+	// buffered, so that terminating never blocks while the heartbeat checks the connection
+	terminationSignal := make(chan bool, 1)
+	r.registrations = append(r.registrations, reaperRegistration{filter: filter, terminationSignal: terminationSignal})
+	if len(r.registrations) == 1 {
+		go r.heartbeat()
+	}
+	return terminationSignal, nil
+}
 
-		retryLimit := 3
-		for retryLimit > 0 {
-			retryLimit--
+// reaperFilter converts the labels into the line registering them as filter with the reaper,
+// which parses it as query string
+func reaperFilter(labels map[string]string) string {
+	labelFilters := make([]string, 0, len(labels))
+	for l, v := range labels {
+		labelFilters = append(labelFilters, "label="+url.QueryEscape(l+"="+v))
+	}
+	sort.Strings(labelFilters)
+	return strings.Join(labelFilters, "&")
+}
 
-			sock.WriteString(strings.Join(labelFilters, "&"))
-			sock.WriteString("\n")
-			if err := sock.Flush(); err != nil {
-				continue
+// connectionTimeoutOrDefault returns how long to wait for connecting to the reaper
+func (r *Reaper) connectionTimeoutOrDefault() time.Duration {
+	if r.connectionTimeout > 0 {
+		return r.connectionTimeout
+	}
+	return 10 * time.Second
+}
+
+func (r *Reaper) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", r.Endpoint, r.connectionTimeoutOrDefault())
+	if err != nil {
+		return nil, fmt.Errorf("%w: Connecting to Ryuk on %s failed", err, r.Endpoint)
+	}
+	return conn, nil
+}
+
+// heartbeat sends the label filters of the registrations again until all of them are terminated, replacing a
+// connection which doesn't acknowledge them with a new one. Failing reconnections are logged with an exponential
+// backoff, i.e. at the 1st, 2nd, 4th, 8th... attempt.
+func (r *Reaper) heartbeat() {
+	ticker := time.NewTicker(reaperHeartbeatInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for range ticker.C {
+		r.mtx.Lock()
+		filters := r.activeFilters()
+		if len(filters) == 0 {
+			if r.conn != nil {
+				r.conn.Close()
+				r.conn = nil
 			}
+			r.mtx.Unlock()
+			return
+		}
 
-			resp, err := sock.ReadString('\n')
-			if err != nil {
-				continue
+		err := errors.New("not connected")
+		if r.conn != nil {
+			err = r.registerAll(r.conn, filters)
+		}
+		if err != nil {
+			if r.conn != nil {
+				r.conn.Close()
+				r.conn = nil
 			}
-			if resp == "ACK\n" {
-				break
+			var conn net.Conn
+			conn, err = r.dial()
+			if err == nil {
+				if err = r.registerAll(conn, filters); err == nil {
+					r.conn = conn
+				} else {
+					conn.Close()
+				}
+			}
+
+			if err == nil {
+				r.log().Printf("Reconnected to Ryuk on %s", r.Endpoint)
+				failures = 0
+			} else {
+				failures++
+				if failures&(failures-1) == 0 {
+					r.log().Printf("Connection to Ryuk on %s lost, the resources of the session might not be cleaned up (attempt %d): %v", r.Endpoint, failures, err)
+				}
 			}
 		}
+		r.mtx.Unlock()
+	}
+}
 
-		<-terminationSignal
-	}(conn)
-	return terminationSignal, nil
+// activeFilters drops the terminated registrations, returning the distinct filters of the remaining ones
+func (r *Reaper) activeFilters() []string {
+	var filters []string
+	seen := map[string]bool{}
+	active := r.registrations[:0]
+	for _, registration := range r.registrations {
+		select {
+		case <-registration.terminationSignal:
+			continue
+		default:
+		}
+
+		active = append(active, registration)
+		if !seen[registration.filter] {
+			seen[registration.filter] = true
+			filters = append(filters, registration.filter)
+		}
+	}
+	r.registrations = active
+	return filters
+}
+
+// registerAll sends each of the label filters to the reaper, until one of them isn't acknowledged
+func (r *Reaper) registerAll(conn net.Conn, filters []string) error {
+	for _, filter := range filters {
+		if err := r.register(conn, filter, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// register sends the label filter to the reaper until it acknowledges it or the attempts are exhausted
+func (r *Reaper) register(conn net.Conn, filter string, attempts int) error {
+	sock := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	var err error
+	for ; attempts > 0; attempts-- {
+		if err = conn.SetDeadline(time.Now().Add(r.connectionTimeoutOrDefault())); err != nil {
+			return err
+		}
+
+		sock.WriteString(filter)
+		sock.WriteString("\n")
+		if err = sock.Flush(); err != nil {
+			continue
+		}
+
+		var resp string
+		resp, err = sock.ReadString('\n')
+		if err != nil {
+			continue
+		}
+		if resp == "ACK\n" {
+			return conn.SetDeadline(time.Time{})
+		}
+		err = fmt.Errorf("unexpected response of Ryuk: %q", resp)
+	}
+	return err
+}
+
+// log returns the logger of the provider of the reaper, or the default one
+func (r *Reaper) log() Logging {
+	if p, ok := r.Provider.(*DockerProvider); ok && p.Logger != nil {
+		return p.Logger
+	}
+	return Logger
 }
 
 // Labels returns the container labels to use so that this Reaper cleans them up
 func (r *Reaper) Labels() map[string]string {
-	return map[string]string{
-		TestcontainerLabel:          "true",
-		TestcontainerLabelSessionID: r.SessionID,
-	}
+	return sessionLabels(r.SessionID)
 }
 
 func extractDockerHost(ctx context.Context) (dockerHostPath string) {
@@ -176,15 +520,69 @@ func extractDockerHost(ctx context.Context) (dockerHostPath string) {
 
 	switch hostURL.Scheme {
 	case "unix":
-		return hostURL.Path
+		return daemonSocketPath(hostURL.Path)
+	case "npipe":
+		// the named pipe of Docker Desktop on Windows forwards to the socket within its Linux VM,
+		// the leading double slash keeps the path from being translated into a Windows path
+		return "/" + dockerHostPath
 	default:
 		return dockerHostPath
 	}
 }
 
-func reaperImage(reaperImageName string) string {
-	if reaperImageName == "" {
-		return ReaperDefaultImage
+// vmSocketPathMarkers identify Docker sockets on the host, which are forwarded to the daemon running within a VM
+// e.g. by Docker Desktop, Colima or Rancher Desktop on macOS. These sockets can't be bind mounted, but the socket within the VM can.
+var vmSocketPathMarkers = []string{
+	"/.docker/run/",
+	"/.docker/desktop/",
+	"/Library/Containers/com.docker.docker/",
+	"/.colima/",
+	"/.rd/",
+}
+
+// daemonSocketPath translates the path of the Docker socket on the host into the path
+// that can be bind mounted into containers as seen by the Docker daemon
+func daemonSocketPath(hostPath string) string {
+	for _, marker := range vmSocketPathMarkers {
+		if strings.Contains(hostPath, marker) {
+			return "/var/run/docker.sock"
+		}
+	}
+	return hostPath
+}
+
+// reaperResources converts the configured memory and CPU limits of the reaper container
+// into the corresponding container resources
+func reaperResources(tcConfig TestContainersConfig) (container.Resources, error) {
+	resources := container.Resources{}
+
+	if tcConfig.RyukMemory != "" {
+		memory, err := units.RAMInBytes(tcConfig.RyukMemory)
+		if err != nil {
+			return resources, fmt.Errorf("%w: invalid reaper memory limit %s", err, tcConfig.RyukMemory)
+		}
+		resources.Memory = memory
+	}
+
+	if tcConfig.RyukCPUs != "" {
+		cpus, err := strconv.ParseFloat(tcConfig.RyukCPUs, 64)
+		if err != nil {
+			return resources, fmt.Errorf("%w: invalid reaper CPU limit %s", err, tcConfig.RyukCPUs)
+		}
+		resources.NanoCPUs = int64(cpus * 1e9)
+	}
+
+	return resources, nil
+}
+
+// reaperImage returns the image of the reaper, which is the one of the request, the one configured by
+// ryuk.container.image or the default image, in that order
+func reaperImage(reaperImageName string, tcConfig TestContainersConfig) string {
+	if reaperImageName != "" {
+		return reaperImageName
+	}
+	if tcConfig.RyukImage != "" {
+		return tcConfig.RyukImage
 	}
-	return reaperImageName
+	return ReaperDefaultImage
 }
@@ -1,12 +1,20 @@
 package testcontainers
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
@@ -38,6 +46,7 @@ func createContainerRequest(customize func(ContainerRequest) ContainerRequest) C
 			TestcontainerLabel:          "true",
 			TestcontainerLabelIsReaper:  "true",
 			TestcontainerLabelSessionID: "sessionId",
+			TestcontainerLabelLang:      TestcontainerLang,
 		},
 		SkipReaper:  true,
 		Mounts:      Mounts(BindMount("/var/run/docker.sock", "/var/run/docker.sock")),
@@ -76,12 +85,41 @@ func Test_NewReaper(t *testing.T) {
 				RyukPrivileged: true,
 			},
 		},
+		{
+			name: "with resource limits",
+			req: createContainerRequest(func(req ContainerRequest) ContainerRequest {
+				req.Resources = container.Resources{
+					Memory:   64 * 1024 * 1024,
+					NanoCPUs: 500000000,
+				}
+				return req
+			}),
+			config: TestContainersConfig{
+				RyukMemory: "64m",
+				RyukCPUs:   "0.5",
+			},
+		},
+		{
+			name: "with timeouts",
+			req: createContainerRequest(func(req ContainerRequest) ContainerRequest {
+				req.Env = map[string]string{
+					"RYUK_CONNECTION_TIMEOUT":   "2m0s",
+					"RYUK_RECONNECTION_TIMEOUT": "30s",
+				}
+				req.WaitingFor = wait.ForListeningPort(nat.Port("8080/tcp")).WithStartupTimeout(2 * time.Minute)
+				return req
+			}),
+			config: TestContainersConfig{
+				RyukConnectionTimeout:   2 * time.Minute,
+				RyukReconnectionTimeout: 30 * time.Second,
+			},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			// make sure we re-initialize the singleton
-			reaper = nil
+			reapers = map[string]*Reaper{}
 			provider := &mockReaperProvider{
 				config: test.config,
 			}
@@ -94,3 +132,340 @@ func Test_NewReaper(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewReaper_InvalidResources(t *testing.T) {
+	reapers = map[string]*Reaper{}
+	provider := &mockReaperProvider{
+		config: TestContainersConfig{
+			RyukMemory: "a lot",
+		},
+	}
+
+	_, err := NewReaper(context.TODO(), "sessionId", provider, "reaperImage")
+	assert.Error(t, err)
+	assert.Empty(t, reapers, "the reaper must not be cached when its configuration is invalid")
+}
+
+func Test_NewReaper_Image(t *testing.T) {
+	config := TestContainersConfig{RyukImage: "registry.example.com/testcontainers/ryuk:0.3.4"}
+
+	tests := []struct {
+		name      string
+		imageName string
+		config    TestContainersConfig
+		want      string
+	}{
+		{name: "default", want: ReaperDefaultImage},
+		{name: "configured", config: config, want: "registry.example.com/testcontainers/ryuk:0.3.4"},
+		{name: "requested", imageName: "reaperImage", config: config, want: "reaperImage"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reapers = map[string]*Reaper{}
+			provider := &mockReaperProvider{
+				config: test.config,
+			}
+
+			_, err := NewReaper(context.TODO(), "sessionId", provider, test.imageName)
+			assert.EqualError(t, err, "expected")
+			assert.Equal(t, test.want, provider.req.Image)
+		})
+	}
+}
+
+func TestReaperReconnects(t *testing.T) {
+	interval := reaperHeartbeatInterval
+	reaperHeartbeatInterval = 10 * time.Millisecond
+	t.Cleanup(func() { reaperHeartbeatInterval = interval })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// a fake Ryuk, which drops the first connection after acknowledging the label filters
+	registrations := make(chan string, 100)
+	go func() {
+		for connections := 0; ; connections++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn, drop bool) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					registrations <- line
+					if _, err := conn.Write([]byte("ACK\n")); err != nil || drop {
+						return
+					}
+				}
+			}(conn, connections == 0)
+		}
+	}()
+
+	r := &Reaper{
+		SessionID: "sessionId",
+		Endpoint:  listener.Addr().String(),
+	}
+	terminationSignal, err := r.Connect()
+	require.NoError(t, err)
+	defer func() { terminationSignal <- true }()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case line := <-registrations:
+			assert.Equal(t, "label=org.testcontainers.golang%3Dtrue&label=org.testcontainers.golang.sessionId%3DsessionId&label=org.testcontainers.lang%3Dgo\n", line)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the label filters weren't sent again after %d registrations", i)
+		}
+	}
+}
+
+func TestReaperSharesConnection(t *testing.T) {
+	interval := reaperHeartbeatInterval
+	reaperHeartbeatInterval = 10 * time.Millisecond
+	t.Cleanup(func() { reaperHeartbeatInterval = interval })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// a fake Ryuk, which counts the connections and reports when one of them is closed
+	connections := make(chan net.Conn, 10)
+	closed := make(chan struct{}, 10)
+	registrations := make(chan string, 1000)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			connections <- conn
+			go func(conn net.Conn) {
+				defer func() { closed <- struct{}{} }()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					registrations <- line
+					if _, err := conn.Write([]byte("ACK\n")); err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	r := &Reaper{
+		SessionID: "sessionId",
+		Endpoint:  listener.Addr().String(),
+	}
+	session, err := r.Connect()
+	require.NoError(t, err)
+	other, err := r.Connect()
+	require.NoError(t, err)
+	custom, err := r.ConnectWithFilter(map[string]string{"com.example.test-run": "1"})
+	require.NoError(t, err)
+
+	// the filters are sent again by one heartbeat, the one of the session only once per beat
+	sent := map[string]int{}
+	for sent["label=com.example.test-run%3D1\n"] < 3 {
+		select {
+		case line := <-registrations:
+			sent[line]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the label filters weren't sent again: %v", sent)
+		}
+	}
+	assert.Len(t, sent, 2)
+	assert.Equal(t, sent["label=com.example.test-run%3D1\n"]+1, sent[reaperFilter(r.Labels())+"\n"], "both connections of the session registered it, but the heartbeat sends it once")
+
+	session <- true
+	other <- true
+	custom <- true
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection must be closed once all registrations are terminated")
+	}
+	assert.Len(t, connections, 1, "all registrations must share one connection")
+}
+
+func TestReaperLogsLostConnectionWithBackoff(t *testing.T) {
+	interval := reaperHeartbeatInterval
+	reaperHeartbeatInterval = 10 * time.Millisecond
+	t.Cleanup(func() { reaperHeartbeatInterval = interval })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = bufio.NewReader(conn).ReadString('\n')
+		_, _ = conn.Write([]byte("ACK\n"))
+		// Ryuk is gone after acknowledging the first filter
+		_ = conn.Close()
+		_ = listener.Close()
+	}()
+
+	logger := make(channelLogger, 100)
+	r := &Reaper{
+		Provider:          &DockerProvider{DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{Logger: logger}}},
+		SessionID:         "sessionId",
+		Endpoint:          listener.Addr().String(),
+		connectionTimeout: time.Second,
+	}
+	terminationSignal, err := r.Connect()
+	require.NoError(t, err)
+	defer func() { terminationSignal <- true }()
+
+	for _, attempt := range []string{"(attempt 1)", "(attempt 2)", "(attempt 4)", "(attempt 8)"} {
+		select {
+		case line := <-logger:
+			assert.Contains(t, line, attempt)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the lost connection wasn't logged at %s", attempt)
+		}
+	}
+}
+
+func TestReaperFilter(t *testing.T) {
+	assert.Equal(t, "label=app%3Dpayments&label=run%3Da%26b", reaperFilter(map[string]string{"run": "a&b", "app": "payments"}))
+
+	_, err := (&Reaper{}).ConnectWithFilter(nil)
+	assert.Error(t, err, "an empty filter would match all resources")
+}
+
+func TestRegisterReaperFilterWithReaperDisabled(t *testing.T) {
+	var paths []string
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte("{}"))
+	})
+	provider.config.RyukDisabled = true
+	paths = nil
+
+	err := provider.RegisterReaperFilter(context.Background(), map[string]string{"com.example.test-run": "1"})
+	assert.ErrorIs(t, err, ErrReaperDisabled)
+	assert.Empty(t, paths, "no reaper must be looked up or started")
+}
+
+func TestFindReaper(t *testing.T) {
+	ryuk, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ryuk.Close()
+	ryukPort := ryuk.Addr().(*net.TCPAddr).Port
+
+	exited, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	exitedPort := exited.Addr().(*net.TCPAddr).Port
+	exited.Close()
+
+	var query string
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.41/containers/json":
+			query = r.URL.Query().Get("filters")
+			_, _ = fmt.Fprintf(w, `[
+				{"Id": "exited", "Ports": [{"PrivatePort": 8080, "PublicPort": %d, "Type": "tcp"}]},
+				{"Id": "ryuk", "Ports": [{"PrivatePort": 8080, "PublicPort": %d, "Type": "tcp"}]}
+			]`, exitedPort, ryukPort)
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+
+	containerID, endpoint, err := provider.findReaper(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ryuk", containerID)
+	assert.Equal(t, ryuk.Addr().String(), endpoint, "the reaper which is still reachable must be used")
+	assert.Contains(t, query, TestcontainerLabelIsReaper+"=true")
+}
+
+// channelLogger sends the logged lines into a channel
+type channelLogger chan string
+
+func (l channelLogger) Printf(format string, v ...interface{}) {
+	l <- fmt.Sprintf(format, v...)
+}
+
+func TestReaperFollowLogs(t *testing.T) {
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.41/containers/ryuk/logs" {
+			_, _ = w.Write([]byte("{}"))
+			return
+		}
+		assert.Equal(t, "1", r.URL.Query().Get("follow"))
+		stdout := stdcopy.NewStdWriter(w, stdcopy.Stdout)
+		_, _ = stdout.Write([]byte("Received the first connection\n"))
+		stderr := stdcopy.NewStdWriter(w, stdcopy.Stderr)
+		_, _ = stderr.Write([]byte("Adding {\"label\":{\"app=payments\":true}}\n"))
+	})
+
+	r := &Reaper{Provider: provider, containerID: "ryuk"}
+	assert.Equal(t, "ryuk", r.ContainerID())
+
+	logger := make(channelLogger, 2)
+	require.NoError(t, r.FollowLogs(context.Background(), logger))
+	assert.Equal(t, "Ryuk: Received the first connection", <-logger)
+	assert.Equal(t, `Ryuk: Adding {"label":{"app=payments":true}}`, <-logger)
+
+	assert.Error(t, (&Reaper{Provider: &mockReaperProvider{}}).FollowLogs(context.Background(), logger))
+}
+
+func Test_ExtractDockerHost(t *testing.T) {
+	type cases struct {
+		name       string
+		dockerHost string
+		want       string
+	}
+
+	tests := []cases{
+		{
+			name: "default",
+			want: "/var/run/docker.sock",
+		},
+		{
+			name:       "unix socket",
+			dockerHost: "unix:///run/user/1000/docker.sock",
+			want:       "/run/user/1000/docker.sock",
+		},
+		{
+			name:       "Docker Desktop on macOS",
+			dockerHost: "unix:///Users/gopher/.docker/run/docker.sock",
+			want:       "/var/run/docker.sock",
+		},
+		{
+			name:       "Docker Desktop on Windows",
+			dockerHost: "npipe:////./pipe/docker_engine",
+			want:       "//var/run/docker.sock",
+		},
+		{
+			name:       "remote daemon",
+			dockerHost: "tcp://127.0.0.1:2375",
+			want:       "/var/run/docker.sock",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), dockerHostContextKey, test.dockerHost)
+
+			assert.Equal(t, test.want, extractDockerHost(ctx))
+		})
+	}
+}
+
+func Test_ReaperKey(t *testing.T) {
+	assert.Equal(t, "tcp://arm64.example.com:2376", reaperKey(&DockerProvider{host: "tcp://arm64.example.com:2376"}))
+	assert.Equal(t, "", reaperKey(&mockReaperProvider{}), "providers without a host share a reaper")
+}
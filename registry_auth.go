@@ -0,0 +1,208 @@
+package testcontainers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// RegistryAuth are the credentials to pull the image of a request from a private registry
+type RegistryAuth struct {
+	Username      string
+	Password      string
+	IdentityToken string // an identity token of the registry, used instead of the username and password
+	ServerAddress string // the address of the registry, defaults to the registry of the image
+}
+
+// encode encodes the credentials like the X-Registry-Auth header of the Docker API
+func (a RegistryAuth) encode() (string, error) {
+	return encodeRegistryAuth(types.AuthConfig{
+		Username:      a.Username,
+		Password:      a.Password,
+		IdentityToken: a.IdentityToken,
+		ServerAddress: a.ServerAddress,
+	})
+}
+
+// dockerAuthConfig is the format of the DOCKER_AUTH_CONFIG environment variable and of the config.json of the
+// Docker CLI, of which only the credentials are used
+type dockerAuthConfig struct {
+	Auths       map[string]types.AuthConfig `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`  // the helper storing the credentials of all registries
+	CredHelpers map[string]string           `json:"credHelpers"` // the helpers storing the credentials by registry
+}
+
+// credentialHelperOutput is what a credential helper returns for a registry
+type credentialHelperOutput struct {
+	Username string
+	Secret   string
+}
+
+// dockerHubRegistries are the keys the credentials of Docker Hub are stored with
+var dockerHubRegistries = []string{"https://index.docker.io/v1/", "index.docker.io", "docker.io", "registry-1.docker.io"}
+
+// registryAuth returns the encoded credentials for the registry of the image. They're looked up in the
+// DOCKER_AUTH_CONFIG environment variable first, then in the config.json of the Docker CLI, including its
+// credential helpers. An empty string is returned if there are no credentials for the registry.
+func registryAuth(image string) (string, error) {
+	registries := dockerHubRegistries
+	if _, ok := dockerHubImageName(image); !ok {
+		registries = []string{image[:strings.Index(image, "/")]}
+	}
+
+	if content := os.Getenv("DOCKER_AUTH_CONFIG"); content != "" {
+		var config dockerAuthConfig
+		if err := json.Unmarshal([]byte(content), &config); err != nil {
+			return "", fmt.Errorf("%w: invalid DOCKER_AUTH_CONFIG", err)
+		}
+
+		auth, found, err := config.lookup(registries)
+		if err != nil {
+			return "", fmt.Errorf("%w: DOCKER_AUTH_CONFIG", err)
+		}
+		if found {
+			return encodeRegistryAuth(auth)
+		}
+	}
+
+	config, err := readDockerConfig()
+	if err != nil {
+		return "", err
+	}
+	auth, found, err := config.lookup(registries)
+	if err != nil || !found {
+		return "", err
+	}
+	return encodeRegistryAuth(auth)
+}
+
+// readDockerConfig reads the config.json of the Docker CLI in DOCKER_CONFIG, ~/.docker by default
+func readDockerConfig() (dockerAuthConfig, error) {
+	var config dockerAuthConfig
+
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return config, nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if err := json.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("%w: invalid Docker config %s", err, filepath.Join(dir, "config.json"))
+	}
+	return config, nil
+}
+
+// lookup returns the credentials for the first of the registries the config has credentials for,
+// asking the credential helpers first like the Docker CLI does
+func (c dockerAuthConfig) lookup(registries []string) (types.AuthConfig, bool, error) {
+	helper := c.CredsStore
+	for key, h := range c.CredHelpers {
+		for _, registry := range registries {
+			if registryHost(key) == registryHost(registry) {
+				helper = h
+			}
+		}
+	}
+	if helper != "" {
+		auth, found, err := credentialsFromHelper(helper, registries[0])
+		if err != nil || found {
+			return auth, found, err
+		}
+	}
+
+	for key, auth := range c.Auths {
+		for _, registry := range registries {
+			if registryHost(key) != registryHost(registry) {
+				continue
+			}
+
+			if auth.Auth != "" && auth.Username == "" {
+				decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+				if err != nil {
+					return auth, false, fmt.Errorf("%w: invalid credentials for %s", err, key)
+				}
+				auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+			}
+			auth.Auth = ""
+			auth.ServerAddress = key
+			return auth, true, nil
+		}
+	}
+
+	return types.AuthConfig{}, false, nil
+}
+
+// credentialsFromHelper asks the credential helper, e.g. desktop or ecr-login, for the credentials of the registry.
+// A registry the helper has no credentials for is not an error, as its images might be public, neither is a helper
+// which isn't installed, e.g. the one of Docker Desktop in a config.json copied to CI.
+func credentialsFromHelper(helper string, registry string) (types.AuthConfig, bool, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return types.AuthConfig{}, false, nil
+		}
+		output := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(output, "credentials not found") {
+			return types.AuthConfig{}, false, nil
+		}
+		return types.AuthConfig{}, false, fmt.Errorf("%w: credential helper %s failed for %s: %s", err, helper, registry, output)
+	}
+
+	var output credentialHelperOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return types.AuthConfig{}, false, fmt.Errorf("%w: invalid output of credential helper %s", err, helper)
+	}
+
+	auth := types.AuthConfig{ServerAddress: registry}
+	if output.Username == "<token>" {
+		// identity tokens are stored with this magic user name
+		auth.IdentityToken = output.Secret
+	} else {
+		auth.Username = output.Username
+		auth.Password = output.Secret
+	}
+	return auth, true, nil
+}
+
+// encodeRegistryAuth encodes the credentials like the X-Registry-Auth header of the Docker API
+func encodeRegistryAuth(auth types.AuthConfig) (string, error) {
+	encoded, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(encoded), nil
+}
+
+// registryHost strips the scheme and the path of a registry address, e.g. https://index.docker.io/v1/ is index.docker.io
+func registryHost(address string) string {
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+	if idx := strings.Index(address, "/"); idx >= 0 {
+		address = address[:idx]
+	}
+	return address
+}
@@ -0,0 +1,121 @@
+package testcontainers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/env"
+)
+
+// decodeRegistryAuth decodes the credentials sent to the Docker daemon
+func decodeRegistryAuth(t *testing.T, encoded string) types.AuthConfig {
+	content, err := base64.URLEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+
+	var auth types.AuthConfig
+	require.NoError(t, json.Unmarshal(content, &auth))
+	return auth
+}
+
+func TestRegistryAuth(t *testing.T) {
+	env.Patch(t, "DOCKER_CONFIG", t.TempDir())
+	env.Patch(t, "DOCKER_AUTH_CONFIG", `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "aHViOnNlY3JldA=="},
+		"registry.example.com:5000": {"username": "ci", "password": "token"}
+	}}`)
+
+	encoded, err := registryAuth("redis:6")
+	require.NoError(t, err)
+	auth := decodeRegistryAuth(t, encoded)
+	assert.Equal(t, "hub", auth.Username)
+	assert.Equal(t, "secret", auth.Password)
+	assert.Equal(t, "https://index.docker.io/v1/", auth.ServerAddress)
+
+	encoded, err = registryAuth("registry.example.com:5000/app:test")
+	require.NoError(t, err)
+	auth = decodeRegistryAuth(t, encoded)
+	assert.Equal(t, "ci", auth.Username)
+	assert.Equal(t, "token", auth.Password)
+
+	encoded, err = registryAuth("quay.io/prometheus/prometheus")
+	require.NoError(t, err)
+	assert.Empty(t, encoded, "registries without credentials are pulled anonymously")
+}
+
+// fakeCredentialHelper is a credential helper knowing the credentials of a single registry
+const fakeCredentialHelper = `#!/bin/sh
+read registry
+case "$registry" in
+  "https://index.docker.io/v1/") echo '{"ServerURL": "https://index.docker.io/v1/", "Username": "<token>", "Secret": "identity"}' ;;
+  "123456789.dkr.ecr.eu-west-1.amazonaws.com") echo '{"Username": "AWS", "Secret": "ecr"}' ;;
+  *) echo "credentials not found in native keychain"; exit 1 ;;
+esac
+`
+
+func TestRegistryAuthFromDockerConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake credential helper is a shell script")
+	}
+
+	bin := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bin, "docker-credential-fake"), []byte(fakeCredentialHelper), 0o755))
+	env.Patch(t, "PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	env.Patch(t, "DOCKER_AUTH_CONFIG", "")
+
+	config := t.TempDir()
+	env.Patch(t, "DOCKER_CONFIG", config)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(config, "config.json"), []byte(`{
+		"auths": {"registry.example.com": {"auth": "Y2k6dG9rZW4="}, "ghcr.io": {}},
+		"credsStore": "missing",
+		"credHelpers": {"123456789.dkr.ecr.eu-west-1.amazonaws.com": "fake", "docker.io": "fake", "ghcr.io": "fake"}
+	}`), 0o600))
+
+	encoded, err := registryAuth("123456789.dkr.ecr.eu-west-1.amazonaws.com/app:test")
+	require.NoError(t, err)
+	auth := decodeRegistryAuth(t, encoded)
+	assert.Equal(t, "AWS", auth.Username)
+	assert.Equal(t, "ecr", auth.Password)
+
+	encoded, err = registryAuth("redis:6")
+	require.NoError(t, err)
+	assert.Equal(t, "identity", decodeRegistryAuth(t, encoded).IdentityToken)
+
+	encoded, err = registryAuth("registry.example.com/app:test")
+	require.NoError(t, err)
+	auth = decodeRegistryAuth(t, encoded)
+	assert.Equal(t, "ci", auth.Username, "the missing credentials store is skipped")
+	assert.Equal(t, "token", auth.Password)
+
+	encoded, err = registryAuth("ghcr.io/app:test")
+	require.NoError(t, err)
+	assert.Equal(t, types.AuthConfig{ServerAddress: "ghcr.io"}, decodeRegistryAuth(t, encoded), "the helper has no credentials for ghcr.io")
+}
+
+func TestRegistryAuthInvalid(t *testing.T) {
+	env.Patch(t, "DOCKER_CONFIG", t.TempDir())
+	env.Patch(t, "DOCKER_AUTH_CONFIG", `{"auths": `)
+	_, err := registryAuth("redis:6")
+	assert.Error(t, err)
+
+	env.Patch(t, "DOCKER_AUTH_CONFIG", `{"auths": {"docker.io": {"auth": "not base64"}}}`)
+	_, err = registryAuth("redis:6")
+	assert.Error(t, err)
+}
+
+func TestRegistryAuthEncode(t *testing.T) {
+	encoded, err := RegistryAuth{Username: "ci", Password: "token", ServerAddress: "registry.example.com"}.encode()
+	require.NoError(t, err)
+	assert.Equal(t, types.AuthConfig{Username: "ci", Password: "token", ServerAddress: "registry.example.com"}, decodeRegistryAuth(t, encoded))
+
+	encoded, err = RegistryAuth{IdentityToken: "identity"}.encode()
+	require.NoError(t, err)
+	assert.Equal(t, types.AuthConfig{IdentityToken: "identity"}, decodeRegistryAuth(t, encoded))
+}
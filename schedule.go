@@ -0,0 +1,117 @@
+package testcontainers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// ScheduledResult is the result of a single execution of a scheduled command
+type ScheduledResult struct {
+	Time     time.Time // when the execution started
+	ExitCode int
+	Output   []byte // combined stdout and stderr of the command
+	Err      error  // error executing the command, not a non-zero exit code
+}
+
+// ScheduledCommand is a handle to a command which is executed repeatedly in a container,
+// until the context passed to Schedule is done or Stop is called
+type ScheduledCommand struct {
+	mtx     sync.Mutex
+	results []ScheduledResult
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// executor executes commands in a container
+type executor interface {
+	Exec(ctx context.Context, cmd []string) (int, io.Reader, error)
+}
+
+// Schedule executes the command in the container every interval until the context is done,
+// e.g. to send keep-alive pings or simulate background jobs during long-running tests.
+// The results of all executions are collected by the returned handle. The interval must be positive.
+func (c *DockerContainer) Schedule(ctx context.Context, interval time.Duration, cmd []string) (*ScheduledCommand, error) {
+	return schedule(ctx, c, interval, cmd)
+}
+
+// ScheduleExec implements Container.Schedule on top of the Exec method of the container,
+// e.g. for implementations of Container by other providers
+func ScheduleExec(ctx context.Context, c Container, interval time.Duration, cmd []string) (*ScheduledCommand, error) {
+	return schedule(ctx, c, interval, cmd)
+}
+
+func schedule(ctx context.Context, e executor, interval time.Duration, cmd []string) (*ScheduledCommand, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("schedule interval must be positive, got %s", interval)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &ScheduledCommand{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.add(executeOnce(ctx, e, cmd))
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// executeOnce executes the command once and reads its output
+func executeOnce(ctx context.Context, e executor, cmd []string) ScheduledResult {
+	result := ScheduledResult{Time: time.Now()}
+
+	exitCode, r, err := e.Exec(ctx, cmd)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.ExitCode = exitCode
+
+	if r != nil {
+		result.Output, result.Err = ioutil.ReadAll(r)
+	}
+
+	return result
+}
+
+func (s *ScheduledCommand) add(result ScheduledResult) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.results = append(s.results, result)
+}
+
+// Results returns the results of all executions so far, in the order of execution
+func (s *ScheduledCommand) Results() []ScheduledResult {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]ScheduledResult(nil), s.results...)
+}
+
+// Done returns a channel which is closed once no more executions are scheduled
+func (s *ScheduledCommand) Done() <-chan struct{} {
+	return s.done
+}
+
+// Stop stops scheduling executions and waits for a running execution to finish
+func (s *ScheduledCommand) Stop() {
+	s.cancel()
+	<-s.done
+}
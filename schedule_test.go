@@ -0,0 +1,64 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingExecutor succeeds on every execution but the second one
+type countingExecutor struct {
+	calls int32
+}
+
+func (e *countingExecutor) Exec(_ context.Context, _ []string) (int, io.Reader, error) {
+	if atomic.AddInt32(&e.calls, 1) == 2 {
+		return 0, nil, errors.New("exec failed")
+	}
+	return 0, strings.NewReader("pong"), nil
+}
+
+func TestSchedule(t *testing.T) {
+	e := &countingExecutor{}
+
+	s, err := schedule(context.Background(), e, 10*time.Millisecond, []string{"ping"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(s.Results()) >= 3 }, time.Second, 10*time.Millisecond)
+	s.Stop()
+
+	results := s.Results()
+	assert.Equal(t, int(atomic.LoadInt32(&e.calls)), len(results), "no execution is scheduled after Stop")
+	assert.Equal(t, []byte("pong"), results[0].Output)
+	assert.EqualError(t, results[1].Err, "exec failed")
+	assert.Equal(t, []byte("pong"), results[2].Output)
+	assert.True(t, results[0].Time.Before(results[2].Time))
+}
+
+func TestScheduleStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s, err := schedule(ctx, &countingExecutor{}, time.Hour, []string{"ping"})
+	require.NoError(t, err)
+	cancel()
+
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("scheduling should stop once the context is done")
+	}
+	assert.Empty(t, s.Results())
+}
+
+func TestScheduleRejectsInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err := schedule(context.Background(), &countingExecutor{}, interval, []string{"ping"})
+		assert.Error(t, err, interval)
+	}
+}
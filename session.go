@@ -1,8 +1,20 @@
 package testcontainers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
 	"github.com/google/uuid"
 )
 
@@ -16,3 +28,136 @@ func sessionID() uuid.UUID {
 
 	return tcSessionID
 }
+
+// ErrSessionKeyNotFound is returned by Session.Get if no value was put for the key
+var ErrSessionKeyNotFound = errors.New("session key not found")
+
+// Session is a key-value store shared by all test processes using the same Docker daemon, e.g. to let the test
+// packages of one `go test ./...` run discover the one shared Postgres started by TestMain of another package.
+// The keys are scoped to a namespace, so that the test runs of different projects sharing a daemon don't see each other's entries.
+// Each value is stored in the labels of a new empty volume, which carries the session labels of the process putting it,
+// so it is cleaned up by the reaper together with the containers of that process.
+// A put never removes the previous value before the new one is stored, so readers always see either of them,
+// and concurrent puts of the same key are last write wins.
+// Values should be small, like addresses or container IDs.
+type Session struct {
+	volumes   client.VolumeAPIClient
+	namespace string
+}
+
+// NewSession returns the key-value store of the namespace on the Docker daemon of the provider,
+// e.g. named after the module of the tests
+func NewSession(provider *DockerProvider, namespace string) *Session {
+	return &Session{volumes: provider.client, namespace: namespace}
+}
+
+// entry returns the identifier of the key within the namespace of the session
+func (s *Session) entry(key string) string {
+	sum := sha256.Sum256([]byte(s.namespace + "\x00" + key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// revisions lists the volumes storing values of the key, the latest revision first
+func (s *Session) revisions(ctx context.Context, key string) ([]*types.Volume, error) {
+	list, err := s.volumes.VolumeList(ctx, filters.NewArgs(
+		filters.Arg("label", TestcontainerLabelSessionEntry+"="+s.entry(key)),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*types.Volume, 0, len(list.Volumes))
+	for _, v := range list.Volumes {
+		// guard against hash collisions
+		if v.Labels[TestcontainerLabelSessionNamespace] == s.namespace && v.Labels[TestcontainerLabelSessionKey] == key {
+			revisions = append(revisions, v)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		ri, rj := sessionRevision(revisions[i]), sessionRevision(revisions[j])
+		if ri != rj {
+			return ri > rj
+		}
+		return revisions[i].Name > revisions[j].Name
+	})
+	return revisions, nil
+}
+
+// sessionRevision returns the revision of the value stored in the volume
+func sessionRevision(v *types.Volume) int64 {
+	revision, _ := strconv.ParseInt(v.Labels[TestcontainerLabelSessionRevision], 10, 64)
+	return revision
+}
+
+// remove removes the volumes, volumes removed concurrently are skipped
+func (s *Session) remove(ctx context.Context, volumes []*types.Volume) error {
+	for _, v := range volumes {
+		if err := s.volumes.VolumeRemove(ctx, v.Name, true); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put stores the value for the key, replacing a previous value
+func (s *Session) Put(ctx context.Context, key string, value string) error {
+	revision := time.Now().UnixNano()
+
+	labels := SessionLabels()
+	for k, v := range StandardLabels() {
+		labels[k] = v
+	}
+	labels[TestcontainerLabelSessionNamespace] = s.namespace
+	labels[TestcontainerLabelSessionEntry] = s.entry(key)
+	labels[TestcontainerLabelSessionKey] = key
+	labels[TestcontainerLabelSessionValue] = value
+	labels[TestcontainerLabelSessionRevision] = strconv.FormatInt(revision, 10)
+
+	_, err := s.volumes.VolumeCreate(ctx, volume.VolumeCreateBody{
+		Name:   "testcontainers-session-" + s.entry(key) + "-" + uuid.NewString(),
+		Labels: labels,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: putting session key %s", err, key)
+	}
+
+	// the new value is visible, so the older ones can be removed
+	revisions, err := s.revisions(ctx, key)
+	if err != nil {
+		return fmt.Errorf("%w: putting session key %s", err, key)
+	}
+	var outdated []*types.Volume
+	for _, v := range revisions {
+		if sessionRevision(v) < revision {
+			outdated = append(outdated, v)
+		}
+	}
+	if err := s.remove(ctx, outdated); err != nil {
+		return fmt.Errorf("%w: putting session key %s", err, key)
+	}
+	return nil
+}
+
+// Get returns the value stored for the key, or ErrSessionKeyNotFound
+func (s *Session) Get(ctx context.Context, key string) (string, error) {
+	revisions, err := s.revisions(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("%w: getting session key %s", err, key)
+	}
+	if len(revisions) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrSessionKeyNotFound, key)
+	}
+	return revisions[0].Labels[TestcontainerLabelSessionValue], nil
+}
+
+// Delete removes the key, deleting a key which doesn't exist is not an error
+func (s *Session) Delete(ctx context.Context, key string) error {
+	revisions, err := s.revisions(ctx, key)
+	if err == nil {
+		err = s.remove(ctx, revisions)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: deleting session key %s", err, key)
+	}
+	return nil
+}
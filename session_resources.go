@@ -0,0 +1,102 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// ListSessionContainers returns the containers created in the current test session, including the stopped ones,
+// e.g. to assert that a test terminated all its containers. The reaper of the session isn't included.
+func (p *DockerProvider) ListSessionContainers(ctx context.Context) ([]types.Container, error) {
+	containers, err := p.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: SessionFilters(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]types.Container, 0, len(containers))
+	for _, c := range containers {
+		if c.Labels[TestcontainerLabelIsReaper] == "true" {
+			continue
+		}
+		listed = append(listed, c)
+	}
+	return listed, nil
+}
+
+// ListSessionNetworks returns the networks created in the current test session
+func (p *DockerProvider) ListSessionNetworks(ctx context.Context) ([]types.NetworkResource, error) {
+	return p.client.NetworkList(ctx, types.NetworkListOptions{
+		Filters: SessionFilters(),
+	})
+}
+
+// ListSessionVolumes returns the volumes created in the current test session,
+// which includes the volumes storing the entries put into the Session by this process
+func (p *DockerProvider) ListSessionVolumes(ctx context.Context) ([]*types.Volume, error) {
+	volumes, err := p.client.VolumeList(ctx, SessionFilters())
+	if err != nil {
+		return nil, err
+	}
+	return volumes.Volumes, nil
+}
+
+// Prune removes all containers, networks, volumes and built images of the test session, e.g. in the teardown of
+// TestMain or to recover when the reaper was disabled or died. Resources which can't be removed are skipped and
+// reported in the returned error. The reaper isn't removed, it stops on its own once all its clients disconnected.
+func (p *DockerProvider) Prune(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return errors.New("session ID mustn't be empty")
+	}
+	sessionFilters := LabelFilters(sessionLabels(sessionID))
+
+	var failed []string
+	fail := func(kind string, id string, err error) {
+		if err != nil && !client.IsErrNotFound(err) {
+			failed = append(failed, fmt.Sprintf("%s %s: %v", kind, id, err))
+		}
+	}
+
+	containers, err := p.client.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: sessionFilters})
+	fail("containers", "list", err)
+	for _, c := range containers {
+		if c.Labels[TestcontainerLabelIsReaper] == "true" {
+			continue
+		}
+		fail("container", c.ID, p.client.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{
+			RemoveVolumes: true,
+			Force:         true,
+		}))
+	}
+
+	networks, err := p.client.NetworkList(ctx, types.NetworkListOptions{Filters: sessionFilters})
+	fail("networks", "list", err)
+	for _, n := range networks {
+		fail("network", n.ID, p.client.NetworkRemove(ctx, n.ID))
+	}
+
+	volumes, err := p.client.VolumeList(ctx, sessionFilters)
+	fail("volumes", "list", err)
+	for _, v := range volumes.Volumes {
+		fail("volume", v.Name, p.client.VolumeRemove(ctx, v.Name, true))
+	}
+
+	images, err := p.client.ImageList(ctx, types.ImageListOptions{All: true, Filters: sessionFilters})
+	fail("images", "list", err)
+	for _, i := range images {
+		_, err := p.client.ImageRemove(ctx, i.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+		fail("image", i.ID, err)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("pruning session %s failed: %s", sessionID, strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package testcontainers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerProvider_ListSessionResources(t *testing.T) {
+	var queries []filters.Args
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+
+		if f := r.URL.Query().Get("filters"); f != "" {
+			args, err := filters.FromJSON(f)
+			require.NoError(t, err)
+			queries = append(queries, args)
+		}
+
+		switch r.URL.Path {
+		case "/v1.41/containers/json":
+			assert.Equal(t, "1", r.URL.Query().Get("all"))
+			_, _ = w.Write([]byte(`[
+				{"Id": "app", "Labels": {"org.testcontainers.golang": "true"}},
+				{"Id": "reaper", "Labels": {"org.testcontainers.golang": "true", "org.testcontainers.golang.reaper": "true"}}
+			]`))
+		case "/v1.41/networks":
+			_, _ = w.Write([]byte(`[{"Id": "network"}]`))
+		case "/v1.41/volumes":
+			_, _ = w.Write([]byte(`{"Volumes": [{"Name": "volume"}]}`))
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+
+	ctx := context.Background()
+
+	containers, err := provider.ListSessionContainers(ctx)
+	require.NoError(t, err)
+	require.Len(t, containers, 1, "the reaper must not be listed")
+	assert.Equal(t, "app", containers[0].ID)
+
+	networks, err := provider.ListSessionNetworks(ctx)
+	require.NoError(t, err)
+	require.Len(t, networks, 1)
+	assert.Equal(t, "network", networks[0].ID)
+
+	volumes, err := provider.ListSessionVolumes(ctx)
+	require.NoError(t, err)
+	require.Len(t, volumes, 1)
+	assert.Equal(t, "volume", volumes[0].Name)
+
+	require.Len(t, queries, 3)
+	for _, q := range queries {
+		assert.True(t, q.ExactMatch("label", TestcontainerLabelSessionID+"="+SessionID()), "resources must be filtered by the session")
+	}
+}
+
+func TestDockerProvider_Prune(t *testing.T) {
+	var mtx sync.Mutex
+	var removed []string
+	provider := fakeDaemonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method == http.MethodDelete {
+			mtx.Lock()
+			removed = append(removed, r.URL.Path)
+			mtx.Unlock()
+
+			if r.URL.Path == "/v1.41/networks/gone" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "no such network"}`))
+				return
+			}
+			if r.URL.Path == "/v1.41/volumes/in-use" {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"message": "volume is in use"}`))
+				return
+			}
+			if strings.HasPrefix(r.URL.Path, "/v1.41/images/") {
+				_, _ = w.Write([]byte("[]"))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+		require.NoError(t, err)
+		assert.True(t, args.ExactMatch("label", TestcontainerLabelSessionID+"=other-session"))
+
+		switch r.URL.Path {
+		case "/v1.41/containers/json":
+			_, _ = w.Write([]byte(`[
+				{"Id": "app"},
+				{"Id": "reaper", "Labels": {"org.testcontainers.golang.reaper": "true"}}
+			]`))
+		case "/v1.41/networks":
+			_, _ = w.Write([]byte(`[{"Id": "network"}, {"Id": "gone"}]`))
+		case "/v1.41/volumes":
+			_, _ = w.Write([]byte(`{"Volumes": [{"Name": "in-use"}]}`))
+		case "/v1.41/images/json":
+			_, _ = w.Write([]byte(`[{"Id": "sha256:built"}]`))
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+
+	assert.Error(t, provider.Prune(context.Background(), ""), "an empty session ID must be rejected")
+
+	err := provider.Prune(context.Background(), "other-session")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "volume in-use")
+	assert.NotContains(t, err.Error(), "gone", "resources removed in the meantime must be ignored")
+
+	assert.Equal(t, []string{
+		"/v1.41/containers/app",
+		"/v1.41/networks/network",
+		"/v1.41/networks/gone",
+		"/v1.41/volumes/in-use",
+		"/v1.41/images/sha256:built",
+	}, removed)
+}
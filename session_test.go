@@ -0,0 +1,114 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVolumes keeps volumes in memory
+type fakeVolumes struct {
+	client.VolumeAPIClient
+	volumes   map[string]types.Volume
+	createErr error
+}
+
+func (f *fakeVolumes) VolumeCreate(_ context.Context, options volume.VolumeCreateBody) (types.Volume, error) {
+	if f.createErr != nil {
+		return types.Volume{}, f.createErr
+	}
+	if _, ok := f.volumes[options.Name]; ok {
+		return types.Volume{}, errdefs.Conflict(errors.New("volume exists"))
+	}
+	v := types.Volume{Name: options.Name, Labels: options.Labels}
+	f.volumes[options.Name] = v
+	return v, nil
+}
+
+func (f *fakeVolumes) VolumeInspect(_ context.Context, volumeID string) (types.Volume, error) {
+	v, ok := f.volumes[volumeID]
+	if !ok {
+		return types.Volume{}, errdefs.NotFound(errors.New("no such volume"))
+	}
+	return v, nil
+}
+
+func (f *fakeVolumes) VolumeList(_ context.Context, filter filters.Args) (volume.VolumeListOKBody, error) {
+	var list volume.VolumeListOKBody
+	for _, v := range f.volumes {
+		v := v
+		matches := true
+		for _, label := range filter.Get("label") {
+			k, value, _ := strings.Cut(label, "=")
+			if v.Labels[k] != value {
+				matches = false
+			}
+		}
+		if matches {
+			list.Volumes = append(list.Volumes, &v)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeVolumes) VolumeRemove(_ context.Context, volumeID string, _ bool) error {
+	if _, ok := f.volumes[volumeID]; !ok {
+		return errdefs.NotFound(errors.New("no such volume"))
+	}
+	delete(f.volumes, volumeID)
+	return nil
+}
+
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+	volumes := &fakeVolumes{volumes: map[string]types.Volume{}}
+	session := &Session{volumes: volumes, namespace: "testcontainers-go"}
+
+	_, err := session.Get(ctx, "postgres")
+	assert.ErrorIs(t, err, ErrSessionKeyNotFound)
+
+	require.NoError(t, session.Put(ctx, "postgres", "localhost:49153"))
+	require.NoError(t, session.Put(ctx, "redis", "localhost:49154"))
+
+	value, err := session.Get(ctx, "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:49153", value)
+
+	require.NoError(t, session.Put(ctx, "postgres", "localhost:49155"))
+	value, err = session.Get(ctx, "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:49155", value)
+	assert.Len(t, volumes.volumes, 2, "previous values should be removed")
+	for _, v := range volumes.volumes {
+		assert.Equal(t, SessionID(), v.Labels[TestcontainerLabelSessionID], "entries should be cleaned up with the session")
+	}
+
+	volumes.createErr = errors.New("daemon unavailable")
+	assert.Error(t, session.Put(ctx, "postgres", "localhost:49156"))
+	volumes.createErr = nil
+	value, err = session.Get(ctx, "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:49155", value, "a failed put must keep the previous value")
+
+	other := &Session{volumes: volumes, namespace: "other"}
+	_, err = other.Get(ctx, "postgres")
+	assert.ErrorIs(t, err, ErrSessionKeyNotFound, "keys must be scoped to the namespace")
+
+	require.NoError(t, session.Delete(ctx, "postgres"))
+	require.NoError(t, session.Delete(ctx, "postgres"))
+	_, err = session.Get(ctx, "postgres")
+	assert.ErrorIs(t, err, ErrSessionKeyNotFound)
+
+	value, err = session.Get(ctx, "redis")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:49154", value)
+}
@@ -0,0 +1,68 @@
+package testcontainers
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// signalCleanupTimeout is how long removing the resources of the session may take once the process was interrupted
+const signalCleanupTimeout = 30 * time.Second
+
+// exitAfterSignal ends the process after the cleanup, like the signal would have without a handler
+var exitAfterSignal = func(sig os.Signal) {
+	signal.Reset(sig)
+	if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+		// give the default handler the chance to end the process with the status of the signal
+		time.Sleep(time.Second)
+	}
+	os.Exit(2)
+}
+
+// EnableSignalCleanup installs a handler of SIGINT and SIGTERM, which removes the containers, networks, volumes and
+// built images of the test session before the process exits, e.g. when go test is interrupted with Ctrl-C. Without it,
+// the reaper removes them only after its reconnection timeout. It should be called once, e.g. in TestMain.
+// The provider removing the resources is created with the options, the returned function uninstalls the handler.
+func EnableSignalCleanup(opts ...DockerProviderOption) (disable func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			cleanupSession(opts...)
+			exitAfterSignal(sig)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// cleanupSession removes the resources of the session, logging the failures as the process is exiting anyway
+func cleanupSession(opts ...DockerProviderOption) {
+	ctx, cancel := context.WithTimeout(context.Background(), signalCleanupTimeout)
+	defer cancel()
+
+	provider, err := NewDockerProvider(opts...)
+	if err != nil {
+		Logger.Printf("Failed to clean up session %s: %v", SessionID(), err)
+		return
+	}
+	defer provider.Close()
+
+	provider.Logger.Printf("Interrupted, removing the resources of session %s", SessionID())
+	if err := provider.Prune(ctx, SessionID()); err != nil {
+		provider.Logger.Printf("Failed to clean up session %s: %v", SessionID(), err)
+	}
+}
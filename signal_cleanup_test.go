@@ -0,0 +1,59 @@
+//go:build !windows
+
+package testcontainers
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableSignalCleanup(t *testing.T) {
+	var mtx sync.Mutex
+	var removed []string
+	server := fakeDaemonServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			mtx.Lock()
+			removed = append(removed, r.URL.Path)
+			mtx.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1.41/containers/json":
+			_, _ = w.Write([]byte(`[{"Id": "app"}]`))
+		case "/v1.41/networks", "/v1.41/images/json":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+	cli := fakeDaemonClient(t, server, "1.41")
+
+	exited := make(chan os.Signal, 1)
+	exit := exitAfterSignal
+	exitAfterSignal = func(sig os.Signal) { exited <- sig }
+	t.Cleanup(func() { exitAfterSignal = exit })
+
+	disable := EnableSignalCleanup(WithDockerClient(cli), WithLogger(TestLogger(t)))
+	defer disable()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case sig := <-exited:
+		assert.Equal(t, syscall.SIGTERM, sig)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session wasn't cleaned up")
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Equal(t, []string{"/v1.41/containers/app"}, removed)
+}
@@ -2,6 +2,12 @@ package testcontainers
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -20,3 +26,72 @@ func SkipIfProviderIsNotHealthy(t *testing.T) {
 		t.Skipf("Docker is not running. TestContainers can't perform is work without it: %s", err)
 	}
 }
+
+// ExtractFiles copies files from a (ready) container to temporary files on the host.
+// The spec maps a name to the path of the file within the container, e.g. "ca" -> "/certs/ca.pem".
+// The returned map contains the host path of every extracted file under the same name.
+// The files are stored in a temporary directory which is removed once the test finishes.
+// The names must be relative paths which stay within that directory, e.g. "certs/ca.pem" but not "../ca.pem".
+// This is a convenience method to get hold of CA certificates, generated passwords or kubeconfigs.
+func (c *DockerContainer) ExtractFiles(ctx context.Context, tb testing.TB, spec map[string]string) (map[string]string, error) {
+	tb.Helper()
+	return ExtractFilesFrom(ctx, tb, c, spec)
+}
+
+// ExtractFilesFrom implements Container.ExtractFiles on top of the CopyFileFromContainer method of the container,
+// e.g. for implementations of Container by other providers
+func ExtractFilesFrom(ctx context.Context, tb testing.TB, c Container, spec map[string]string) (map[string]string, error) {
+	tb.Helper()
+
+	for name := range spec {
+		if !isLocalPath(name) {
+			return nil, fmt.Errorf("invalid name %q: must be a relative path within the temporary directory", name)
+		}
+	}
+
+	dir := tb.TempDir()
+	paths := make(map[string]string, len(spec))
+
+	for name, containerPath := range spec {
+		hostPath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := extractFile(ctx, c, containerPath, hostPath); err != nil {
+			return nil, fmt.Errorf("%w: failed to extract %s", err, containerPath)
+		}
+		paths[name] = hostPath
+	}
+
+	return paths, nil
+}
+
+// isLocalPath reports whether the slash separated path is relative and doesn't escape its parent directory,
+// like filepath.IsLocal does in later versions of Go.
+func isLocalPath(name string) bool {
+	if name == "" || strings.Contains(name, `\`) || strings.Contains(name, ":") {
+		return false
+	}
+	cleaned := path.Clean(name)
+	return !path.IsAbs(cleaned) && cleaned != "." && cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
+func extractFile(ctx context.Context, c Container, containerPath string, hostPath string) error {
+	if err := os.MkdirAll(filepath.Dir(hostPath), 0o755); err != nil {
+		return err
+	}
+
+	reader, err := c.CopyFileFromContainer(ctx, containerPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	f, err := os.OpenFile(hostPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, reader); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
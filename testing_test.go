@@ -1,7 +1,28 @@
 package testcontainers
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func ExampleSkipIfProviderIsNotHealthy() {
 	SkipIfProviderIsNotHealthy(&testing.T{})
 }
+
+func TestExtractFilesRejectsPathTraversal(t *testing.T) {
+	c := &DockerContainer{}
+
+	for _, name := range []string{"", ".", "..", "../ca.pem", "certs/../../ca.pem", "/etc/ca.pem", `..\ca.pem`, "C:ca.pem"} {
+		_, err := c.ExtractFiles(context.Background(), t, map[string]string{name: "/certs/ca.pem"})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestIsLocalPath(t *testing.T) {
+	assert.True(t, isLocalPath("ca.pem"))
+	assert.True(t, isLocalPath("certs/ca.pem"))
+	assert.True(t, isLocalPath("certs/../ca.pem"))
+	assert.False(t, isLocalPath("certs/../../ca.pem"))
+}
@@ -14,8 +14,9 @@ type ExecStrategy struct {
 	cmd            []string
 
 	// additional properties
-	ExitCodeMatcher func(exitCode int) bool
-	PollInterval    time.Duration
+	ExitCodeMatcher      func(exitCode int) bool
+	PollInterval         time.Duration
+	ConsecutiveSuccesses int // number of checks that must succeed in a row, defaults to 1
 }
 
 // NewExecStrategy constructs an Exec strategy ...
@@ -48,6 +49,13 @@ func (ws *ExecStrategy) WithPollInterval(pollInterval time.Duration) *ExecStrate
 	return ws
 }
 
+// WithConsecutiveSuccesses requires the command to succeed n times in a row,
+// so flappy services are only considered ready once they stay ready
+func (ws *ExecStrategy) WithConsecutiveSuccesses(n int) *ExecStrategy {
+	ws.ConsecutiveSuccesses = n
+	return ws
+}
+
 // ForExec is a convenience method to assign ExecStrategy
 func ForExec(cmd []string) *ExecStrategy {
 	return NewExecStrategy(cmd)
@@ -58,6 +66,7 @@ func (ws ExecStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget
 	ctx, cancelContext := context.WithTimeout(ctx, ws.startupTimeout)
 	defer cancelContext()
 
+	successes := 0
 	for {
 		select {
 		case <-ctx.Done():
@@ -68,6 +77,12 @@ func (ws ExecStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget
 				return err
 			}
 			if !ws.ExitCodeMatcher(exitCode) {
+				successes = 0
+				continue
+			}
+
+			successes++
+			if successes < requiredSuccesses(ws.ConsecutiveSuccesses) {
 				continue
 			}
 
@@ -132,3 +132,35 @@ func TestExecStrategyWaitUntilReady_CustomExitCode(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// mockSequenceExecTarget returns the given exit codes in order, repeating the last one
+type mockSequenceExecTarget struct {
+	mockExecTarget
+	exitCodes []int
+	calls     int
+}
+
+func (st *mockSequenceExecTarget) Exec(_ context.Context, _ []string) (int, io.Reader, error) {
+	i := st.calls
+	if i >= len(st.exitCodes) {
+		i = len(st.exitCodes) - 1
+	}
+	st.calls++
+	return st.exitCodes[i], nil, nil
+}
+
+func TestExecStrategyWaitUntilReady_ConsecutiveSuccesses(t *testing.T) {
+	target := &mockSequenceExecTarget{
+		exitCodes: []int{0, 1, 0, 0, 0},
+	}
+	wg := wait.NewExecStrategy([]string{"true"}).
+		WithPollInterval(10 * time.Millisecond).
+		WithConsecutiveSuccesses(3)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.calls != 5 {
+		t.Fatalf("expected 5 executions, got %d", target.calls)
+	}
+}
@@ -22,6 +22,8 @@ type HostPortStrategy struct {
 	// all WaitStrategies should have a startupTimeout to avoid waiting infinitely
 	startupTimeout time.Duration
 	PollInterval   time.Duration
+	// number of connection attempts that must succeed in a row, defaults to 1
+	ConsecutiveSuccesses int
 }
 
 // NewHostPortStrategy constructs a default host port strategy
@@ -60,6 +62,13 @@ func (hp *HostPortStrategy) WithPollInterval(pollInterval time.Duration) *HostPo
 	return hp
 }
 
+// WithConsecutiveSuccesses requires n connection attempts in a row to succeed,
+// so flappy services are only considered ready once they stay ready
+func (hp *HostPortStrategy) WithConsecutiveSuccesses(n int) *HostPortStrategy {
+	hp.ConsecutiveSuccesses = n
+	return hp
+}
+
 // WaitUntilReady implements Strategy.WaitUntilReady
 func (hp *HostPortStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget) (err error) {
 	// limit context to startupTimeout
@@ -154,6 +163,22 @@ func (hp *HostPortStrategy) WaitUntilReady(ctx context.Context, target StrategyT
 		}
 	}
 
+	// the port was reachable once, make sure it stays reachable
+	for successes := 1; successes < requiredSuccesses(hp.ConsecutiveSuccesses); {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitInterval):
+			conn, err := dialer.DialContext(ctx, proto, address)
+			if err != nil {
+				successes = 0
+				continue
+			}
+			_ = conn.Close()
+			successes++
+		}
+	}
+
 	return nil
 }
 
@@ -34,6 +34,8 @@ type HTTPStrategy struct {
 	Method            string      // http method
 	Body              io.Reader   // http request body
 	PollInterval      time.Duration
+	// number of requests that must succeed in a row, defaults to 1
+	ConsecutiveSuccesses int
 }
 
 // NewHTTPStrategy constructs a HTTP strategy waiting on port 80 and status code 200
@@ -109,6 +111,13 @@ func (ws *HTTPStrategy) WithPollInterval(pollInterval time.Duration) *HTTPStrate
 	return ws
 }
 
+// WithConsecutiveSuccesses requires n requests in a row to match,
+// so flappy services are only considered ready once they stay ready
+func (ws *HTTPStrategy) WithConsecutiveSuccesses(n int) *HTTPStrategy {
+	ws.ConsecutiveSuccesses = n
+	return ws
+}
+
 // ForHTTP is a convenience method similar to Wait.java
 // https://github.com/testcontainers/testcontainers-java/blob/1d85a3834bd937f80aad3a4cec249c027f31aeb4/core/src/main/java/org/testcontainers/containers/wait/strategy/Wait.java
 func ForHTTP(path string) *HTTPStrategy {
@@ -195,6 +204,7 @@ func (ws *HTTPStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 		}
 	}
 
+	successes := 0
 	for {
 		select {
 		case <-ctx.Done():
@@ -204,20 +214,33 @@ func (ws *HTTPStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 			if err != nil {
 				return err
 			}
-			resp, err := client.Do(req)
-			if err != nil {
-				continue
-			}
-			if ws.StatusCodeMatcher != nil && !ws.StatusCodeMatcher(resp.StatusCode) {
-				continue
-			}
-			if ws.ResponseMatcher != nil && !ws.ResponseMatcher(resp.Body) {
+			if !ws.check(client, req) {
+				successes = 0
 				continue
 			}
-			if err := resp.Body.Close(); err != nil {
+
+			successes++
+			if successes < requiredSuccesses(ws.ConsecutiveSuccesses) {
 				continue
 			}
 			return nil
 		}
 	}
 }
+
+// check sends the request and reports whether the response matches
+func (ws *HTTPStrategy) check(client http.Client, req *http.Request) bool {
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if ws.StatusCodeMatcher != nil && !ws.StatusCodeMatcher(resp.StatusCode) {
+		return false
+	}
+	if ws.ResponseMatcher != nil && !ws.ResponseMatcher(resp.Body) {
+		return false
+	}
+	return true
+}
@@ -5,15 +5,20 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -126,3 +131,64 @@ func TestHTTPStrategyWaitUntilReady(t *testing.T) {
 		return
 	}
 }
+
+// mockHTTPTarget exposes a server running on the local host
+type mockHTTPTarget struct {
+	port nat.Port
+}
+
+func (st mockHTTPTarget) Host(_ context.Context) (string, error) {
+	return "127.0.0.1", nil
+}
+
+func (st mockHTTPTarget) Ports(_ context.Context) (nat.PortMap, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (st mockHTTPTarget) MappedPort(_ context.Context, _ nat.Port) (nat.Port, error) {
+	return st.port, nil
+}
+
+func (st mockHTTPTarget) Logs(_ context.Context) (io.ReadCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (st mockHTTPTarget) Exec(_ context.Context, _ []string) (int, io.Reader, error) {
+	return 0, nil, errors.New("not implemented")
+}
+
+func (st mockHTTPTarget) State(_ context.Context) (*types.ContainerState, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestHTTPStrategyWaitUntilReady_ConsecutiveSuccesses(t *testing.T) {
+	// the server flaps once after the first successful request
+	statuses := []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusOK, http.StatusOK, http.StatusOK}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := requests
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		requests++
+		w.WriteHeader(statuses[i])
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := wait.ForHTTP("/").
+		WithPollInterval(10 * time.Millisecond).
+		WithStartupTimeout(5 * time.Second).
+		WithConsecutiveSuccesses(3)
+	err = wg.WaitUntilReady(context.Background(), mockHTTPTarget{port: nat.Port(port + "/tcp")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 5 {
+		t.Fatalf("expected 5 requests, got %d", requests)
+	}
+}
@@ -0,0 +1,168 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// Implement interface
+var _ Strategy = (*TLSStrategy)(nil)
+
+// TLSStrategy will wait until a TLS handshake succeeds on the given port.
+// The certificate chain presented by the server is captured and can be read
+// afterwards using PeerCertificates, e.g. to trust a self-signed certificate
+// that the service generated at boot.
+type TLSStrategy struct {
+	// all Strategies should have a startupTimeout to avoid waiting infinitely
+	startupTimeout time.Duration
+
+	// additional properties
+	Port         nat.Port
+	TLSConfig    *tls.Config // TLS config used for the handshake, the server certificate is not verified by default
+	MinNotAfter  time.Time   // the leaf certificate must be valid at least until this point in time
+	PollInterval time.Duration
+
+	mtx          sync.Mutex
+	certificates []*x509.Certificate
+}
+
+// NewTLSStrategy constructs a TLS strategy waiting for a successful handshake on the given port
+func NewTLSStrategy(port nat.Port) *TLSStrategy {
+	return &TLSStrategy{
+		startupTimeout: defaultStartupTimeout(),
+		Port:           port,
+		PollInterval:   defaultPollInterval(),
+	}
+}
+
+// fluent builders for each property
+// since go has neither covariance nor generics, the return type must be the type of the concrete implementation
+// this is true for all properties, even the "shared" ones like startupTimeout
+
+// ForTLSHandshake is the default construction for the fluid interface.
+//
+// For Example:
+// wait.
+//     ForTLSHandshake("443/tcp").
+//     WithMinNotAfter(time.Now().Add(24 * time.Hour))
+func ForTLSHandshake(port nat.Port) *TLSStrategy {
+	return NewTLSStrategy(port)
+}
+
+// WithStartupTimeout can be used to change the default startup timeout
+func (ws *TLSStrategy) WithStartupTimeout(startupTimeout time.Duration) *TLSStrategy {
+	ws.startupTimeout = startupTimeout
+	return ws
+}
+
+// WithPollInterval can be used to override the default polling interval of 100 milliseconds
+func (ws *TLSStrategy) WithPollInterval(pollInterval time.Duration) *TLSStrategy {
+	ws.PollInterval = pollInterval
+	return ws
+}
+
+// WithTLSConfig can be used to verify the presented certificate against e.g. a known CA or server name
+func (ws *TLSStrategy) WithTLSConfig(config *tls.Config) *TLSStrategy {
+	ws.TLSConfig = config
+	return ws
+}
+
+// WithMinNotAfter requires the leaf certificate to be valid at least until notAfter
+func (ws *TLSStrategy) WithMinNotAfter(notAfter time.Time) *TLSStrategy {
+	ws.MinNotAfter = notAfter
+	return ws
+}
+
+// PeerCertificates returns the certificate chain presented by the server during
+// the last successful handshake, the leaf certificate comes first
+func (ws *TLSStrategy) PeerCertificates() []*x509.Certificate {
+	ws.mtx.Lock()
+	defer ws.mtx.Unlock()
+
+	return ws.certificates
+}
+
+// WaitUntilReady implements Strategy.WaitUntilReady
+func (ws *TLSStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget) (err error) {
+	// limit context to startupTimeout
+	ctx, cancelContext := context.WithTimeout(ctx, ws.startupTimeout)
+	defer cancelContext()
+
+	ipAddress, err := target.Host(ctx)
+	if err != nil {
+		return
+	}
+
+	var port nat.Port
+	port, err = target.MappedPort(ctx, ws.Port)
+
+	for port == "" {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s:%w", ctx.Err(), err)
+		case <-time.After(ws.PollInterval):
+			port, err = target.MappedPort(ctx, ws.Port)
+		}
+	}
+
+	if port.Proto() != "tcp" {
+		return fmt.Errorf("cannot perform a TLS handshake on non-TCP port %s", port)
+	}
+
+	config := &tls.Config{InsecureSkipVerify: true}
+	if ws.TLSConfig != nil {
+		config = ws.TLSConfig.Clone()
+	}
+
+	address := net.JoinHostPort(ipAddress, port.Port())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s:%w", ctx.Err(), err)
+		case <-time.After(ws.PollInterval):
+			var certs []*x509.Certificate
+			certs, err = ws.handshake(ctx, address, config)
+			if err != nil {
+				continue
+			}
+
+			ws.mtx.Lock()
+			ws.certificates = certs
+			ws.mtx.Unlock()
+
+			return nil
+		}
+	}
+}
+
+func (ws *TLSStrategy) handshake(ctx context.Context, address string, config *tls.Config) ([]*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: time.Second},
+		Config:    config,
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate presented by %s", address)
+	}
+
+	if !ws.MinNotAfter.IsZero() && certs[0].NotAfter.Before(ws.MinNotAfter) {
+		return nil, fmt.Errorf("certificate presented by %s expires at %s, before %s", address, certs[0].NotAfter, ws.MinNotAfter)
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,113 @@
+package wait_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func ExampleTLSStrategy() {
+	ctx := context.Background()
+	strategy := wait.ForTLSHandshake("443/tcp").
+		WithMinNotAfter(time.Now().Add(24 * time.Hour))
+
+	req := testcontainers.ContainerRequest{
+		Image:        "docker.io/nginx:alpine",
+		ExposedPorts: []string{"443/tcp"},
+		WaitingFor:   strategy,
+	}
+
+	nginx, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	defer nginx.Terminate(ctx) // nolint: errcheck
+	// Here you have a running container, trusting strategy.PeerCertificates()
+}
+
+type mockTLSTarget struct {
+	address string
+}
+
+func (st mockTLSTarget) Host(_ context.Context) (string, error) {
+	host, _, err := net.SplitHostPort(st.address)
+	return host, err
+}
+
+func (st mockTLSTarget) Ports(_ context.Context) (nat.PortMap, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (st mockTLSTarget) MappedPort(_ context.Context, _ nat.Port) (nat.Port, error) {
+	_, port, err := net.SplitHostPort(st.address)
+	if err != nil {
+		return "", err
+	}
+	return nat.NewPort("tcp", port)
+}
+
+func (st mockTLSTarget) Logs(_ context.Context) (io.ReadCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (st mockTLSTarget) Exec(_ context.Context, _ []string) (int, io.Reader, error) {
+	return 0, nil, errors.New("not implemented")
+}
+
+func (st mockTLSTarget) State(_ context.Context) (*types.ContainerState, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestTLSStrategyWaitUntilReady(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	target := mockTLSTarget{address: server.Listener.Addr().String()}
+	wg := wait.ForTLSHandshake("443/tcp").
+		WithStartupTimeout(5 * time.Second)
+
+	if err := wg.WaitUntilReady(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+
+	certs := wg.PeerCertificates()
+	if len(certs) == 0 {
+		t.Fatal("expected the peer certificates to be captured")
+	}
+	if !certs[0].Equal(server.Certificate()) {
+		t.Error("captured certificate doesn't match the server certificate")
+	}
+}
+
+func TestTLSStrategyWaitUntilReady_MinNotAfter(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	target := mockTLSTarget{address: server.Listener.Addr().String()}
+	wg := wait.ForTLSHandshake("443/tcp").
+		WithStartupTimeout(500 * time.Millisecond).
+		WithMinNotAfter(server.Certificate().NotAfter.Add(time.Hour))
+
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err == nil {
+		t.Fatal("expected the strategy to time out because the certificate expires too early")
+	}
+	if len(wg.PeerCertificates()) != 0 {
+		t.Error("expected no certificates to be captured")
+	}
+}
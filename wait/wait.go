@@ -29,3 +29,11 @@ func defaultStartupTimeout() time.Duration {
 func defaultPollInterval() time.Duration {
 	return 100 * time.Millisecond
 }
+
+// requiredSuccesses returns the number of consecutive successful checks a strategy waits for, at least one
+func requiredSuccesses(consecutiveSuccesses int) int {
+	if consecutiveSuccesses < 1 {
+		return 1
+	}
+	return consecutiveSuccesses
+}